@@ -0,0 +1,169 @@
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Code is the machine-readable identifier returned to clients. Values are
+// part of the public API and must not change once released.
+type Code string
+
+const (
+	CodeBadRequest       Code = "BAD_REQUEST"
+	CodeValidation       Code = "VALIDATION_FAILED"
+	CodeUnauthorized     Code = "UNAUTHORIZED"
+	CodeForbidden        Code = "FORBIDDEN"
+	CodeNotFound         Code = "NOT_FOUND"
+	CodeConflict         Code = "CONFLICT"
+	CodeInvalidReference Code = "INVALID_REFERENCE"
+	CodeTooLarge         Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMedia Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeTooManyRequests  Code = "TOO_MANY_REQUESTS"
+	CodeInternal         Code = "INTERNAL_ERROR"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+	// RetryAfter tells the client when to try again; zero leaves it out.
+	RetryAfter time.Duration
+}
+
+func New(code Code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Wrap keeps err as the cause so it can still be logged or matched with
+// errors.Is, while clients only see code and message.
+func Wrap(err error, code Code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func BadRequest(message string) *Error {
+	return New(CodeBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Conflict(field, message string) *Error {
+	return New(CodeConflict, message).WithField(field, "unique", message)
+}
+
+func Validation(fields ...FieldError) *Error {
+	message := "validation failed"
+	if len(fields) == 1 {
+		message = fields[0].Message
+	}
+
+	return &Error{
+		Code:    CodeValidation,
+		Message: message,
+		Fields:  fields,
+	}
+}
+
+func TooManyRequests(message string, retryAfter time.Duration) *Error {
+	return &Error{
+		Code:       CodeTooManyRequests,
+		Message:    message,
+		RetryAfter: retryAfter,
+	}
+}
+
+func Internal(err error) *Error {
+	return Wrap(err, CodeInternal, "internal server error")
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) WithField(field, rule, message string) *Error {
+	copied := *e
+	copied.Fields = append(append([]FieldError{}, e.Fields...), FieldError{
+		Field:   field,
+		Rule:    rule,
+		Message: message,
+	})
+
+	return &copied
+}
+
+// As returns the first *Error in err's chain, converting anything else into
+// an internal error.
+func As(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	if fieldErr := FromValidator(err); fieldErr != nil {
+		return fieldErr
+	}
+
+	return Internal(err)
+}
+
+func HasCode(err error, code Code) bool {
+	var appErr *Error
+
+	return errors.As(err, &appErr) && appErr.Code == code
+}
+
+func HTTPStatus(code Code) int {
+	switch code {
+	case CodeBadRequest, CodeValidation, CodeInvalidReference:
+		return http.StatusBadRequest
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case CodeUnsupportedMedia:
+		return http.StatusUnsupportedMediaType
+	case CodeTooManyRequests:
+		return http.StatusTooManyRequests
+	}
+
+	return http.StatusInternalServerError
+}
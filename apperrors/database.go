@@ -0,0 +1,87 @@
+package apperrors
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+)
+
+// Postgres SQLSTATE codes, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgInvalidText         = "22P02"
+	pgNumericOutOfRange   = "22003"
+)
+
+var detailKeyPattern = regexp.MustCompile(`^Key \(([^)]+)\)`)
+
+// FromDatabase converts gorm and Postgres errors into typed errors. Errors it
+// does not recognise are returned unchanged.
+func FromDatabase(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Wrap(err, CodeNotFound, "record not found")
+	}
+	if validationErr := FromValidator(err); validationErr != nil {
+		return validationErr
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		field := constraintField(pgErr)
+		return &Error{
+			Code:    CodeConflict,
+			Message: field + " is duplicated",
+			Fields:  []FieldError{{Field: field, Rule: "unique", Message: field + " is duplicated"}},
+			Err:     err,
+		}
+	case pgForeignKeyViolation:
+		field := constraintField(pgErr)
+		return &Error{
+			Code:    CodeInvalidReference,
+			Message: field + " does not reference an existing record",
+			Fields:  []FieldError{{Field: field, Rule: "exists", Message: field + " does not reference an existing record"}},
+			Err:     err,
+		}
+	case pgNotNullViolation:
+		return &Error{
+			Code:    CodeValidation,
+			Message: pgErr.ColumnName + " is required",
+			Fields:  []FieldError{{Field: pgErr.ColumnName, Rule: "required", Message: pgErr.ColumnName + " is required"}},
+			Err:     err,
+		}
+	case pgInvalidText, pgNumericOutOfRange:
+		return Wrap(err, CodeBadRequest, "invalid value")
+	}
+
+	return err
+}
+
+// constraintField finds the column behind a constraint violation, first from
+// the "Key (column)=(value)" detail and then from gorm's idx_<table>_<column>
+// index naming.
+func constraintField(pgErr *pgconn.PgError) string {
+	if match := detailKeyPattern.FindStringSubmatch(pgErr.Detail); match != nil {
+		return match[1]
+	}
+
+	prefix := "idx_" + pgErr.TableName + "_"
+	if strings.HasPrefix(pgErr.ConstraintName, prefix) {
+		return strings.TrimPrefix(pgErr.ConstraintName, prefix)
+	}
+
+	return pgErr.ConstraintName
+}
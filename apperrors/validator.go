@@ -0,0 +1,44 @@
+package apperrors
+
+import (
+	"errors"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// FromValidator converts govalidator errors into a validation error with one
+// entry per failing field, or returns nil if err did not come from govalidator.
+func FromValidator(err error) *Error {
+	var validationErrs govalidator.Errors
+	if errors.As(err, &validationErrs) {
+		return Validation(validatorFields(validationErrs)...)
+	}
+
+	var validationErr govalidator.Error
+	if errors.As(err, &validationErr) {
+		return Validation(validatorFields(govalidator.Errors{validationErr})...)
+	}
+
+	return nil
+}
+
+func validatorFields(errs govalidator.Errors) []FieldError {
+	var fields []FieldError
+
+	for _, err := range errs {
+		switch e := err.(type) {
+		case govalidator.Errors:
+			fields = append(fields, validatorFields(e)...)
+		case govalidator.Error:
+			fields = append(fields, FieldError{
+				Field:   e.Name,
+				Rule:    e.Validator,
+				Message: e.Error(),
+			})
+		default:
+			fields = append(fields, FieldError{Message: err.Error()})
+		}
+	}
+
+	return fields
+}
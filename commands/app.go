@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"final-project-golang/config"
+	"final-project-golang/database"
+	"final-project-golang/helpers"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
+	"final-project-golang/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// app holds the wiring shared by every command that talks to the database.
+type app struct {
+	cfg      *config.Config
+	db       *gorm.DB
+	repos    repositories.Repositories
+	keyring  *helpers.Keyring
+	migrator *database.Migrator
+	store    storage.Storage
+}
+
+func newApp(configPath string) (*app, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring, err := helpers.LoadKeyring(cfg.JWT)
+	if err != nil {
+		return nil, err
+	}
+	helpers.InitToken(keyring, time.Duration(cfg.JWT.AccessTTL), time.Duration(cfg.JWT.RefreshTTL))
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.Check(); err != nil {
+		return nil, err
+	}
+
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &app{
+		cfg:      cfg,
+		db:       db,
+		repos:    repositories.NewRepositories(db),
+		keyring:  keyring,
+		migrator: migrator,
+		store:    store,
+	}, nil
+}
+
+func (a *app) userService() *services.UserService {
+	return services.NewUserService(a.repos, nil, 0)
+}
+
+// findUser looks a user up by id or by email.
+func (a *app) findUser(id uint, email string) (uint, error) {
+	if id != 0 {
+		user, err := a.userService().Get(id)
+		if err != nil {
+			return 0, err
+		}
+		return user.Id, nil
+	}
+
+	user, err := a.userService().GetByEmail(email)
+	if err != nil {
+		return 0, err
+	}
+
+	return user.Id, nil
+}
+
+func (a *app) close() {
+	if sqlDB, err := a.db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
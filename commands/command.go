@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "start the HTTP server (default)", runServe},
+	{"migrate", "apply or roll back schema migrations", runMigrate},
+	{"seed", "fill the database with demo data", runSeed},
+	{"user", "create, disable, reset the password of or change the role of a user", runUser},
+	{"token", "issue tokens for debugging", runToken},
+	{"purge", "hard delete rows past the deletion retention", runPurge},
+}
+
+// Run dispatches args (without the program name) to a subcommand. Running
+// without arguments starts the server.
+func Run(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runServe(args)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	if args[0] != "help" && args[0] != "-h" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	}
+	usage()
+
+	return flag.ErrHelp
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// newFlagSet returns a flag set that already has the shared -config flag.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+
+	return flags, configPath
+}
+
+// subcommand picks the action of commands such as `user create`.
+func subcommand(args []string, usage string) (string, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", nil, fmt.Errorf("usage: %s", usage)
+	}
+
+	return args[0], args[1:], nil
+}
+
+// ErrorMessage formats err for the terminal, listing every invalid field.
+func ErrorMessage(err error) string {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || len(appErr.Fields) == 0 {
+		return err.Error()
+	}
+
+	lines := []string{appErr.Message}
+	for _, field := range appErr.Fields {
+		lines = append(lines, fmt.Sprintf("  %s: %s", field.Field, field.Message))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"final-project-golang/config"
+	"final-project-golang/database"
+	"fmt"
+	"strconv"
+)
+
+const migrateUsage = "migrate [-config FILE] <up|down|status|to VERSION>"
+
+func runMigrate(args []string) error {
+	flags, configPath := newFlagSet("migrate")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	action, rest, err := subcommand(flags.Args(), migrateUsage)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		return migrator.Up()
+	case "down":
+		return migrator.Down()
+	case "to":
+		if len(rest) == 0 {
+			return fmt.Errorf("usage: migrate to VERSION")
+		}
+		version, err := strconv.ParseUint(rest[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", rest[0])
+		}
+		return migrator.To(uint(version))
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("usage: %s", migrateUsage)
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"final-project-golang/services"
+	"fmt"
+	"time"
+)
+
+func runPurge(args []string) error {
+	flags, configPath := newFlagSet("purge")
+	olderThan := flags.Duration("older-than", 0, "purge rows deleted longer ago than this, defaults to deletion.purge_after")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(*configPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	if *olderThan <= 0 {
+		*olderThan = time.Duration(a.cfg.Deletion.PurgeAfter)
+	}
+
+	result, err := services.NewPurgeService(a.repos, a.store).Purge(time.Now().Add(-*olderThan))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("purged %d users, %d photos, %d comments and %d social media\n",
+		result.Users, result.Photos, result.Comments, result.Socials)
+
+	return nil
+}
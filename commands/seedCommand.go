@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"final-project-golang/apperrors"
+	"final-project-golang/models"
+	"final-project-golang/services"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+var (
+	seedFirstNames = []string{"Andi", "Budi", "Citra", "Dewi", "Eka", "Fajar", "Gita", "Hendra", "Indah", "Joko", "Kartika", "Lukman", "Maya", "Nanda", "Oki", "Putri", "Rizky", "Sari", "Tono", "Wulan"}
+	seedLastNames  = []string{"Pratama", "Saputra", "Wijaya", "Lestari", "Hidayat", "Nugroho", "Kusuma", "Santoso", "Permata", "Siregar"}
+	seedPlaces     = []string{"Bromo", "Raja Ampat", "Ubud", "Labuan Bajo", "Toba", "Malioboro", "Kawah Ijen", "Bukittinggi", "Gili Trawangan", "Dieng"}
+	seedMoments    = []string{"Sunrise at", "Weekend in", "Lost in", "Morning walk around", "Golden hour at", "Rainy day in", "Road trip to", "Coffee break in"}
+	seedCaptions   = []string{"Worth the early alarm.", "Would go back in a heartbeat.", "No filter needed.", "The view did all the work.", "", "Best trip this year.", "Shot on my phone, believe it or not."}
+	seedComments   = []string{"Wow, this is stunning!", "Adding this to my list.", "What camera did you use?", "The colours are unreal.", "I was there last year, still miss it.", "Great shot!", "How long did the hike take?", "This made my day."}
+	seedPlatforms  = [][2]string{{"Instagram", "https://instagram.com/"}, {"Twitter", "https://twitter.com/"}, {"GitHub", "https://github.com/"}, {"LinkedIn", "https://linkedin.com/in/"}, {"TikTok", "https://tiktok.com/@"}}
+)
+
+func runSeed(args []string) error {
+	flags, configPath := newFlagSet("seed")
+	users := flags.Int("users", 10, "number of users to create")
+	photos := flags.Int("photos", 3, "photos per user")
+	comments := flags.Int("comments", 2, "comments per photo")
+	password := flags.String("password", "password123", "password of every seeded user")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "random seed, for reproducible data")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(*configPath)
+	if err != nil {
+		return err
+	}
+
+	seeder := &seeder{
+		random:         rand.New(rand.NewSource(*seed)),
+		userService:    a.userService(),
+		photoService:   services.NewPhotoService(a.repos, a.store, nil),
+		commentService: services.NewCommentService(a.repos),
+		socialService:  services.NewSocialService(a.repos),
+	}
+
+	created, err := seeder.users(*users, *password)
+	if err != nil {
+		return err
+	}
+
+	photoIds, err := seeder.photos(created, *photos)
+	if err != nil {
+		return err
+	}
+
+	if err := seeder.comments(created, photoIds, *comments); err != nil {
+		return err
+	}
+
+	if err := seeder.socials(created); err != nil {
+		return err
+	}
+
+	fmt.Printf("seeded %d users, %d photos and %d comments (seed %d, password %q)\n", len(created), len(photoIds), len(photoIds)**comments, *seed, *password)
+
+	return nil
+}
+
+type seeder struct {
+	random         *rand.Rand
+	userService    *services.UserService
+	photoService   *services.PhotoService
+	commentService *services.CommentService
+	socialService  *services.SocialService
+}
+
+func (s *seeder) pick(values []string) string {
+	return values[s.random.Intn(len(values))]
+}
+
+func (s *seeder) users(count int, password string) ([]models.User, error) {
+	users := make([]models.User, 0, count)
+	now := time.Now()
+
+	for len(users) < count {
+		first, last := s.pick(seedFirstNames), s.pick(seedLastNames)
+		username := fmt.Sprintf("%s.%s%d", strings.ToLower(first), strings.ToLower(last), s.random.Intn(1000))
+
+		user := models.User{
+			Username: username,
+			Email:    username + "@example.com",
+			Password: password,
+			Age:      18 + s.random.Intn(45),
+
+			EmailVerifiedAt: &now,
+		}
+
+		err := s.userService.Register(&user)
+		if apperrors.HasCode(err, apperrors.CodeConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (s *seeder) photos(users []models.User, perUser int) ([]uint, error) {
+	var ids []uint
+
+	for _, user := range users {
+		for i := 0; i < perUser; i++ {
+			photo := models.Photo{
+				Title:    s.pick(seedMoments) + " " + s.pick(seedPlaces),
+				Caption:  s.pick(seedCaptions),
+				PhotoUrl: fmt.Sprintf("https://picsum.photos/seed/%d/1200/800", s.random.Int63()),
+				UserId:   user.Id,
+			}
+			if err := s.photoService.Create(&photo); err != nil {
+				return nil, err
+			}
+			ids = append(ids, photo.Id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *seeder) comments(users []models.User, photoIds []uint, perPhoto int) error {
+	for _, photoId := range photoIds {
+		for i := 0; i < perPhoto; i++ {
+			comment := models.Comment{
+				Message: s.pick(seedComments),
+				PhotoId: photoId,
+				UserId:  users[s.random.Intn(len(users))].Id,
+			}
+			if err := s.commentService.Create(&comment); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *seeder) socials(users []models.User) error {
+	for _, user := range users {
+		for _, platform := range seedPlatforms {
+			if s.random.Intn(2) == 0 {
+				continue
+			}
+			social := models.Social{
+				Name:           platform[0],
+				SocialMediaUrl: platform[1] + user.Username,
+				UserId:         user.Id,
+			}
+			if err := s.socialService.Create(&social); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"final-project-golang/controllers"
+	"final-project-golang/mailer"
+	"final-project-golang/oidc"
+	"final-project-golang/ratelimit"
+	"final-project-golang/routes"
+	"final-project-golang/services"
+	"log"
+	"net/http"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+const photoQueueSize = 256
+
+func runServe(args []string) error {
+	flags, configPath := newFlagSet("serve")
+	addr := flags.String("addr", "", "address to listen on, overrides server.addr")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(*configPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	if *addr != "" {
+		a.cfg.Server.Addr = *addr
+	}
+
+	healthController := controllers.NewHealthController(map[string]controllers.ReadinessCheck{
+		"database": func(ctx context.Context) error {
+			sqlDB, err := a.db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+		"migrations": func(ctx context.Context) error {
+			return a.migrator.Check()
+		},
+	})
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	processor := services.NewPhotoProcessor(a.repos, a.store, photoQueueSize)
+	if err := processor.Start(workerCtx, photoWorkers()); err != nil {
+		return err
+	}
+
+	purger := services.NewPurgeService(a.repos, a.store)
+	go purger.Run(workerCtx, time.Duration(a.cfg.Deletion.PurgeInterval), time.Duration(a.cfg.Deletion.PurgeAfter))
+
+	limits, err := ratelimit.New(a.cfg.RateLimit)
+	if err != nil {
+		return err
+	}
+
+	mail, err := mailer.New(a.cfg.Mail)
+	if err != nil {
+		return err
+	}
+
+	router := routes.NewRouter(routes.Dependencies{
+		Repos:          a.repos,
+		Keyring:        a.keyring,
+		Storage:        a.store,
+		PhotoProcessor: processor,
+		Health:         healthController,
+		MaxUploadSize:  a.cfg.Storage.MaxUploadSize,
+		RestoreWindow:  time.Duration(a.cfg.Deletion.RestoreWindow),
+		RateLimits:     limits,
+		Mailer:         mail,
+
+		PasswordResetTTL: time.Duration(a.cfg.Password.ResetTTL),
+		PasswordResetURL: a.cfg.Password.ResetURL,
+		Verification:     a.cfg.Verification,
+		TwoFactor:        a.cfg.TwoFactor,
+		OIDC:             oidc.New(a.cfg.OIDC),
+		OIDCStateTTL:     time.Duration(a.cfg.OIDC.StateTTL),
+		TrustedProxies:   a.cfg.Server.TrustedProxies,
+	})
+
+	server := &http.Server{
+		Addr:              a.cfg.Server.Addr,
+		Handler:           router,
+		ReadTimeout:       time.Duration(a.cfg.Server.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(a.cfg.Server.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(a.cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(a.cfg.Server.IdleTimeout),
+	}
+
+	err = serve(server, healthController, time.Duration(a.cfg.Server.DrainDelay), time.Duration(a.cfg.Server.ShutdownTimeout))
+
+	// Photos still queued, or cut off mid-render, stay pending and are picked
+	// up on the next start.
+	stopWorkers()
+	processor.Wait()
+
+	return err
+}
+
+func photoWorkers() int {
+	if runtime.NumCPU() < 4 {
+		return runtime.NumCPU()
+	}
+
+	return 4
+}
+
+// serve runs server until SIGINT or SIGTERM. It then reports draining on
+// /readyz for drainDelay while still serving, stops accepting connections
+// and waits up to shutdownTimeout for requests in flight.
+func serve(server *http.Server, healthController *controllers.HealthController, drainDelay, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", server.Addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	// From here a second signal kills the process.
+	stop()
+
+	healthController.SetDraining()
+	if drainDelay > 0 {
+		log.Printf("shutting down, reporting not ready for %s", drainDelay)
+		select {
+		case <-time.After(drainDelay):
+		case err := <-errs:
+			return err
+		}
+	}
+
+	log.Printf("shutting down, draining for up to %s", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	log.Printf("server stopped")
+
+	return nil
+}
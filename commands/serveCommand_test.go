@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"final-project-golang/controllers"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServeReportsDrainingBeforeClosing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	health := controllers.NewHealthController(nil)
+	router := gin.New()
+	router.GET("/readyz", health.Readyz)
+	server := &http.Server{Addr: addr, Handler: router}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(server, health, 300*time.Millisecond, time.Second)
+	}()
+
+	readyz := func() int {
+		resp, err := http.Get("http://" + addr + "/readyz")
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	waitFor := func(status int) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if readyz() == status {
+				return
+			}
+		}
+		t.Fatalf("/readyz never answered %d", status)
+	}
+
+	waitFor(http.StatusOK)
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	// Still listening, but no longer ready.
+	waitFor(http.StatusServiceUnavailable)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("serve: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return")
+	}
+	if status := readyz(); status != 0 {
+		t.Fatalf("/readyz answered %d after shutdown", status)
+	}
+}
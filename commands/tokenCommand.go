@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"encoding/json"
+	"final-project-golang/controllers"
+	"fmt"
+	"os"
+)
+
+const tokenUsage = "token issue -id ID | -email EMAIL"
+
+func runToken(args []string) error {
+	action, rest, err := subcommand(args, tokenUsage)
+	if err != nil || action != "issue" {
+		return fmt.Errorf("usage: %s", tokenUsage)
+	}
+
+	flags, configPath := newFlagSet("token issue")
+	id := flags.Uint("id", 0, "user id")
+	email := flags.String("email", "", "user email, used when -id is not set")
+	if err := flags.Parse(rest); err != nil {
+		return err
+	}
+	if *id == 0 && *email == "" {
+		return fmt.Errorf("usage: %s", tokenUsage)
+	}
+
+	a, err := newApp(*configPath)
+	if err != nil {
+		return err
+	}
+
+	userId, err := a.findUser(*id, *email)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := a.userService().IssueTokens(userId)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(controllers.UserTokenResponse{
+		Token:        tokens.Token,
+		ExpiresAt:    tokens.ExpiresAt,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"final-project-golang/controllers"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"fmt"
+	"time"
+)
+
+const userUsage = "user <create|disable|reset-password|role> [flags]"
+
+func runUser(args []string) error {
+	action, rest, err := subcommand(args, userUsage)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "create":
+		return runUserCreate(rest)
+	case "disable":
+		return runUserDisable(rest)
+	case "reset-password":
+		return runUserResetPassword(rest)
+	case "role":
+		return runUserRole(rest)
+	}
+
+	return fmt.Errorf("usage: %s", userUsage)
+}
+
+func runUserCreate(args []string) error {
+	flags, configPath := newFlagSet("user create")
+	req := controllers.UserRegisterRequest{}
+	flags.StringVar(&req.Username, "username", "", "username")
+	flags.StringVar(&req.Email, "email", "", "email address")
+	flags.StringVar(&req.Password, "password", "", "password, generated when empty")
+	flags.UintVar(&req.Age, "age", 0, "age")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	generated := req.Password == ""
+	if generated {
+		password, err := randomPassword()
+		if err != nil {
+			return err
+		}
+		req.Password = password
+	}
+
+	if err := helpers.Validate(&req); err != nil {
+		return err
+	}
+
+	a, err := newApp(*configPath)
+	if err != nil {
+		return err
+	}
+
+	// Accounts made by an operator don't need to verify their email.
+	now := time.Now()
+	user := models.User{
+		Username:        req.Username,
+		Email:           req.Email,
+		Password:        req.Password,
+		Age:             int(req.Age),
+		EmailVerifiedAt: &now,
+	}
+	if err := a.userService().Register(&user); err != nil {
+		return err
+	}
+
+	fmt.Printf("created user %d (%s)\n", user.Id, user.Email)
+	if generated {
+		fmt.Printf("password: %s\n", req.Password)
+	}
+
+	return nil
+}
+
+func runUserDisable(args []string) error {
+	flags, configPath := newFlagSet("user disable")
+	id := flags.Uint("id", 0, "user id")
+	email := flags.String("email", "", "user email, used when -id is not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == 0 && *email == "" {
+		return fmt.Errorf("usage: user disable -id ID | -email EMAIL")
+	}
+
+	a, err := newApp(*configPath)
+	if err != nil {
+		return err
+	}
+
+	userId, err := a.findUser(*id, *email)
+	if err != nil {
+		return err
+	}
+	if err := a.userService().Disable(userId); err != nil {
+		return err
+	}
+
+	fmt.Printf("disabled user %d and ended its sessions\n", userId)
+
+	return nil
+}
+
+func runUserResetPassword(args []string) error {
+	flags, configPath := newFlagSet("user reset-password")
+	id := flags.Uint("id", 0, "user id")
+	email := flags.String("email", "", "user email, used when -id is not set")
+	password := flags.String("password", "", "new password, generated when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == 0 && *email == "" {
+		return fmt.Errorf("usage: user reset-password -id ID | -email EMAIL [-password PASSWORD]")
+	}
+
+	generated := *password == ""
+	if generated {
+		value, err := randomPassword()
+		if err != nil {
+			return err
+		}
+		*password = value
+	}
+
+	a, err := newApp(*configPath)
+	if err != nil {
+		return err
+	}
+
+	userId, err := a.findUser(*id, *email)
+	if err != nil {
+		return err
+	}
+	if err := a.userService().ResetPassword(userId, *password); err != nil {
+		return err
+	}
+
+	fmt.Printf("reset the password of user %d and ended its sessions\n", userId)
+	if generated {
+		fmt.Printf("password: %s\n", *password)
+	}
+
+	return nil
+}
+
+func runUserRole(args []string) error {
+	flags, configPath := newFlagSet("user role")
+	id := flags.Uint("id", 0, "user id")
+	email := flags.String("email", "", "user email, used when -id is not set")
+	role := flags.String("role", "", "user, moderator or admin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if (*id == 0 && *email == "") || *role == "" {
+		return fmt.Errorf("usage: user role -id ID | -email EMAIL -role ROLE")
+	}
+
+	a, err := newApp(*configPath)
+	if err != nil {
+		return err
+	}
+
+	userId, err := a.findUser(*id, *email)
+	if err != nil {
+		return err
+	}
+	if err := a.userService().SetRole(userId, *role); err != nil {
+		return err
+	}
+
+	fmt.Printf("user %d is now %s\n", userId, *role)
+
+	return nil
+}
+
+// randomPassword returns a password that always passes IsStrongPassword.
+func randomPassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf) + "a1", nil
+}
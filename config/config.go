@@ -0,0 +1,547 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	JWT       JWTConfig       `yaml:"jwt" toml:"jwt"`
+	Storage   StorageConfig   `yaml:"storage" toml:"storage"`
+	Deletion  DeletionConfig  `yaml:"deletion" toml:"deletion"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Mail      MailConfig      `yaml:"mail" toml:"mail"`
+	Password  PasswordConfig  `yaml:"password" toml:"password"`
+	// Verification is named email_verification in files.
+	Verification VerificationConfig `yaml:"email_verification" toml:"email_verification"`
+	TwoFactor    TwoFactorConfig    `yaml:"two_factor" toml:"two_factor"`
+	OIDC         OIDCConfig         `yaml:"oidc" toml:"oidc"`
+}
+
+type ServerConfig struct {
+	Addr              string   `yaml:"addr" toml:"addr"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	// ReadTimeout and WriteTimeout bound a whole request, so they have to
+	// leave time for a photo of Storage.MaxUploadSize on a slow connection.
+	ReadTimeout     Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout     Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// DrainDelay is how long /readyz answers 503 before the listener closes
+	// on shutdown, so load balancers stop sending requests first.
+	DrainDelay Duration `yaml:"drain_delay" toml:"drain_delay"`
+	// TrustedProxies are the IPs and CIDR ranges of the proxies whose
+	// X-Forwarded-For is believed. With none the client IP is the peer's.
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	Name     string `yaml:"name" toml:"name"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode"`
+	// ConnectAttempts and ConnectBackoff control the retries at startup;
+	// the backoff doubles after every failed attempt.
+	ConnectAttempts int      `yaml:"connect_attempts" toml:"connect_attempts"`
+	ConnectBackoff  Duration `yaml:"connect_backoff" toml:"connect_backoff"`
+}
+
+type JWTConfig struct {
+	Secret     string         `yaml:"secret" toml:"secret"`
+	AccessTTL  Duration       `yaml:"access_ttl" toml:"access_ttl"`
+	RefreshTTL Duration       `yaml:"refresh_ttl" toml:"refresh_ttl"`
+	ActiveKey  string         `yaml:"active_key" toml:"active_key"`
+	Keys       []JWTKeyConfig `yaml:"keys" toml:"keys"`
+}
+
+// JWTKeyConfig describes one asymmetric key. Keys with only a public key file
+// are kept for verifying tokens signed before a rotation.
+type JWTKeyConfig struct {
+	Id             string `yaml:"id" toml:"id"`
+	Algorithm      string `yaml:"algorithm" toml:"algorithm"`
+	PrivateKeyFile string `yaml:"private_key_file" toml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file" toml:"public_key_file"`
+}
+
+type StorageConfig struct {
+	// Driver is "local", "s3" or "memory".
+	Driver        string   `yaml:"driver" toml:"driver"`
+	LocalDir      string   `yaml:"local_dir" toml:"local_dir"`
+	PublicURL     string   `yaml:"public_url" toml:"public_url"`
+	MaxUploadSize int64    `yaml:"max_upload_size" toml:"max_upload_size"`
+	S3            S3Config `yaml:"s3" toml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint" toml:"endpoint"`
+	Region          string `yaml:"region" toml:"region"`
+	Bucket          string `yaml:"bucket" toml:"bucket"`
+	AccessKeyId     string `yaml:"access_key_id" toml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key" toml:"secret_access_key"`
+	// PathStyle puts the bucket in the path instead of the host name, which
+	// most self-hosted servers need.
+	PathStyle bool `yaml:"path_style" toml:"path_style"`
+}
+
+// DeletionConfig controls soft deleted users, photos, comments and social
+// media. They can be restored for RestoreWindow and are hard deleted once
+// they are older than PurgeAfter, checked every PurgeInterval.
+type DeletionConfig struct {
+	RestoreWindow Duration `yaml:"restore_window" toml:"restore_window"`
+	PurgeAfter    Duration `yaml:"purge_after" toml:"purge_after"`
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+// RateLimitConfig throttles sign-in endpoints per client IP and writes per
+// user, and locks out an email after repeated failed logins.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Store is "memory"; other stores let instances share their limits.
+	Store   string        `yaml:"store" toml:"store"`
+	Auth    LimitConfig   `yaml:"auth" toml:"auth"`
+	Writes  LimitConfig   `yaml:"writes" toml:"writes"`
+	Lockout LockoutConfig `yaml:"lockout" toml:"lockout"`
+}
+
+// LimitConfig allows Requests per Per, refilled continuously.
+type LimitConfig struct {
+	Requests int      `yaml:"requests" toml:"requests"`
+	Per      Duration `yaml:"per" toml:"per"`
+}
+
+// LockoutConfig locks an email for Base after Threshold failed logins,
+// doubling up to Max with every further failure. Failures are forgotten after
+// Window without one.
+type LockoutConfig struct {
+	Threshold int      `yaml:"threshold" toml:"threshold"`
+	Base      Duration `yaml:"base" toml:"base"`
+	Max       Duration `yaml:"max" toml:"max"`
+	Window    Duration `yaml:"window" toml:"window"`
+}
+
+type MailConfig struct {
+	// Driver is "log" or "file".
+	Driver  string `yaml:"driver" toml:"driver"`
+	From    string `yaml:"from" toml:"from"`
+	FileDir string `yaml:"file_dir" toml:"file_dir"`
+}
+
+// PasswordConfig controls the forgot password flow. ResetURL is the page
+// that reads the token; the token is appended as ?token=.
+type PasswordConfig struct {
+	ResetTTL Duration `yaml:"reset_ttl" toml:"reset_ttl"`
+	ResetURL string   `yaml:"reset_url" toml:"reset_url"`
+}
+
+// VerificationConfig controls email verification links. URL is the page that
+// reads the token, appended as ?token=. Restrict lists what unverified users
+// can't do: "photos" and "comments".
+type VerificationConfig struct {
+	TTL      Duration `yaml:"ttl" toml:"ttl"`
+	URL      string   `yaml:"url" toml:"url"`
+	Restrict []string `yaml:"restrict" toml:"restrict"`
+}
+
+// Restricts reports whether unverified users are kept from posting kind.
+func (v VerificationConfig) Restricts(kind string) bool {
+	for _, restricted := range v.Restrict {
+		if restricted == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TwoFactorConfig controls TOTP. Issuer is the name authenticator apps show
+// and ChallengeTTL how long a user has to enter a code after their password.
+type TwoFactorConfig struct {
+	Issuer       string   `yaml:"issuer" toml:"issuer"`
+	ChallengeTTL Duration `yaml:"challenge_ttl" toml:"challenge_ttl"`
+}
+
+// OIDCConfig lists the OpenID Connect providers users can sign in with.
+// StateTTL is how long a user has to come back from the provider.
+type OIDCConfig struct {
+	StateTTL  Duration             `yaml:"state_ttl" toml:"state_ttl"`
+	Providers []OIDCProviderConfig `yaml:"providers" toml:"providers"`
+}
+
+// OIDCProviderConfig describes one provider. Its endpoints and keys are
+// discovered from Issuer. RedirectURL is the page the provider sends users
+// back to; it passes the code and state on to the callback endpoint.
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name" toml:"name"`
+	Issuer       string   `yaml:"issuer" toml:"issuer"`
+	ClientId     string   `yaml:"client_id" toml:"client_id"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url"`
+	Scopes       []string `yaml:"scopes" toml:"scopes"`
+}
+
+// Duration accepts values such as "15m" or "720h" in config files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSN(d.Host), d.Port, quoteDSN(d.User), quoteDSN(d.Password), quoteDSN(d.Name), quoteDSN(d.SSLMode),
+	)
+}
+
+// quoteDSN quotes a keyword/value connection string value so that empty
+// values and values containing spaces or quotes are parsed correctly.
+func quoteDSN(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return "'" + value + "'"
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              ":8001",
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			ReadTimeout:       Duration(5 * time.Minute),
+			WriteTimeout:      Duration(5 * time.Minute),
+			IdleTimeout:       Duration(2 * time.Minute),
+			ShutdownTimeout:   Duration(15 * time.Second),
+			DrainDelay:        Duration(5 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Host:    "localhost",
+			Port:    5432,
+			Name:    "final_project_go",
+			User:    "postgres",
+			SSLMode: "disable",
+
+			ConnectAttempts: 10,
+			ConnectBackoff:  Duration(time.Second),
+		},
+		JWT: JWTConfig{
+			AccessTTL:  Duration(15 * time.Minute),
+			RefreshTTL: Duration(30 * 24 * time.Hour),
+		},
+		Storage: StorageConfig{
+			Driver:        "local",
+			LocalDir:      "uploads",
+			PublicURL:     "/media",
+			MaxUploadSize: 10 << 20,
+			S3: S3Config{
+				Region: "us-east-1",
+			},
+		},
+		Deletion: DeletionConfig{
+			RestoreWindow: Duration(7 * 24 * time.Hour),
+			PurgeAfter:    Duration(30 * 24 * time.Hour),
+			PurgeInterval: Duration(time.Hour),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   "memory",
+			Auth:    LimitConfig{Requests: 10, Per: Duration(time.Minute)},
+			Writes:  LimitConfig{Requests: 60, Per: Duration(time.Minute)},
+			Lockout: LockoutConfig{
+				Threshold: 5,
+				Base:      Duration(time.Minute),
+				Max:       Duration(time.Hour),
+				Window:    Duration(time.Hour),
+			},
+		},
+		Mail: MailConfig{
+			Driver:  "log",
+			From:    "no-reply@localhost",
+			FileDir: "mail",
+		},
+		Password: PasswordConfig{
+			ResetTTL: Duration(time.Hour),
+		},
+		Verification: VerificationConfig{
+			TTL: Duration(48 * time.Hour),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:       "Final Project",
+			ChallengeTTL: Duration(5 * time.Minute),
+		},
+		OIDC: OIDCConfig{
+			StateTTL: Duration(10 * time.Minute),
+		},
+	}
+}
+
+// Load builds the configuration in order of precedence: defaults, then the
+// optional YAML/TOML file at path, then the .env file, then the process
+// environment.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := loadDotEnv(".env"); err != nil {
+		return nil, err
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, cfg)
+	case ".toml":
+		err = toml.Unmarshal(content, cfg)
+	default:
+		return fmt.Errorf("config: unsupported file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Server.Addr == "" {
+		problems = append(problems, "server address is required")
+	}
+	if c.Server.ReadHeaderTimeout <= 0 || c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server timeouts must be positive")
+	}
+	if c.Server.DrainDelay < 0 {
+		problems = append(problems, "server drain delay can't be negative")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				problems = append(problems, fmt.Sprintf("server trusted proxy %q must be an IP or CIDR range", proxy))
+			}
+		}
+	}
+	if c.Database.Host == "" {
+		problems = append(problems, "database host is required")
+	}
+	if c.Database.Port <= 0 || c.Database.Port > 65535 {
+		problems = append(problems, "database port must be between 1 and 65535")
+	}
+	if c.Database.Name == "" {
+		problems = append(problems, "database name is required")
+	}
+	if c.Database.User == "" {
+		problems = append(problems, "database user is required")
+	}
+	if c.Database.ConnectAttempts < 1 || c.Database.ConnectBackoff <= 0 {
+		problems = append(problems, "database connect attempts and backoff must be positive")
+	}
+	if len(c.JWT.Keys) == 0 && c.JWT.Secret == "" {
+		problems = append(problems, "JWT_SECRET is required when no signing keys are configured")
+	}
+	problems = append(problems, c.JWT.validateKeys()...)
+	if c.JWT.AccessTTL <= 0 || c.JWT.RefreshTTL <= 0 {
+		problems = append(problems, "JWT token lifetimes must be positive")
+	}
+
+	problems = append(problems, c.Storage.validate()...)
+
+	if c.Deletion.RestoreWindow <= 0 || c.Deletion.PurgeAfter <= 0 || c.Deletion.PurgeInterval <= 0 {
+		problems = append(problems, "deletion restore window, purge age and purge interval must be positive")
+	} else if c.Deletion.PurgeAfter < c.Deletion.RestoreWindow {
+		problems = append(problems, "deletion purge_after must not be shorter than restore_window")
+	}
+
+	problems = append(problems, c.RateLimit.validate()...)
+
+	problems = append(problems, c.Mail.validate()...)
+	if c.Password.ResetTTL <= 0 {
+		problems = append(problems, "password reset_ttl must be positive")
+	}
+	problems = append(problems, c.Verification.validate()...)
+	if c.TwoFactor.Issuer == "" || c.TwoFactor.ChallengeTTL <= 0 {
+		problems = append(problems, "two factor issuer is required and challenge_ttl must be positive")
+	}
+	problems = append(problems, c.OIDC.validate()...)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("config: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func (j JWTConfig) validateKeys() []string {
+	var problems []string
+	seen := make(map[string]bool)
+	activeFound := false
+
+	for i, key := range j.Keys {
+		if key.Id == "" {
+			problems = append(problems, fmt.Sprintf("jwt key #%d needs an id", i+1))
+			continue
+		}
+		if seen[key.Id] {
+			problems = append(problems, fmt.Sprintf("jwt key %q is defined twice", key.Id))
+		}
+		seen[key.Id] = true
+
+		switch key.Algorithm {
+		case "RS256", "ES256", "EdDSA":
+		default:
+			problems = append(problems, fmt.Sprintf("jwt key %q: algorithm must be RS256, ES256 or EdDSA", key.Id))
+		}
+		if key.PrivateKeyFile == "" && key.PublicKeyFile == "" {
+			problems = append(problems, fmt.Sprintf("jwt key %q needs a private or public key file", key.Id))
+		}
+		if key.Id == j.ActiveKey {
+			activeFound = true
+			if key.PrivateKeyFile == "" {
+				problems = append(problems, fmt.Sprintf("active jwt key %q needs a private key file", key.Id))
+			}
+		}
+	}
+
+	if len(j.Keys) > 0 && !activeFound {
+		problems = append(problems, "jwt active_key must name one of the configured keys")
+	}
+
+	return problems
+}
+
+func (s StorageConfig) validate() []string {
+	var problems []string
+
+	switch s.Driver {
+	case "local":
+		if s.LocalDir == "" {
+			problems = append(problems, "storage local_dir is required for the local driver")
+		}
+	case "s3":
+		if s.S3.Endpoint == "" || s.S3.Bucket == "" || s.S3.Region == "" {
+			problems = append(problems, "storage s3 endpoint, bucket and region are required for the s3 driver")
+		}
+	case "memory":
+	default:
+		problems = append(problems, "storage driver must be local, s3 or memory")
+	}
+	if s.MaxUploadSize <= 0 {
+		problems = append(problems, "storage max_upload_size must be positive")
+	}
+
+	return problems
+}
+
+func (r RateLimitConfig) validate() []string {
+	if !r.Enabled {
+		return nil
+	}
+
+	var problems []string
+	if r.Store != "memory" {
+		problems = append(problems, fmt.Sprintf("unknown rate limit store %q", r.Store))
+	}
+	if r.Auth.Requests <= 0 || r.Auth.Per <= 0 || r.Writes.Requests <= 0 || r.Writes.Per <= 0 {
+		problems = append(problems, "rate limit requests and periods must be positive")
+	}
+	if r.Lockout.Threshold <= 0 || r.Lockout.Base <= 0 || r.Lockout.Max < r.Lockout.Base || r.Lockout.Window <= 0 {
+		problems = append(problems, "lockout threshold, base and window must be positive and max must not be shorter than base")
+	}
+
+	return problems
+}
+
+func (v VerificationConfig) validate() []string {
+	var problems []string
+
+	if v.TTL <= 0 {
+		problems = append(problems, "email verification ttl must be positive")
+	}
+	for _, restricted := range v.Restrict {
+		if restricted != "photos" && restricted != "comments" {
+			problems = append(problems, fmt.Sprintf("unknown email verification restriction %q", restricted))
+		}
+	}
+
+	return problems
+}
+
+func (o OIDCConfig) validate() []string {
+	var problems []string
+	seen := make(map[string]bool)
+
+	if o.StateTTL <= 0 {
+		problems = append(problems, "oidc state_ttl must be positive")
+	}
+	for i, provider := range o.Providers {
+		if provider.Name == "" {
+			problems = append(problems, fmt.Sprintf("oidc provider #%d needs a name", i+1))
+			continue
+		}
+		if seen[provider.Name] {
+			problems = append(problems, fmt.Sprintf("oidc provider %q is defined twice", provider.Name))
+		}
+		seen[provider.Name] = true
+
+		if provider.Issuer == "" || provider.ClientId == "" || provider.RedirectURL == "" {
+			problems = append(problems, fmt.Sprintf("oidc provider %q needs an issuer, client_id and redirect_url", provider.Name))
+		}
+	}
+
+	return problems
+}
+
+func (m MailConfig) validate() []string {
+	var problems []string
+
+	switch m.Driver {
+	case "log":
+	case "file":
+		if m.FileDir == "" {
+			problems = append(problems, "mail file_dir is required for the file driver")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown mail driver %q", m.Driver))
+	}
+	if m.From == "" {
+		problems = append(problems, "mail from address is required")
+	}
+
+	return problems
+}
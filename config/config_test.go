@@ -0,0 +1,375 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envPrefixes cover every variable applyEnv reads.
+var envPrefixes = []string{
+	"SERVER_", "DB_", "JWT_", "STORAGE_", "S3_", "DELETION_", "RATE_LIMIT_", "LOCKOUT_",
+	"MAIL_", "PASSWORD_", "EMAIL_VERIFY_", "TWO_FACTOR_", "OIDC_",
+}
+
+// isolate runs the test in an empty directory, so there is no .env, with none
+// of the variables Load reads set. Whatever the test or a .env sets is
+// undone afterwards.
+func isolate(t *testing.T) string {
+	t.Helper()
+
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		for _, prefix := range envPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				unsetenv(t, key)
+			}
+		}
+	}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+	})
+
+	return dir
+}
+
+// unsetenv unsets key until the test ends.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+// writeDotEnv writes a .env to dir and has the variables it sets unset
+// again when the test ends, since Load copies them into the environment.
+func writeDotEnv(t *testing.T, dir, content string) {
+	t.Helper()
+
+	for _, line := range strings.Split(content, "\n") {
+		key, _, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if found && !strings.HasPrefix(line, "#") {
+			unsetenv(t, strings.TrimSpace(key))
+		}
+	}
+	writeFile(t, dir, ".env", content)
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		dotEnv string
+		env    map[string]string
+		addr   string
+		port   int
+	}{
+		{
+			name: "defaults",
+			addr: ":8001",
+			port: 5432,
+		},
+		{
+			name: "file over defaults",
+			file: "server:\n  addr: \":9000\"\ndatabase:\n  port: 6000\n",
+			addr: ":9000",
+			port: 6000,
+		},
+		{
+			name:   ".env over the file",
+			file:   "server:\n  addr: \":9000\"\ndatabase:\n  port: 6000\n",
+			dotEnv: "# comment\nexport SERVER_ADDR=\":9100\"\n",
+			addr:   ":9100",
+			port:   6000,
+		},
+		{
+			name:   "environment over .env and the file",
+			file:   "server:\n  addr: \":9000\"\ndatabase:\n  port: 6000\n",
+			dotEnv: "SERVER_ADDR=:9100\nDB_PORT=6100\n",
+			env:    map[string]string{"SERVER_ADDR": ":9200"},
+			addr:   ":9200",
+			port:   6100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := isolate(t)
+			if tt.dotEnv != "" {
+				writeDotEnv(t, dir, tt.dotEnv)
+			}
+			t.Setenv("JWT_SECRET", "secret")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeFile(t, dir, "config.yaml", tt.file)
+			}
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Server.Addr != tt.addr || cfg.Database.Port != tt.port {
+				t.Fatalf("addr %q, port %d; want %q, %d", cfg.Server.Addr, cfg.Database.Port, tt.addr, tt.port)
+			}
+		})
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	dir := isolate(t)
+	path := writeFile(t, dir, "config.toml", `
+[server]
+addr = ":9000"
+drain_delay = "1s"
+
+[jwt]
+secret = "secret"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Addr != ":9000" || cfg.Server.DrainDelay != Duration(time.Second) || cfg.JWT.Secret != "secret" {
+		t.Fatalf("server %+v, jwt %+v", cfg.Server, cfg.JWT)
+	}
+}
+
+func TestLoadEnvKeys(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		got  func(*Config) interface{}
+		want interface{}
+	}{
+		{
+			env:  map[string]string{"DB_PORT": "6543"},
+			got:  func(c *Config) interface{} { return c.Database.Port },
+			want: 6543,
+		},
+		{
+			env:  map[string]string{"STORAGE_MAX_UPLOAD_SIZE": "1048576"},
+			got:  func(c *Config) interface{} { return c.Storage.MaxUploadSize },
+			want: int64(1 << 20),
+		},
+		{
+			env:  map[string]string{"SERVER_DRAIN_DELAY": "0s", "JWT_ACCESS_TTL": "5m"},
+			got:  func(c *Config) interface{} { return []Duration{c.Server.DrainDelay, c.JWT.AccessTTL} },
+			want: []Duration{0, Duration(5 * time.Minute)},
+		},
+		{
+			env:  map[string]string{"S3_PATH_STYLE": "1", "RATE_LIMIT_ENABLED": "false"},
+			got:  func(c *Config) interface{} { return []bool{c.Storage.S3.PathStyle, c.RateLimit.Enabled} },
+			want: []bool{true, false},
+		},
+		{
+			env:  map[string]string{"SERVER_TRUSTED_PROXIES": " 10.0.0.0/8, ,127.0.0.1 "},
+			got:  func(c *Config) interface{} { return c.Server.TrustedProxies },
+			want: []string{"10.0.0.0/8", "127.0.0.1"},
+		},
+		{
+			env: map[string]string{"JWT_PRIVATE_KEY_FILE": "keys/jwt.pem"},
+			got: func(c *Config) interface{} { return c.JWT },
+			want: JWTConfig{
+				Secret:     "secret",
+				ActiveKey:  "default",
+				Keys:       []JWTKeyConfig{{Id: "default", Algorithm: "RS256", PrivateKeyFile: "keys/jwt.pem"}},
+				AccessTTL:  Duration(15 * time.Minute),
+				RefreshTTL: Duration(30 * 24 * time.Hour),
+			},
+		},
+		{
+			env: map[string]string{
+				"OIDC_ISSUER":        "https://accounts.example.com",
+				"OIDC_CLIENT_ID":     "client",
+				"OIDC_REDIRECT_URL":  "https://app.example.com/callback",
+				"OIDC_SCOPES":        "openid,email",
+				"OIDC_PROVIDER_NAME": "example",
+			},
+			got: func(c *Config) interface{} { return c.OIDC.Providers },
+			want: []OIDCProviderConfig{{
+				Name:        "example",
+				Issuer:      "https://accounts.example.com",
+				ClientId:    "client",
+				RedirectURL: "https://app.example.com/callback",
+				Scopes:      []string{"openid", "email"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		var keys []string
+		for key := range tt.env {
+			keys = append(keys, key)
+		}
+		t.Run(strings.Join(keys, ","), func(t *testing.T) {
+			isolate(t)
+			t.Setenv("JWT_SECRET", "secret")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load("")
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got := tt.got(cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		err  string
+	}{
+		{name: "number", env: map[string]string{"DB_PORT": "five"}, err: "DB_PORT must be a number"},
+		{name: "duration", env: map[string]string{"JWT_ACCESS_TTL": "soon"}, err: "JWT_ACCESS_TTL must be a duration"},
+		{name: "missing file", file: "missing.yaml", err: "no such file"},
+		{name: "unsupported format", file: "config.json", err: `unsupported file format ".json"`},
+		{name: "invalid file", file: "broken.yaml", err: "parse"},
+		{name: "invalid config", env: map[string]string{"DB_PORT": "0"}, err: "database port must be between 1 and 65535"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := isolate(t)
+			t.Setenv("JWT_SECRET", "secret")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			writeFile(t, dir, "config.json", "{}")
+			writeFile(t, dir, "broken.yaml", "server: [")
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(dir, tt.file)
+			}
+
+			if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Config)
+		err    string
+	}{
+		{name: "valid"},
+		{name: "no secret", change: func(c *Config) { c.JWT.Secret = "" }, err: "JWT_SECRET is required"},
+		{name: "timeout", change: func(c *Config) { c.Server.ShutdownTimeout = 0 }, err: "server timeouts must be positive"},
+		{name: "drain delay", change: func(c *Config) { c.Server.DrainDelay = -1 }, err: "server drain delay can't be negative"},
+		{
+			name:   "trusted proxy",
+			change: func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/8", "proxy.local"} },
+			err:    `server trusted proxy "proxy.local" must be an IP or CIDR range`,
+		},
+		{
+			name:   "active key",
+			change: func(c *Config) { c.JWT.Keys = []JWTKeyConfig{{Id: "a", Algorithm: "RS256", PublicKeyFile: "a.pem"}} },
+			err:    "jwt active_key must name one of the configured keys",
+		},
+		{
+			name: "active key without a private key",
+			change: func(c *Config) {
+				c.JWT.ActiveKey = "a"
+				c.JWT.Keys = []JWTKeyConfig{{Id: "a", Algorithm: "HS256", PublicKeyFile: "a.pem"}}
+			},
+			err: `jwt key "a": algorithm must be RS256, ES256 or EdDSA; active jwt key "a" needs a private key file`,
+		},
+		{name: "storage driver", change: func(c *Config) { c.Storage.Driver = "ftp" }, err: "storage driver must be local, s3 or memory"},
+		{name: "s3", change: func(c *Config) { c.Storage.Driver = "s3" }, err: "storage s3 endpoint, bucket and region are required"},
+		{
+			name:   "purge before restore",
+			change: func(c *Config) { c.Deletion.PurgeAfter = Duration(time.Hour) },
+			err:    "deletion purge_after must not be shorter than restore_window",
+		},
+		{
+			name:   "lockout",
+			change: func(c *Config) { c.RateLimit.Lockout.Max = Duration(time.Second) },
+			err:    "max must not be shorter than base",
+		},
+		{name: "rate limits off skip their checks", change: func(c *Config) { c.RateLimit = RateLimitConfig{} }},
+		{name: "mail", change: func(c *Config) { c.Mail.Driver = "pigeon" }, err: `unknown mail driver "pigeon"`},
+		{
+			name:   "restriction",
+			change: func(c *Config) { c.Verification.Restrict = []string{"photos", "likes"} },
+			err:    `unknown email verification restriction "likes"`,
+		},
+		{
+			name:   "oidc provider",
+			change: func(c *Config) { c.OIDC.Providers = []OIDCProviderConfig{{Name: "x"}, {Name: "x"}} },
+			err:    `oidc provider "x" needs an issuer, client_id and redirect_url; oidc provider "x" is defined twice`,
+		},
+		{
+			name: "every problem at once",
+			change: func(c *Config) {
+				c.Server.Addr = ""
+				c.Database.Name = ""
+			},
+			err: "config: server address is required; database name is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.JWT.Secret = "secret"
+			if tt.change != nil {
+				tt.change(&cfg)
+			}
+
+			err := cfg.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDSNQuotesValues(t *testing.T) {
+	d := DatabaseConfig{Host: "db", Port: 5432, User: "app", Password: `it's a \ secret`, Name: "photos", SSLMode: ""}
+
+	want := `host='db' port=5432 user='app' password='it\'s a \\ secret' dbname='photos' sslmode=''`
+	if got := d.DSN(); got != want {
+		t.Fatalf("DSN() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// loadDotEnv copies KEY=VALUE pairs from the given file into the process
+// environment. Variables that are already set are left untouched.
+func loadDotEnv(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("config: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("config: %s:%d: expected KEY=VALUE", path, lineNumber)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		os.Setenv(key, value)
+	}
+
+	return scanner.Err()
+}
+
+func applyEnv(cfg *Config) error {
+	setString(&cfg.Server.Addr, "SERVER_ADDR")
+	setString(&cfg.Database.Host, "DB_HOST")
+	setString(&cfg.Database.Name, "DB_NAME")
+	setString(&cfg.Database.User, "DB_USER")
+	setString(&cfg.Database.Password, "DB_PASSWORD")
+	setString(&cfg.Database.SSLMode, "DB_SSLMODE")
+	setString(&cfg.JWT.Secret, "JWT_SECRET")
+	setString(&cfg.JWT.ActiveKey, "JWT_ACTIVE_KEY")
+	setString(&cfg.Storage.Driver, "STORAGE_DRIVER")
+	setString(&cfg.Storage.LocalDir, "STORAGE_LOCAL_DIR")
+	setString(&cfg.Storage.PublicURL, "STORAGE_PUBLIC_URL")
+	setString(&cfg.Storage.S3.Endpoint, "S3_ENDPOINT")
+	setString(&cfg.Storage.S3.Region, "S3_REGION")
+	setString(&cfg.Storage.S3.Bucket, "S3_BUCKET")
+	setString(&cfg.Storage.S3.AccessKeyId, "S3_ACCESS_KEY_ID")
+	setString(&cfg.Storage.S3.SecretAccessKey, "S3_SECRET_ACCESS_KEY")
+	if value, ok := os.LookupEnv("S3_PATH_STYLE"); ok {
+		cfg.Storage.S3.PathStyle = value == "true" || value == "1"
+	}
+	setString(&cfg.RateLimit.Store, "RATE_LIMIT_STORE")
+	setString(&cfg.Mail.Driver, "MAIL_DRIVER")
+	setString(&cfg.Mail.From, "MAIL_FROM")
+	setString(&cfg.Mail.FileDir, "MAIL_FILE_DIR")
+	setString(&cfg.Password.ResetURL, "PASSWORD_RESET_URL")
+	setString(&cfg.Verification.URL, "EMAIL_VERIFY_URL")
+	setString(&cfg.TwoFactor.Issuer, "TWO_FACTOR_ISSUER")
+	if value, ok := os.LookupEnv("SERVER_TRUSTED_PROXIES"); ok {
+		cfg.Server.TrustedProxies = splitList(value)
+	}
+	if value, ok := os.LookupEnv("EMAIL_VERIFY_RESTRICT"); ok {
+		cfg.Verification.Restrict = splitList(value)
+	}
+	if value, ok := os.LookupEnv("RATE_LIMIT_ENABLED"); ok {
+		cfg.RateLimit.Enabled = value == "true" || value == "1"
+	}
+
+	// A single signing key can be supplied without a config file.
+	if file, ok := os.LookupEnv("JWT_PRIVATE_KEY_FILE"); ok {
+		key := JWTKeyConfig{
+			Id:             os.Getenv("JWT_KEY_ID"),
+			Algorithm:      os.Getenv("JWT_ALGORITHM"),
+			PrivateKeyFile: file,
+		}
+		if key.Id == "" {
+			key.Id = "default"
+		}
+		if key.Algorithm == "" {
+			key.Algorithm = "RS256"
+		}
+		cfg.JWT.Keys = append(cfg.JWT.Keys, key)
+		cfg.JWT.ActiveKey = key.Id
+	}
+
+	// So can a single OpenID Connect provider.
+	if issuer, ok := os.LookupEnv("OIDC_ISSUER"); ok {
+		provider := OIDCProviderConfig{
+			Name:         os.Getenv("OIDC_PROVIDER_NAME"),
+			Issuer:       issuer,
+			ClientId:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       splitList(os.Getenv("OIDC_SCOPES")),
+		}
+		if provider.Name == "" {
+			provider.Name = "default"
+		}
+		cfg.OIDC.Providers = append(cfg.OIDC.Providers, provider)
+	}
+
+	if err := setInt(&cfg.Database.Port, "DB_PORT"); err != nil {
+		return err
+	}
+	if err := setInt(&cfg.Database.ConnectAttempts, "DB_CONNECT_ATTEMPTS"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Database.ConnectBackoff, "DB_CONNECT_BACKOFF"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Server.ReadHeaderTimeout, "SERVER_READ_HEADER_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Server.ReadTimeout, "SERVER_READ_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Server.ShutdownTimeout, "SERVER_SHUTDOWN_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Server.DrainDelay, "SERVER_DRAIN_DELAY"); err != nil {
+		return err
+	}
+	if err := setInt64(&cfg.Storage.MaxUploadSize, "STORAGE_MAX_UPLOAD_SIZE"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.JWT.AccessTTL, "JWT_ACCESS_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.JWT.RefreshTTL, "JWT_REFRESH_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Deletion.RestoreWindow, "DELETION_RESTORE_WINDOW"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Deletion.PurgeAfter, "DELETION_PURGE_AFTER"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Deletion.PurgeInterval, "DELETION_PURGE_INTERVAL"); err != nil {
+		return err
+	}
+	if err := setInt(&cfg.RateLimit.Auth.Requests, "RATE_LIMIT_AUTH_REQUESTS"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.RateLimit.Auth.Per, "RATE_LIMIT_AUTH_PER"); err != nil {
+		return err
+	}
+	if err := setInt(&cfg.RateLimit.Writes.Requests, "RATE_LIMIT_WRITES_REQUESTS"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.RateLimit.Writes.Per, "RATE_LIMIT_WRITES_PER"); err != nil {
+		return err
+	}
+	if err := setInt(&cfg.RateLimit.Lockout.Threshold, "LOCKOUT_THRESHOLD"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.RateLimit.Lockout.Base, "LOCKOUT_BASE"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.RateLimit.Lockout.Max, "LOCKOUT_MAX"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.RateLimit.Lockout.Window, "LOCKOUT_WINDOW"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Password.ResetTTL, "PASSWORD_RESET_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Verification.TTL, "EMAIL_VERIFY_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.TwoFactor.ChallengeTTL, "TWO_FACTOR_CHALLENGE_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.OIDC.StateTTL, "OIDC_STATE_TTL"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func setString(target *string, key string) {
+	if value, ok := os.LookupEnv(key); ok {
+		*target = value
+	}
+}
+
+func setInt(target *int, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("config: %s must be a number", key)
+	}
+	*target = parsed
+
+	return nil
+}
+
+func setInt64(target *int64, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("config: %s must be a number", key)
+	}
+	*target = parsed
+
+	return nil
+}
+
+func setDuration(target *Duration, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	if err := target.UnmarshalText([]byte(value)); err != nil {
+		return fmt.Errorf("config: %s must be a duration such as 15m or 720h", key)
+	}
+
+	return nil
+}
+
+// splitList reads a comma separated list, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	moderationService *services.ModerationService
+	auditService      *services.AuditService
+}
+
+type ModerationRequest struct {
+	Reason string `json:"reason" valid:"maxstringlength(500)~reason must be at most 500 characters"`
+}
+
+type AdminRoleRequest struct {
+	Role   string `json:"role" valid:"required~role is required"`
+	Reason string `json:"reason" valid:"maxstringlength(500)~reason must be at most 500 characters"`
+}
+
+type AdminUserResponse struct {
+	Id         uint       `json:"id"`
+	Username   string     `json:"username"`
+	Email      string     `json:"email"`
+	Age        int        `json:"age"`
+	Role       string     `json:"role"`
+	Private    bool       `json:"private"`
+	DisabledAt *time.Time `json:"disabled_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+type ModerationActionResponse struct {
+	Id         uint       `json:"id"`
+	ActorId    *uint      `json:"actor_id"`
+	Action     string     `json:"action"`
+	TargetType string     `json:"target_type"`
+	TargetId   uint       `json:"target_id"`
+	Reason     string     `json:"reason"`
+	Detail     string     `json:"detail"`
+	CreatedAt  *time.Time `json:"created_at"`
+	Actor      UserDataResponse
+}
+
+func NewAdminController(moderationService *services.ModerationService, auditService *services.AuditService) *AdminController {
+	return &AdminController{
+		moderationService: moderationService,
+		auditService:      auditService,
+	}
+}
+
+func (a *AdminController) Users(ctx *gin.Context) {
+	pagination, err := helpers.ParsePagination(ctx, "updated_at", "username")
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	filter, err := helpers.ParseFilters(ctx)
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	users, total, err := a.moderationService.Users(repositories.ListQuery{Pagination: pagination, Filter: filter})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	response := make([]AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		response = append(response, AdminUserResponse{
+			Id:         user.Id,
+			Username:   user.Username,
+			Email:      user.Email,
+			Age:        user.Age,
+			Role:       user.Role,
+			Private:    user.Private,
+			DisabledAt: user.DisabledAt,
+			CreatedAt:  user.CreatedAt,
+			UpdatedAt:  user.UpdatedAt,
+		})
+	}
+
+	var lastId uint
+	if len(users) > 0 {
+		lastId = users[len(users)-1].Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(users), total, lastId, pagination)
+}
+
+func (a *AdminController) DisableUser(ctx *gin.Context) {
+	a.moderateUser(ctx, a.moderationService.DisableUser, "The user has been disabled")
+}
+
+func (a *AdminController) EnableUser(ctx *gin.Context) {
+	a.moderateUser(ctx, a.moderationService.EnableUser, "The user has been enabled")
+}
+
+func (a *AdminController) ResetTwoFactor(ctx *gin.Context) {
+	a.moderateUser(ctx, a.moderationService.ResetTwoFactor, "Two factor authentication has been reset")
+}
+
+func (a *AdminController) SetRole(ctx *gin.Context) {
+	var roleReq AdminRoleRequest
+
+	userId, ok := helpers.GetParamId(ctx, "userId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "user not found")
+		return
+	}
+
+	err := helpers.BindJSON(ctx, &roleReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	before, after, err := a.moderationService.SetRole(helpers.GetUserId(ctx), userId, roleReq.Role, roleReq.Reason)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, a.auditService, models.AuditUpdate, "user", userId, moderatedUser(before), moderatedUser(after))
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"id":   userId,
+		"role": roleReq.Role,
+	})
+}
+
+func (a *AdminController) DeletePhoto(ctx *gin.Context) {
+	a.moderate(ctx, "photoId", "photo not found", "photo", func(actorId, id uint, reason string) (interface{}, error) {
+		return a.moderationService.DeletePhoto(actorId, id, reason)
+	}, "The photo has been deleted")
+}
+
+func (a *AdminController) DeleteComment(ctx *gin.Context) {
+	a.moderate(ctx, "commentId", "comment not found", "comment", func(actorId, id uint, reason string) (interface{}, error) {
+		return a.moderationService.DeleteComment(actorId, id, reason)
+	}, "The comment has been deleted")
+}
+
+func (a *AdminController) DeleteSocial(ctx *gin.Context) {
+	a.moderate(ctx, "socialMediaId", "social media not found", "social", func(actorId, id uint, reason string) (interface{}, error) {
+		return a.moderationService.DeleteSocial(actorId, id, reason)
+	}, "The social media has been deleted")
+}
+
+func (a *AdminController) History(ctx *gin.Context) {
+	pagination, err := helpers.ParsePagination(ctx)
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	filter, err := helpers.ParseFilters(ctx, "actor_id", "target_id")
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	actions, total, err := a.moderationService.History(repositories.ListQuery{Pagination: pagination, Filter: filter})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	response := make([]ModerationActionResponse, 0, len(actions))
+	for _, action := range actions {
+		response = append(response, newModerationActionResponse(action))
+	}
+
+	var lastId uint
+	if len(actions) > 0 {
+		lastId = actions[len(actions)-1].Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(actions), total, lastId, pagination)
+}
+
+// moderate runs a delete on the id in param and records what was deleted.
+// The body, and with it the reason, is optional.
+func (a *AdminController) moderate(ctx *gin.Context, param, notFound, resourceType string, action func(actorId, id uint, reason string) (interface{}, error), message string) {
+	id, reason, ok := moderationTarget(ctx, param, notFound)
+	if !ok {
+		return
+	}
+
+	before, err := action(helpers.GetUserId(ctx), id, reason)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, a.auditService, models.AuditDelete, resourceType, id, before, nil)
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// moderateUser runs an account action on the user in the path and records
+// how the user changed.
+func (a *AdminController) moderateUser(ctx *gin.Context, action func(actorId, id uint, reason string) (*models.User, *models.User, error), message string) {
+	id, reason, ok := moderationTarget(ctx, "userId", "user not found")
+	if !ok {
+		return
+	}
+
+	before, after, err := action(helpers.GetUserId(ctx), id, reason)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, a.auditService, models.AuditUpdate, "user", id, moderatedUser(before), moderatedUser(after))
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// moderationTarget reads the id in param and the optional reason. It has
+// written the response when ok is false.
+func moderationTarget(ctx *gin.Context, param, notFound string) (id uint, reason string, ok bool) {
+	var moderationReq ModerationRequest
+
+	id, ok = helpers.GetParamId(ctx, param)
+	if !ok {
+		helpers.NotFoundResponse(ctx, notFound)
+		return 0, "", false
+	}
+
+	if ctx.Request.ContentLength > 0 {
+		err := helpers.BindJSON(ctx, &moderationReq)
+		if err != nil {
+			helpers.ErrorResponse(ctx, err)
+			return 0, "", false
+		}
+	}
+
+	return id, moderationReq.Reason, true
+}
+
+// moderatedUser is what the audit log keeps of a user an account action
+// applies to. The state these actions change is left out of the user's own
+// JSON.
+func moderatedUser(user *models.User) gin.H {
+	return gin.H{
+		"role":               user.Role,
+		"disabled":           user.DisabledAt != nil,
+		"two_factor_enabled": user.TwoFactorEnabled(),
+	}
+}
+
+func newModerationActionResponse(action models.ModerationAction) ModerationActionResponse {
+	return ModerationActionResponse{
+		Id:         action.Id,
+		ActorId:    action.ActorId,
+		Action:     action.Action,
+		TargetType: action.TargetType,
+		TargetId:   action.TargetId,
+		Reason:     action.Reason,
+		Detail:     action.Detail,
+		CreatedAt:  action.CreatedAt,
+		Actor:      newUserDataResponse(action.Actor),
+	}
+}
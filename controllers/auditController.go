@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"encoding/json"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	auditResourceTypes = []string{"user", "photo", "comment", "social", "identity"}
+	auditActions       = []string{models.AuditCreate, models.AuditUpdate, models.AuditDelete, models.AuditRestore}
+)
+
+type AuditController struct {
+	auditService *services.AuditService
+}
+
+type AuditEntryResponse struct {
+	Id           uint            `json:"id"`
+	ActorId      *uint           `json:"actor_id"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceId   uint            `json:"resource_id"`
+	Changes      json.RawMessage `json:"changes"`
+	Ip           string          `json:"ip"`
+	UserAgent    string          `json:"user_agent"`
+	CreatedAt    *time.Time      `json:"created_at"`
+	Actor        UserDataResponse
+}
+
+func NewAuditController(auditService *services.AuditService) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+	}
+}
+
+func (a *AuditController) Get(ctx *gin.Context) {
+	pagination, err := helpers.ParsePagination(ctx)
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	filter, auditFilter, err := parseAuditFilters(ctx)
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	entries, total, err := a.auditService.List(repositories.ListQuery{Pagination: pagination, Filter: filter}, auditFilter)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	response := make([]AuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, newAuditEntryResponse(entry))
+	}
+
+	var lastId uint
+	if len(entries) > 0 {
+		lastId = entries[len(entries)-1].Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(entries), total, lastId, pagination)
+}
+
+// Export streams every matching entry as JSON lines, oldest first. Errors
+// after the first line can only be logged.
+func (a *AuditController) Export(ctx *gin.Context) {
+	filter, auditFilter, err := parseAuditFilters(ctx)
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	started := false
+	start := func() {
+		ctx.Header("Content-Type", "application/x-ndjson")
+		ctx.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+		ctx.Status(http.StatusOK)
+		started = true
+	}
+	encoder := json.NewEncoder(ctx.Writer)
+
+	err = a.auditService.Export(filter, auditFilter, func(entry models.AuditEntry) error {
+		if !started {
+			start()
+		}
+		return encoder.Encode(newAuditEntryResponse(entry))
+	})
+	if err != nil {
+		if !started {
+			helpers.ErrorResponse(ctx, err)
+			return
+		}
+		log.Printf("audit: export failed: %v", err)
+		return
+	}
+
+	if !started {
+		start()
+	}
+}
+
+// parseAuditFilters reads the actor_id, resource_id, resource_type and action
+// filters shared by the listing and the export.
+func parseAuditFilters(ctx *gin.Context) (helpers.Filter, repositories.AuditFilter, error) {
+	auditFilter := repositories.AuditFilter{
+		ResourceType: ctx.Query("resource_type"),
+		Action:       ctx.Query("action"),
+	}
+
+	filter, err := helpers.ParseFilters(ctx, "actor_id", "resource_id")
+	if err != nil {
+		return filter, auditFilter, err
+	}
+
+	if auditFilter.ResourceType != "" && !containsString(auditResourceTypes, auditFilter.ResourceType) {
+		return filter, auditFilter, fmt.Errorf("resource_type must be one of %s", strings.Join(auditResourceTypes, ", "))
+	}
+	if auditFilter.Action != "" && !containsString(auditActions, auditFilter.Action) {
+		return filter, auditFilter, fmt.Errorf("action must be one of %s", strings.Join(auditActions, ", "))
+	}
+
+	return filter, auditFilter, nil
+}
+
+// recordAudit records a change made by the signed in user. The change has
+// already gone through, so a failure to record it is logged rather than
+// failing the request.
+func recordAudit(ctx *gin.Context, auditService *services.AuditService, action, resourceType string, resourceId uint, before, after interface{}) {
+	recordAuditAs(ctx, auditService, helpers.GetUserId(ctx), action, resourceType, resourceId, before, after)
+}
+
+func recordAuditAs(ctx *gin.Context, auditService *services.AuditService, actorId uint, action, resourceType string, resourceId uint, before, after interface{}) {
+	actor := services.AuditActor{
+		UserId:    actorId,
+		Ip:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+
+	err := auditService.Record(actor, action, resourceType, resourceId, before, after)
+	if err != nil {
+		log.Printf("audit: could not record %s of %s %d: %v", action, resourceType, resourceId, err)
+	}
+}
+
+func newAuditEntryResponse(entry models.AuditEntry) AuditEntryResponse {
+	return AuditEntryResponse{
+		Id:           entry.Id,
+		ActorId:      entry.ActorId,
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceId:   entry.ResourceId,
+		Changes:      json.RawMessage(entry.Changes),
+		Ip:           entry.Ip,
+		UserAgent:    entry.UserAgent,
+		CreatedAt:    entry.CreatedAt,
+		Actor:        newUserDataResponse(entry.Actor),
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
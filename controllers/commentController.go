@@ -3,26 +3,35 @@ package controllers
 import (
 	"final-project-golang/helpers"
 	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type CommentController struct {
-	db *gorm.DB
+	commentService *services.CommentService
+	likeService    *services.LikeService
+	auditService   *services.AuditService
 }
 
 type CommentCreateRequest struct {
-	Message string `json:"message"`
-	PhotoId uint   `json:"photo_id"`
+	Message  string `json:"message" valid:"required~message is required"`
+	PhotoId  uint   `json:"photo_id" valid:"required~photo_id is required"`
+	ParentId *uint  `json:"parent_id"`
+}
+
+type CommentUpdateRequest struct {
+	Message string `json:"message" valid:"required~message is required"`
 }
 
 type CommentCreateResponse struct {
 	Id        uint       `json:"id"`
 	Message   string     `json:"message"`
 	PhotoId   uint       `json:"photo_id"`
+	ParentId  *uint      `json:"parent_id"`
 	UserId    uint       `json:"user_id"`
 	CreatedAt *time.Time `json:"created_at"`
 }
@@ -39,13 +48,32 @@ type CommentGetResponse struct {
 	Id        uint       `json:"id"`
 	Message   string     `json:"message"`
 	PhotoId   uint       `json:"photo_id"`
+	ParentId  *uint      `json:"parent_id"`
+	Deleted   bool       `json:"deleted"`
 	UserId    uint       `json:"user_id"`
 	UpdatedAt *time.Time `json:"updated_at"`
 	CreatedAt *time.Time `json:"created_at"`
+	LikeCount int64      `json:"like_count"`
+	LikedByMe bool       `json:"liked_by_me"`
 	User      UserCommentResponse
 	Photo     PhotoCommentResponse
 }
 
+type CommentThreadResponse struct {
+	Id         uint       `json:"id"`
+	Message    string     `json:"message"`
+	ParentId   *uint      `json:"parent_id"`
+	Deleted    bool       `json:"deleted"`
+	UserId     uint       `json:"user_id"`
+	CreatedAt  *time.Time `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+	LikeCount  int64      `json:"like_count"`
+	LikedByMe  bool       `json:"liked_by_me"`
+	ReplyCount int        `json:"reply_count"`
+	User       UserDataResponse
+	Replies    []CommentThreadResponse `json:"replies"`
+}
+
 type UserCommentResponse struct {
 	Id       uint   `json:"id"`
 	Email    string `json:"email"`
@@ -60,46 +88,42 @@ type PhotoCommentResponse struct {
 	UserId   uint   `json:"user_id"`
 }
 
-func NewCommentController(db *gorm.DB) *CommentController {
+func NewCommentController(commentService *services.CommentService, likeService *services.LikeService, auditService *services.AuditService) *CommentController {
 	return &CommentController{
-		db: db,
+		commentService: commentService,
+		likeService:    likeService,
+		auditService:   auditService,
 	}
 }
 
 func (c *CommentController) Create(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
 	var commentReq CommentCreateRequest
 
-	err := ctx.ShouldBindJSON(&commentReq)
+	err := helpers.BindJSON(ctx, &commentReq)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
 	newComment := models.Comment{
-		Message: commentReq.Message,
-		PhotoId: commentReq.PhotoId,
-		UserId:  uint(userId.(float64)),
+		Message:  commentReq.Message,
+		PhotoId:  commentReq.PhotoId,
+		ParentId: commentReq.ParentId,
+		UserId:   helpers.GetUserId(ctx),
 	}
 
-	err = c.db.Create(&newComment).Error
+	err = c.commentService.Create(&newComment)
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		if err.Error() == `ERROR: insert or update on table "comments" violates foreign key constraint "fk_photos_comment" (SQLSTATE 23503)` {
-			helpers.NotFoundResponse(ctx, "Photo not found")
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
+	recordAudit(ctx, c.auditService, models.AuditCreate, "comment", newComment.Id, nil, newComment)
 
 	response := CommentCreateResponse{
 		Id:        newComment.Id,
 		Message:   newComment.Message,
 		PhotoId:   newComment.PhotoId,
+		ParentId:  newComment.ParentId,
 		UserId:    newComment.UserId,
 		CreatedAt: newComment.CreatedAt,
 	}
@@ -108,90 +132,140 @@ func (c *CommentController) Create(ctx *gin.Context) {
 }
 
 func (c *CommentController) Get(ctx *gin.Context) {
-	var comments []models.Comment
+	pagination, err := helpers.ParsePagination(ctx, "updated_at")
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
 
-	err := c.db.Preload("User").Preload("Photo").Find(&comments).Error
+	filter, err := helpers.ParseFilters(ctx, "user_id", "photo_id")
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.BadRequestResponse(ctx, err.Error())
 		return
 	}
 
-	var response []CommentGetResponse
+	comments, total, err := c.commentService.List(helpers.GetUserId(ctx), repositories.ListQuery{Pagination: pagination, Filter: filter})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	ids := make([]uint, 0, len(comments))
 	for _, comment := range comments {
-		var userData UserCommentResponse
-		if comment.User != nil {
-			userData = UserCommentResponse{
-				Id:       comment.User.Id,
-				Username: comment.User.Username,
-				Email:    comment.User.Email,
-			}
-		}
-		var photoData PhotoCommentResponse
-		if comment.Photo != nil {
-			photoData = PhotoCommentResponse{
-				Id:       comment.Photo.Id,
-				Title:    comment.Photo.Title,
-				Caption:  comment.Photo.Caption,
-				PhotoUrl: comment.Photo.PhotoUrl,
-				UserId:   comment.Photo.UserId,
-			}
-		}
-		response = append(response, CommentGetResponse{
-			Id:        comment.Id,
-			Message:   comment.Message,
-			PhotoId:   comment.PhotoId,
-			UserId:    comment.UserId,
-			UpdatedAt: comment.UpdatedAt,
-			CreatedAt: comment.CreatedAt,
-			User:      userData,
-			Photo:     photoData,
-		})
+		ids = append(ids, comment.Id)
 	}
 
-	helpers.WriteJsonResponse(ctx, http.StatusOK, response)
+	likes, err := c.likeService.Counts(helpers.GetUserId(ctx), repositories.LikeComment, ids)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	response := make([]CommentGetResponse, 0, len(comments))
+	for _, comment := range comments {
+		response = append(response, newCommentGetResponse(comment, likes[comment.Id]))
+	}
+
+	var lastId uint
+	if len(comments) > 0 {
+		lastId = comments[len(comments)-1].Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(comments), total, lastId, pagination)
 }
 
-func (c *CommentController) Update(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
-	commentId := ctx.Param("commentId")
-	var commentReq CommentCreateRequest
-	var comment models.Comment
+func (c *CommentController) Thread(ctx *gin.Context) {
+	photoId, ok := helpers.GetParamId(ctx, "photoId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "photo not found")
+		return
+	}
 
-	err := ctx.ShouldBindJSON(&commentReq)
+	pagination, err := helpers.ParsePagination(ctx, "updated_at")
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.BadRequestResponse(ctx, err.Error())
 		return
 	}
 
-	updateComment := models.Comment{
-		Message: commentReq.Message,
+	threads, total, err := c.commentService.Thread(helpers.GetUserId(ctx), photoId, repositories.ListQuery{Pagination: pagination})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
 	}
 
-	err = c.db.First(&comment, commentId).Error
+	var ids []uint
+	collectThreadIds(threads, &ids)
+
+	likes, err := c.likeService.Counts(helpers.GetUserId(ctx), repositories.LikeComment, ids)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			helpers.NotFoundResponse(ctx, "data not found")
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	if comment.UserId != uint(userId.(float64)) {
-		helpers.UnauthorizeJsonResponse(ctx, "you're not allowed to update or edit this comment")
+	response := newCommentThreadResponses(threads, likes)
+
+	var lastId uint
+	if len(threads) > 0 {
+		lastId = threads[len(threads)-1].Comment.Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(threads), total, lastId, pagination)
+}
+
+func (c *CommentController) GetById(ctx *gin.Context) {
+	commentId, ok := helpers.GetParamId(ctx, "commentId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "comment not found")
+		return
+	}
+
+	comment, err := c.commentService.Get(helpers.GetUserId(ctx), commentId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	err = c.db.Model(&comment).Updates(updateComment).Error
+	likes, err := c.likeService.Counts(helpers.GetUserId(ctx), repositories.LikeComment, []uint{comment.Id})
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
+	helpers.WriteJsonResponse(ctx, http.StatusOK, newCommentGetResponse(*comment, likes[comment.Id]))
+}
+
+func (c *CommentController) Update(ctx *gin.Context) {
+	var commentReq CommentUpdateRequest
+
+	commentId, ok := helpers.GetParamId(ctx, "commentId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "comment not found")
+		return
+	}
+
+	err := helpers.BindJSON(ctx, &commentReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	updateComment := models.Comment{
+		Message: commentReq.Message,
+	}
+
+	before, err := c.commentService.Get(helpers.GetUserId(ctx), commentId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	comment, err := c.commentService.Update(helpers.GetUserId(ctx), commentId, updateComment)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, c.auditService, models.AuditUpdate, "comment", comment.Id, before, comment)
+
 	response := CommentUpdateResponse{
 		Id:        comment.Id,
 		Message:   comment.Message,
@@ -204,36 +278,101 @@ func (c *CommentController) Update(ctx *gin.Context) {
 }
 
 func (c *CommentController) Delete(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
-	commentId := ctx.Param("commentId")
-	var comment models.Comment
-
-	err := c.db.First(&comment, commentId).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			helpers.NotFoundResponse(ctx, "data not found")
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+	commentId, ok := helpers.GetParamId(ctx, "commentId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "comment not found")
 		return
 	}
 
-	if comment.UserId != uint(userId.(float64)) {
-		helpers.BadRequestResponse(ctx, "you're not allowed to delete this comment")
+	before, err := c.commentService.Get(helpers.GetUserId(ctx), commentId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	err = c.db.Delete(&comment).Error
+	err = c.commentService.Delete(helpers.GetUserId(ctx), commentId)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
+	recordAudit(ctx, c.auditService, models.AuditDelete, "comment", before.Id, before, nil)
 
 	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
 		"message": "Your comment has been successfully deleted",
 	})
 }
+
+func newCommentGetResponse(comment models.Comment, likes repositories.LikeCount) CommentGetResponse {
+	var userData UserCommentResponse
+	if comment.User != nil && !comment.Removed() {
+		userData = UserCommentResponse{
+			Id:       comment.User.Id,
+			Username: comment.User.Username,
+			Email:    comment.User.Email,
+		}
+	}
+	var photoData PhotoCommentResponse
+	if comment.Photo != nil {
+		photoData = PhotoCommentResponse{
+			Id:       comment.Photo.Id,
+			Title:    comment.Photo.Title,
+			Caption:  comment.Photo.Caption,
+			PhotoUrl: comment.Photo.PhotoUrl,
+			UserId:   comment.Photo.UserId,
+		}
+	}
+
+	response := CommentGetResponse{
+		Id:        comment.Id,
+		Message:   comment.Message,
+		PhotoId:   comment.PhotoId,
+		ParentId:  comment.ParentId,
+		Deleted:   comment.Removed(),
+		UpdatedAt: comment.UpdatedAt,
+		CreatedAt: comment.CreatedAt,
+		LikeCount: likes.Total,
+		LikedByMe: likes.LikedByMe,
+		User:      userData,
+		Photo:     photoData,
+	}
+	if !comment.Removed() {
+		response.UserId = comment.UserId
+	}
+
+	return response
+}
+
+// newCommentThreadResponses hides the author of tombstoned comments but keeps
+// their place in the tree.
+func newCommentThreadResponses(threads []services.CommentThread, likes map[uint]repositories.LikeCount) []CommentThreadResponse {
+	response := make([]CommentThreadResponse, 0, len(threads))
+	for _, thread := range threads {
+		comment := thread.Comment
+		item := CommentThreadResponse{
+			Id:         comment.Id,
+			Message:    comment.Message,
+			ParentId:   comment.ParentId,
+			Deleted:    comment.Removed(),
+			CreatedAt:  comment.CreatedAt,
+			UpdatedAt:  comment.UpdatedAt,
+			LikeCount:  likes[comment.Id].Total,
+			LikedByMe:  likes[comment.Id].LikedByMe,
+			ReplyCount: len(thread.Replies),
+			Replies:    newCommentThreadResponses(thread.Replies, likes),
+		}
+		if !comment.Removed() {
+			item.UserId = comment.UserId
+			item.User = newUserDataResponse(comment.User)
+		}
+		response = append(response, item)
+	}
+
+	return response
+}
+
+func collectThreadIds(threads []services.CommentThread, ids *[]uint) {
+	for _, thread := range threads {
+		*ids = append(*ids, thread.Comment.Id)
+		collectThreadIds(thread.Replies, ids)
+	}
+}
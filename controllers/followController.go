@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FollowStatusFollowing = "following"
+	FollowStatusRequested = "requested"
+)
+
+type FollowController struct {
+	followService *services.FollowService
+}
+
+type FollowStatusResponse struct {
+	UserId uint   `json:"user_id"`
+	Status string `json:"status"`
+}
+
+type FollowUserResponse struct {
+	Id          uint       `json:"id"`
+	Username    string     `json:"username"`
+	FollowedAt  *time.Time `json:"followed_at,omitempty"`
+	RequestedAt *time.Time `json:"requested_at,omitempty"`
+}
+
+func NewFollowController(followService *services.FollowService) *FollowController {
+	return &FollowController{
+		followService: followService,
+	}
+}
+
+func (f *FollowController) Follow(ctx *gin.Context) {
+	userId, ok := helpers.GetParamId(ctx, "userId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "user not found")
+		return
+	}
+
+	follow, err := f.followService.Follow(helpers.GetUserId(ctx), userId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, newFollowStatusResponse(*follow))
+}
+
+func (f *FollowController) Unfollow(ctx *gin.Context) {
+	userId, ok := helpers.GetParamId(ctx, "userId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "user not found")
+		return
+	}
+
+	err := f.followService.Unfollow(helpers.GetUserId(ctx), userId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": "You have successfully unfollowed this user",
+	})
+}
+
+func (f *FollowController) Followers(ctx *gin.Context) {
+	f.list(ctx, false)
+}
+
+func (f *FollowController) Following(ctx *gin.Context) {
+	f.list(ctx, true)
+}
+
+func (f *FollowController) Requests(ctx *gin.Context) {
+	pagination, err := helpers.ParsePagination(ctx)
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	follows, total, err := f.followService.Requests(helpers.GetUserId(ctx), repositories.ListQuery{Pagination: pagination})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	writeFollowPage(ctx, follows, total, pagination, false)
+}
+
+func (f *FollowController) Approve(ctx *gin.Context) {
+	followerId, ok := helpers.GetParamId(ctx, "userId")
+	if !ok {
+		helpers.ErrorResponse(ctx, services.ErrFollowRequestNotFound)
+		return
+	}
+
+	_, err := f.followService.Approve(helpers.GetUserId(ctx), followerId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": "Follow request has been approved",
+	})
+}
+
+func (f *FollowController) Reject(ctx *gin.Context) {
+	followerId, ok := helpers.GetParamId(ctx, "userId")
+	if !ok {
+		helpers.ErrorResponse(ctx, services.ErrFollowRequestNotFound)
+		return
+	}
+
+	err := f.followService.Reject(helpers.GetUserId(ctx), followerId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": "Follow request has been rejected",
+	})
+}
+
+func (f *FollowController) list(ctx *gin.Context, following bool) {
+	userId, ok := helpers.GetParamId(ctx, "userId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "user not found")
+		return
+	}
+
+	pagination, err := helpers.ParsePagination(ctx)
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	query := repositories.ListQuery{Pagination: pagination}
+	find := f.followService.Followers
+	if following {
+		find = f.followService.Following
+	}
+
+	follows, total, err := find(helpers.GetUserId(ctx), userId, query)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	writeFollowPage(ctx, follows, total, pagination, following)
+}
+
+// writeFollowPage lists the other side of each follow. The cursor is the id
+// of the follow, not of the user.
+func writeFollowPage(ctx *gin.Context, follows []models.Follow, total int64, pagination helpers.Pagination, following bool) {
+	response := make([]FollowUserResponse, 0, len(follows))
+	for _, follow := range follows {
+		user := follow.Follower
+		if following {
+			user = follow.Following
+		}
+
+		item := FollowUserResponse{FollowedAt: follow.AcceptedAt}
+		if !follow.Accepted() {
+			item.RequestedAt = follow.CreatedAt
+		}
+		if user != nil {
+			item.Id = user.Id
+			item.Username = user.Username
+		}
+		response = append(response, item)
+	}
+
+	var lastId uint
+	if len(follows) > 0 {
+		lastId = follows[len(follows)-1].Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(follows), total, lastId, pagination)
+}
+
+func newFollowStatusResponse(follow models.Follow) FollowStatusResponse {
+	status := FollowStatusFollowing
+	if !follow.Accepted() {
+		status = FollowStatusRequested
+	}
+
+	return FollowStatusResponse{
+		UserId: follow.FollowingId,
+		Status: status,
+	}
+}
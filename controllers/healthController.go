@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck reports whether a dependency is usable.
+type ReadinessCheck func(ctx context.Context) error
+
+type HealthController struct {
+	checks   map[string]ReadinessCheck
+	draining int32
+}
+
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewHealthController(checks map[string]ReadinessCheck) *HealthController {
+	return &HealthController{
+		checks: checks,
+	}
+}
+
+// SetDraining makes /readyz fail so load balancers stop sending traffic
+// while in-flight requests finish.
+func (h *HealthController) SetDraining() {
+	atomic.StoreInt32(&h.draining, 1)
+}
+
+func (h *HealthController) Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, ReadinessResponse{Status: "ok"})
+}
+
+func (h *HealthController) Readyz(ctx *gin.Context) {
+	if atomic.LoadInt32(&h.draining) == 1 {
+		ctx.JSON(http.StatusServiceUnavailable, ReadinessResponse{Status: "draining"})
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	response := ReadinessResponse{Status: "ok", Checks: map[string]string{}}
+	status := http.StatusOK
+	for _, name := range names {
+		if err := h.checks[name](checkCtx); err != nil {
+			response.Checks[name] = err.Error()
+			response.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		response.Checks[name] = "ok"
+	}
+
+	ctx.JSON(status, response)
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KeyController struct {
+	keyring *helpers.Keyring
+}
+
+func NewKeyController(keyring *helpers.Keyring) *KeyController {
+	return &KeyController{
+		keyring: keyring,
+	}
+}
+
+func (k *KeyController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	helpers.WriteJsonResponse(ctx, http.StatusOK, k.keyring.JWKS())
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"final-project-golang/config"
+	"final-project-golang/helpers"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// The keys in testdata only sign tests. es256 is public only, the way a
+// rotated key is kept.
+func TestJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keyring, err := helpers.LoadKeyring(config.JWTConfig{
+		ActiveKey: "rs256",
+		Keys: []config.JWTKeyConfig{
+			{Id: "rs256", Algorithm: "RS256", PrivateKeyFile: filepath.Join("testdata", "rs256.pem")},
+			{Id: "es256", Algorithm: "ES256", PublicKeyFile: filepath.Join("testdata", "es256.pub.pem")},
+			{Id: "ed25519", Algorithm: "EdDSA", PrivateKeyFile: filepath.Join("testdata", "ed25519.pem")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", NewKeyController(keyring).JWKS)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "public, max-age=300" {
+		t.Fatalf("status = %d, headers %v", w.Code, w.Header())
+	}
+
+	var got bytes.Buffer
+	if err := json.Indent(&got, w.Body.Bytes(), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	got.WriteByte('\n')
+
+	golden := filepath.Join("testdata", "jwks.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != string(want) {
+		t.Fatalf("JWKS =\n%s\nwant\n%s", got.String(), want)
+	}
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LikeController struct {
+	likeService *services.LikeService
+}
+
+type LikeStatusResponse struct {
+	LikeCount int64 `json:"like_count"`
+	LikedByMe bool  `json:"liked_by_me"`
+}
+
+type LikerResponse struct {
+	Id       uint       `json:"id"`
+	Username string     `json:"username"`
+	LikedAt  *time.Time `json:"liked_at"`
+}
+
+func NewLikeController(likeService *services.LikeService) *LikeController {
+	return &LikeController{
+		likeService: likeService,
+	}
+}
+
+func (l *LikeController) LikePhoto(ctx *gin.Context) {
+	l.like(ctx, repositories.LikePhoto, "photoId", true)
+}
+
+func (l *LikeController) UnlikePhoto(ctx *gin.Context) {
+	l.like(ctx, repositories.LikePhoto, "photoId", false)
+}
+
+func (l *LikeController) PhotoLikers(ctx *gin.Context) {
+	l.likers(ctx, repositories.LikePhoto, "photoId")
+}
+
+func (l *LikeController) LikeComment(ctx *gin.Context) {
+	l.like(ctx, repositories.LikeComment, "commentId", true)
+}
+
+func (l *LikeController) UnlikeComment(ctx *gin.Context) {
+	l.like(ctx, repositories.LikeComment, "commentId", false)
+}
+
+func (l *LikeController) CommentLikers(ctx *gin.Context) {
+	l.likers(ctx, repositories.LikeComment, "commentId")
+}
+
+func (l *LikeController) like(ctx *gin.Context, target repositories.LikeTarget, param string, like bool) {
+	targetId, ok := helpers.GetParamId(ctx, param)
+	if !ok {
+		helpers.NotFoundResponse(ctx, targetNotFound(target))
+		return
+	}
+
+	update := l.likeService.Unlike
+	if like {
+		update = l.likeService.Like
+	}
+
+	count, err := update(helpers.GetUserId(ctx), target, targetId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, newLikeStatusResponse(count))
+}
+
+func (l *LikeController) likers(ctx *gin.Context, target repositories.LikeTarget, param string) {
+	targetId, ok := helpers.GetParamId(ctx, param)
+	if !ok {
+		helpers.NotFoundResponse(ctx, targetNotFound(target))
+		return
+	}
+
+	pagination, err := helpers.ParsePagination(ctx)
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	likes, total, err := l.likeService.Likers(helpers.GetUserId(ctx), target, targetId, repositories.ListQuery{Pagination: pagination})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	response := make([]LikerResponse, 0, len(likes))
+	for _, like := range likes {
+		item := LikerResponse{Id: like.UserId, LikedAt: like.CreatedAt}
+		if like.User != nil {
+			item.Username = like.User.Username
+		}
+		response = append(response, item)
+	}
+
+	var lastId uint
+	if len(likes) > 0 {
+		lastId = likes[len(likes)-1].Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(likes), total, lastId, pagination)
+}
+
+func newLikeStatusResponse(count repositories.LikeCount) LikeStatusResponse {
+	return LikeStatusResponse{
+		LikeCount: count.Total,
+		LikedByMe: count.LikedByMe,
+	}
+}
+
+func targetNotFound(target repositories.LikeTarget) string {
+	if target == repositories.LikePhoto {
+		return "photo not found"
+	}
+
+	return "comment not found"
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MediaController struct {
+	mediaService *services.MediaService
+}
+
+func NewMediaController(mediaService *services.MediaService) *MediaController {
+	return &MediaController{
+		mediaService: mediaService,
+	}
+}
+
+// Get streams a stored file. Keys are random and never reused, so responses
+// for public accounts can be cached forever. Those of private accounts are
+// kept by the browser only and checked on every use, since a follower who is
+// removed must stop seeing them.
+func (m *MediaController) Get(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+
+	media, err := m.mediaService.Open(ctx.Request.Context(), helpers.GetUserId(ctx), key)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	defer media.Body.Close()
+
+	contentType := media.Object.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	cacheControl := "public, max-age=31536000, immutable"
+	if !media.Public {
+		cacheControl = "private, no-cache"
+	}
+
+	ctx.DataFromReader(http.StatusOK, media.Object.Size, contentType, media.Body, map[string]string{
+		"Cache-Control":          cacheControl,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
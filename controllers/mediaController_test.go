@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
+	"final-project-golang/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMediaCacheControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	store := storage.NewMemory("/media")
+
+	tests := []struct {
+		name    string
+		private bool
+		want    string
+	}{
+		{name: "public", want: "public, max-age=31536000, immutable"},
+		{name: "private", private: true, want: "private, no-cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.User{Username: tt.name, Email: tt.name + "@example.com", Password: "secret123", Age: 20, Private: tt.private}
+			if err := repos.Users.Create(&user); err != nil {
+				t.Fatal(err)
+			}
+
+			key := "photos/" + tt.name + ".jpg"
+			if err := store.Put(context.Background(), key, strings.NewReader("jpeg"), 4, "image/jpeg"); err != nil {
+				t.Fatal(err)
+			}
+			photo := &models.Photo{Title: tt.name, PhotoUrl: store.URL(key), UserId: user.Id, StorageKey: &key}
+			if err := repos.Photos.Create(photo); err != nil {
+				t.Fatal(err)
+			}
+
+			router := gin.New()
+			router.GET("/media/*key", func(ctx *gin.Context) {
+				ctx.Set("id", float64(user.Id))
+			}, NewMediaController(services.NewMediaService(repos, store)).Get)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/media/"+key, nil))
+
+			if w.Code != http.StatusOK || w.Body.String() != "jpeg" {
+				t.Fatalf("status = %d, body %q", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Fatalf("Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
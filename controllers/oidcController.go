@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcLoginCookie keeps the binding of a sign-in in the browser that started
+// it. The state goes to the client too, but only this browser has the
+// cookie, and other sites can't set or send it.
+const oidcLoginCookie = "oidc_login"
+
+type OIDCController struct {
+	oidcService  *services.OIDCService
+	auditService *services.AuditService
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" valid:"required~code is required"`
+	State string `json:"state" valid:"required~state is required"`
+}
+
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+type OIDCStartResponse struct {
+	AuthorizationUrl string    `json:"authorization_url"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type IdentityResponse struct {
+	Id        uint       `json:"id"`
+	Provider  string     `json:"provider"`
+	Email     string     `json:"email"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+func NewOIDCController(oidcService *services.OIDCService, auditService *services.AuditService) *OIDCController {
+	return &OIDCController{
+		oidcService:  oidcService,
+		auditService: auditService,
+	}
+}
+
+func (o *OIDCController) Providers(ctx *gin.Context) {
+	helpers.WriteJsonResponse(ctx, http.StatusOK, OIDCProvidersResponse{
+		Providers: o.oidcService.Providers(),
+	})
+}
+
+func (o *OIDCController) Start(ctx *gin.Context) {
+	login, err := o.oidcService.Start(ctx.Request.Context(), ctx.Param("provider"))
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	setOIDCLoginCookie(ctx, login.Binding, int(time.Until(login.ExpiresAt).Seconds()))
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, OIDCStartResponse{
+		AuthorizationUrl: login.URL,
+		State:            login.State,
+		ExpiresAt:        login.ExpiresAt,
+	})
+}
+
+func (o *OIDCController) Callback(ctx *gin.Context) {
+	var callbackReq OIDCCallbackRequest
+
+	err := helpers.BindJSON(ctx, &callbackReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	binding, _ := ctx.Cookie(oidcLoginCookie)
+	setOIDCLoginCookie(ctx, "", -1)
+
+	result, linked, err := o.oidcService.Callback(ctx.Request.Context(), ctx.Param("provider"), callbackReq.Code, callbackReq.State, binding)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	if linked != nil {
+		recordAuditAs(ctx, o.auditService, linked.UserId, models.AuditCreate, "identity", linked.Id, nil, linked)
+	}
+
+	writeLoginResult(ctx, result)
+}
+
+func (o *OIDCController) Identities(ctx *gin.Context) {
+	identities, err := o.oidcService.Identities(helpers.GetUserId(ctx))
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	response := make([]IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		response = append(response, newIdentityResponse(identity))
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, response)
+}
+
+func (o *OIDCController) Unlink(ctx *gin.Context) {
+	identityId, ok := helpers.GetParamId(ctx, "identityId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "identity not found")
+		return
+	}
+
+	identity, err := o.oidcService.Unlink(helpers.GetUserId(ctx), identityId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, o.auditService, models.AuditDelete, "identity", identity.Id, identity, nil)
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": "The provider account has been unlinked",
+	})
+}
+
+// setOIDCLoginCookie sets the binding cookie, or removes it when maxAge is
+// negative.
+func setOIDCLoginCookie(ctx *gin.Context, value string, maxAge int) {
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     oidcLoginCookie,
+		Value:    value,
+		Path:     "/auth/oidc",
+		MaxAge:   maxAge,
+		Secure:   ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func newIdentityResponse(identity models.Identity) IdentityResponse {
+	return IdentityResponse{
+		Id:        identity.Id,
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt,
+	}
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordController struct {
+	userService     *services.UserService
+	passwordService *services.PasswordService
+	auditService    *services.AuditService
+}
+
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" valid:"required~current_password is required"`
+	Password        string `json:"password" valid:"required~password is required, password~password must be at least 8 characters and contain a letter and a digit"`
+}
+
+type PasswordForgotRequest struct {
+	Email string `json:"email" valid:"required~email is required, email~Invalid format email"`
+}
+
+type PasswordResetRequest struct {
+	Token    string `json:"token" valid:"required~token is required"`
+	Password string `json:"password" valid:"required~password is required, password~password must be at least 8 characters and contain a letter and a digit"`
+}
+
+func NewPasswordController(userService *services.UserService, passwordService *services.PasswordService, auditService *services.AuditService) *PasswordController {
+	return &PasswordController{
+		userService:     userService,
+		passwordService: passwordService,
+		auditService:    auditService,
+	}
+}
+
+func (p *PasswordController) Change(ctx *gin.Context) {
+	var passwordReq PasswordChangeRequest
+
+	err := helpers.BindJSON(ctx, &passwordReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	before, err := p.userService.Get(helpers.GetUserId(ctx))
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	user, tokens, err := p.passwordService.Change(before.Id, passwordReq.CurrentPassword, passwordReq.Password)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, p.auditService, models.AuditUpdate, "user", user.Id, before, user)
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, newUserTokenResponse(tokens))
+}
+
+// Forgot answers the same whether or not the email belongs to an account.
+func (p *PasswordController) Forgot(ctx *gin.Context) {
+	var passwordReq PasswordForgotRequest
+
+	err := helpers.BindJSON(ctx, &passwordReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	p.passwordService.RequestReset(passwordReq.Email)
+
+	helpers.WriteJsonResponse(ctx, http.StatusAccepted, gin.H{
+		"message": "If the email belongs to an account, a reset link has been sent to it",
+	})
+}
+
+func (p *PasswordController) Reset(ctx *gin.Context) {
+	var passwordReq PasswordResetRequest
+
+	err := helpers.BindJSON(ctx, &passwordReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	before, user, err := p.passwordService.Reset(passwordReq.Token, passwordReq.Password)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAuditAs(ctx, p.auditService, user.Id, models.AuditUpdate, "user", user.Id, before, user)
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": "Your password has been reset, please log in again",
+	})
+}
@@ -1,33 +1,54 @@
 package controllers
 
 import (
+	"final-project-golang/apperrors"
 	"final-project-golang/helpers"
 	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
+	"fmt"
 	"net/http"
 	"time"
 
-	"github.com/asaskevich/govalidator"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
+// multipartOverhead allows for the boundaries and text fields sent along
+// with the file.
+const multipartOverhead = 1 << 20
+
 type PhotoController struct {
-	db *gorm.DB
+	photoService  *services.PhotoService
+	likeService   *services.LikeService
+	auditService  *services.AuditService
+	maxUploadSize int64
+}
+
+type PhotoUploadRequest struct {
+	Title   string `form:"title" valid:"required~title is required"`
+	Caption string `form:"caption"`
+}
+
+type PhotoUpdateRequest struct {
+	Title    string `json:"title" valid:"required~title is required"`
+	Caption  string `json:"caption"`
+	PhotoUrl string `json:"photo_url" valid:"url~Invalid format photo_url"`
 }
 
 type PhotoCreateRequest struct {
-	Title    string `json:"title"`
+	Title    string `json:"title" valid:"required~title is required"`
 	Caption  string `json:"caption"`
-	PhotoUrl string `json:"photo_url"`
+	PhotoUrl string `json:"photo_url" valid:"required~photo_url is required, url~Invalid format photo_url"`
 }
 
 type PhotoCreateResponse struct {
-	Id        uint       `json:"id"`
-	Title     string     `json:"title"`
-	Caption   string     `json:"caption"`
-	PhotoUrl  string     `json:"photo_url"`
-	UserId    uint       `json:"user_id"`
-	CreatedAt *time.Time `json:"created_at"`
+	Id               uint       `json:"id"`
+	Title            string     `json:"title"`
+	Caption          string     `json:"caption"`
+	PhotoUrl         string     `json:"photo_url"`
+	UserId           uint       `json:"user_id"`
+	CreatedAt        *time.Time `json:"created_at"`
+	ProcessingStatus string     `json:"processing_status,omitempty"`
 }
 
 type PhotoUpdateResponse struct {
@@ -40,10 +61,39 @@ type PhotoUpdateResponse struct {
 }
 
 type PhotoGetResponse struct {
+	Id               uint                     `json:"id"`
+	Title            string                   `json:"title"`
+	Caption          string                   `json:"caption"`
+	PhotoUrl         string                   `json:"photo_url"`
+	UserId           uint                     `json:"user_id"`
+	CreatedAt        *time.Time               `json:"created_at"`
+	UpdatedAt        *time.Time               `json:"updated_at"`
+	ProcessingStatus string                   `json:"processing_status,omitempty"`
+	Width            int                      `json:"width,omitempty"`
+	Height           int                      `json:"height,omitempty"`
+	Format           string                   `json:"format,omitempty"`
+	Blurhash         string                   `json:"blurhash,omitempty"`
+	Renditions       *PhotoRenditionsResponse `json:"renditions,omitempty"`
+	LikeCount        int64                    `json:"like_count"`
+	LikedByMe        bool                     `json:"liked_by_me"`
+	User             UserDataResponse
+}
+
+type PhotoRenditionsResponse struct {
+	Original  string `json:"original"`
+	Medium    string `json:"medium"`
+	Thumbnail string `json:"thumbnail"`
+}
+
+type PhotoDetailResponse struct {
+	PhotoGetResponse
+	Comments []PhotoCommentDataResponse `json:"comments"`
+}
+
+type PhotoCommentDataResponse struct {
 	Id        uint       `json:"id"`
-	Title     string     `json:"title"`
-	Caption   string     `json:"caption"`
-	PhotoUrl  string     `json:"photo_url"`
+	Message   string     `json:"message"`
+	ParentId  *uint      `json:"parent_id"`
 	UserId    uint       `json:"user_id"`
 	CreatedAt *time.Time `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
@@ -55,19 +105,28 @@ type UserDataResponse struct {
 	Username string `json:"username"`
 }
 
-func NewPhotoController(db *gorm.DB) *PhotoController {
+func NewPhotoController(photoService *services.PhotoService, likeService *services.LikeService, auditService *services.AuditService, maxUploadSize int64) *PhotoController {
 	return &PhotoController{
-		db: db,
+		photoService:  photoService,
+		likeService:   likeService,
+		auditService:  auditService,
+		maxUploadSize: maxUploadSize,
 	}
 }
 
+// Create accepts either JSON with a photo_url or a multipart form with the
+// image in the "photo" field.
 func (p *PhotoController) Create(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
+	if ctx.ContentType() == gin.MIMEMultipartPOSTForm {
+		p.upload(ctx)
+		return
+	}
+
 	var photoReq PhotoCreateRequest
 
-	err := ctx.ShouldBindJSON(&photoReq)
+	err := helpers.BindJSON(ctx, &photoReq)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
@@ -75,18 +134,15 @@ func (p *PhotoController) Create(ctx *gin.Context) {
 		Title:    photoReq.Title,
 		Caption:  photoReq.Caption,
 		PhotoUrl: photoReq.PhotoUrl,
-		UserId:   uint(userId.(float64)),
+		UserId:   helpers.GetUserId(ctx),
 	}
 
-	err = p.db.Create(&newPhoto).Error
+	err = p.photoService.Create(&newPhoto)
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
+	recordAudit(ctx, p.auditService, models.AuditCreate, "photo", newPhoto.Id, nil, newPhoto)
 
 	response := PhotoCreateResponse{
 		Id:        newPhoto.Id,
@@ -100,89 +156,203 @@ func (p *PhotoController) Create(ctx *gin.Context) {
 	helpers.WriteJsonResponse(ctx, http.StatusCreated, response)
 }
 
+func (p *PhotoController) upload(ctx *gin.Context) {
+	tooLarge := apperrors.New(apperrors.CodeTooLarge, fmt.Sprintf("photo must not be larger than %d bytes", p.maxUploadSize))
+	if ctx.Request.ContentLength > p.maxUploadSize+multipartOverhead {
+		helpers.ErrorResponse(ctx, tooLarge)
+		return
+	}
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, p.maxUploadSize+multipartOverhead)
+
+	var photoReq PhotoUploadRequest
+
+	err := helpers.BindForm(ctx, &photoReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("photo")
+	if err != nil {
+		helpers.ErrorResponse(ctx, apperrors.Validation(apperrors.FieldError{Field: "photo", Rule: "required", Message: "photo is required"}))
+		return
+	}
+	if fileHeader.Size > p.maxUploadSize {
+		helpers.ErrorResponse(ctx, tooLarge)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	defer file.Close()
+
+	newPhoto := models.Photo{
+		Title:   photoReq.Title,
+		Caption: photoReq.Caption,
+		UserId:  helpers.GetUserId(ctx),
+	}
+
+	err = p.photoService.Upload(ctx.Request.Context(), &newPhoto, file, fileHeader.Size)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, p.auditService, models.AuditCreate, "photo", newPhoto.Id, nil, newPhoto)
+
+	response := PhotoCreateResponse{
+		Id:        newPhoto.Id,
+		Title:     newPhoto.Title,
+		Caption:   newPhoto.Caption,
+		PhotoUrl:  newPhoto.PhotoUrl,
+		UserId:    newPhoto.UserId,
+		CreatedAt: newPhoto.CreatedAt,
+
+		ProcessingStatus: newPhoto.ProcessingStatus,
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusAccepted, response)
+}
+
 func (p *PhotoController) Get(ctx *gin.Context) {
-	var photos []models.Photo
+	pagination, err := helpers.ParsePagination(ctx, "updated_at", "title")
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
 
-	err := p.db.Preload("User").Find(&photos).Error
+	filter, err := helpers.ParseFilters(ctx, "user_id")
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		helpers.BadRequestResponse(ctx, err)
+		helpers.BadRequestResponse(ctx, err.Error())
 		return
 	}
 
-	var response []PhotoGetResponse
-	for _, photo := range photos {
-		var userData UserDataResponse
-		if photo.User != nil {
-			userData = UserDataResponse{
-				Username: photo.User.Username,
-				Email:    photo.User.Email,
-			}
-		}
-		response = append(response, PhotoGetResponse{
-			Id:        photo.Id,
-			Title:     photo.Title,
-			Caption:   photo.Caption,
-			PhotoUrl:  photo.PhotoUrl,
-			UserId:    photo.UserId,
-			CreatedAt: photo.CreatedAt,
-			UpdatedAt: photo.UpdatedAt,
-			User:      userData,
-		})
+	photos, total, err := p.photoService.List(helpers.GetUserId(ctx), repositories.ListQuery{Pagination: pagination, Filter: filter})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
 	}
 
-	helpers.WriteJsonResponse(ctx, http.StatusOK, response)
+	response, err := p.newPhotoGetResponses(ctx, photos)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	var lastId uint
+	if len(photos) > 0 {
+		lastId = photos[len(photos)-1].Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(photos), total, lastId, pagination)
 }
 
-func (p *PhotoController) Update(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
-	photoId := ctx.Param("photoId")
-	var photoReq PhotoCreateRequest
-	var photo models.Photo
+func (p *PhotoController) Feed(ctx *gin.Context) {
+	pagination, err := helpers.ParseCursor(ctx)
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
 
-	err := ctx.ShouldBindJSON(&photoReq)
+	photos, err := p.photoService.Feed(helpers.GetUserId(ctx), pagination)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	updatedPhoto := models.Photo{
-		Title:    photoReq.Title,
-		Caption:  photoReq.Caption,
-		PhotoUrl: photoReq.PhotoUrl,
+	response, err := p.newPhotoGetResponses(ctx, photos)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
 	}
 
-	// Tambahin validasi Update
-	_, errCreate := govalidator.ValidateStruct(&updatedPhoto)
-	if errCreate != nil {
-		helpers.BadRequestResponse(ctx, err)
+	var lastId uint
+	if len(photos) > 0 {
+		lastId = photos[len(photos)-1].Id
+	}
+
+	helpers.WriteCursorResponse(ctx, response, len(photos), lastId, pagination)
+}
+
+func (p *PhotoController) GetById(ctx *gin.Context) {
+	photoId, ok := helpers.GetParamId(ctx, "photoId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "photo not found")
 		return
 	}
 
-	err = p.db.First(&photo, photoId).Error
+	photo, err := p.photoService.Get(helpers.GetUserId(ctx), photoId)
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, "data not found")
-			return
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	comments := make([]PhotoCommentDataResponse, 0, len(photo.Comment))
+	for _, comment := range photo.Comment {
+		data := PhotoCommentDataResponse{
+			Id:        comment.Id,
+			Message:   comment.Message,
+			ParentId:  comment.ParentId,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+		}
+		if !comment.Removed() {
+			data.UserId = comment.UserId
+			data.User = newUserDataResponse(comment.User)
 		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		comments = append(comments, data)
+	}
+
+	likes, err := p.likeService.Counts(helpers.GetUserId(ctx), repositories.LikePhoto, []uint{photo.Id})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	if photo.UserId != uint(userId.(float64)) {
-		helpers.UnauthorizeJsonResponse(ctx, "you're not allowed to update or edit this photo")
+	response := PhotoDetailResponse{
+		PhotoGetResponse: newPhotoGetResponse(*photo, likes[photo.Id]),
+		Comments:         comments,
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, response)
+}
+
+func (p *PhotoController) Update(ctx *gin.Context) {
+	var photoReq PhotoUpdateRequest
+
+	photoId, ok := helpers.GetParamId(ctx, "photoId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "photo not found")
 		return
 	}
 
-	err = p.db.Model(&photo).Updates(updatedPhoto).Error
+	err := helpers.BindJSON(ctx, &photoReq)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
+	updatedPhoto := models.Photo{
+		Title:    photoReq.Title,
+		Caption:  photoReq.Caption,
+		PhotoUrl: photoReq.PhotoUrl,
+	}
+
+	before, err := p.photoService.Get(helpers.GetUserId(ctx), photoId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	photo, err := p.photoService.Update(helpers.GetUserId(ctx), photoId, updatedPhoto)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, p.auditService, models.AuditUpdate, "photo", photo.Id, before, photo)
+
 	response := PhotoUpdateResponse{
 		Id:        photo.Id,
 		Title:     photo.Title,
@@ -196,37 +366,88 @@ func (p *PhotoController) Update(ctx *gin.Context) {
 }
 
 func (p *PhotoController) Delete(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
-	photoId := ctx.Param("photoId")
-	var photo models.Photo
-
-	err := p.db.First(&photo, photoId).Error
-	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, "data not found")
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+	photoId, ok := helpers.GetParamId(ctx, "photoId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "photo not found")
 		return
 	}
 
-	if photo.UserId != uint(userId.(float64)) {
-		helpers.UnauthorizeJsonResponse(ctx, "you're not allowed to delete this photo")
+	before, err := p.photoService.Get(helpers.GetUserId(ctx), photoId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	err = p.db.Delete(&photo).Error
+	err = p.photoService.Delete(helpers.GetUserId(ctx), photoId)
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, err.Error())
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err.Error())
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
+	recordAudit(ctx, p.auditService, models.AuditDelete, "photo", before.Id, before, nil)
 
 	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
 		"message": "Your photo has been successfully deleted",
 	})
+}
+
+// newPhotoGetResponses shapes a page of photos with the like counts of the
+// current user, loaded in one query.
+func (p *PhotoController) newPhotoGetResponses(ctx *gin.Context, photos []models.Photo) ([]PhotoGetResponse, error) {
+	ids := make([]uint, 0, len(photos))
+	for _, photo := range photos {
+		ids = append(ids, photo.Id)
+	}
 
+	likes, err := p.likeService.Counts(helpers.GetUserId(ctx), repositories.LikePhoto, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]PhotoGetResponse, 0, len(photos))
+	for _, photo := range photos {
+		response = append(response, newPhotoGetResponse(photo, likes[photo.Id]))
+	}
+
+	return response, nil
+}
+
+func newPhotoGetResponse(photo models.Photo, likes repositories.LikeCount) PhotoGetResponse {
+	response := PhotoGetResponse{
+		Id:               photo.Id,
+		Title:            photo.Title,
+		Caption:          photo.Caption,
+		PhotoUrl:         photo.PhotoUrl,
+		UserId:           photo.UserId,
+		CreatedAt:        photo.CreatedAt,
+		UpdatedAt:        photo.UpdatedAt,
+		ProcessingStatus: photo.ProcessingStatus,
+		Width:            photo.Width,
+		Height:           photo.Height,
+		Format:           photo.Format,
+		Blurhash:         photo.Blurhash,
+		LikeCount:        likes.Total,
+		LikedByMe:        likes.LikedByMe,
+		User:             newUserDataResponse(photo.User),
+	}
+
+	if photo.ProcessingStatus == models.PhotoProcessingReady {
+		response.Renditions = &PhotoRenditionsResponse{
+			Original:  photo.PhotoUrl,
+			Medium:    photo.MediumUrl,
+			Thumbnail: photo.ThumbnailUrl,
+		}
+	}
+
+	return response
+}
+
+func newUserDataResponse(user *models.User) UserDataResponse {
+	if user == nil {
+		return UserDataResponse{}
+	}
+
+	return UserDataResponse{
+		Username: user.Username,
+		Email:    user.Email,
+	}
 }
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RestoreController struct {
+	restoreService *services.RestoreService
+	auditService   *services.AuditService
+}
+
+type UserRestoreRequest struct {
+	Email    string `json:"email" valid:"required~email is required, email~Invalid format email"`
+	Password string `json:"password" valid:"required~password is required"`
+}
+
+func NewRestoreController(restoreService *services.RestoreService, auditService *services.AuditService) *RestoreController {
+	return &RestoreController{
+		restoreService: restoreService,
+		auditService:   auditService,
+	}
+}
+
+func (r *RestoreController) User(ctx *gin.Context) {
+	var userReq UserRestoreRequest
+
+	err := helpers.BindJSON(ctx, &userReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	user, err := r.restoreService.RestoreUser(userReq.Email, userReq.Password)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	// A deleted user has no session, so they restore their account themselves.
+	recordAuditAs(ctx, r.auditService, user.Id, models.AuditRestore, "user", user.Id, nil, user)
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"id":      user.Id,
+		"message": "Your account has been successfully restored",
+	})
+}
+
+func (r *RestoreController) Photo(ctx *gin.Context) {
+	photoId, ok := helpers.GetParamId(ctx, "photoId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "photo not found")
+		return
+	}
+
+	photo, err := r.restoreService.RestorePhoto(helpers.GetUserId(ctx), photoId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, r.auditService, models.AuditRestore, "photo", photo.Id, nil, photo)
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"id":      photo.Id,
+		"message": "Your photo has been successfully restored",
+	})
+}
+
+func (r *RestoreController) Comment(ctx *gin.Context) {
+	commentId, ok := helpers.GetParamId(ctx, "commentId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "comment not found")
+		return
+	}
+
+	comment, err := r.restoreService.RestoreComment(helpers.GetUserId(ctx), commentId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, r.auditService, models.AuditRestore, "comment", comment.Id, nil, comment)
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"id":      comment.Id,
+		"message": "Your comment has been successfully restored",
+	})
+}
+
+func (r *RestoreController) Social(ctx *gin.Context) {
+	socialMediaId, ok := helpers.GetParamId(ctx, "socialMediaId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "social media not found")
+		return
+	}
+
+	social, err := r.restoreService.RestoreSocial(helpers.GetUserId(ctx), socialMediaId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	recordAudit(ctx, r.auditService, models.AuditRestore, "social", social.Id, nil, social)
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"id":      social.Id,
+		"message": "Your social media has been successfully restored",
+	})
+}
@@ -3,20 +3,22 @@ package controllers
 import (
 	"final-project-golang/helpers"
 	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type SocialController struct {
-	db *gorm.DB
+	socialService *services.SocialService
+	auditService  *services.AuditService
 }
 
 type SocialCreateRequest struct {
-	Name           string `json:"name"`
-	SocialMediaUrl string `json:"social_media_url"`
+	Name           string `json:"name" valid:"required~name is required, maxstringlength(100)~name must be at most 100 characters"`
+	SocialMediaUrl string `json:"social_media_url" valid:"required~social_media_url is required, url~Invalid format social_media_url"`
 }
 
 type SocialCreateResponse struct {
@@ -35,10 +37,6 @@ type SocialUpdateResponse struct {
 	UpdatedAt      *time.Time `json:"updated_at"`
 }
 
-type SocialGetResponse struct {
-	SocialMedias []SocialData `json:"social_medias"`
-}
-
 type SocialData struct {
 	Id             uint       `json:"id"`
 	Name           string     `json:"name"`
@@ -54,37 +52,34 @@ type UserSocialResponse struct {
 	Username string `json:"username"`
 }
 
-func NewSocialController(db *gorm.DB) *SocialController {
+func NewSocialController(socialService *services.SocialService, auditService *services.AuditService) *SocialController {
 	return &SocialController{
-		db: db,
+		socialService: socialService,
+		auditService:  auditService,
 	}
 }
 
 func (s *SocialController) Create(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
 	var socialReq SocialCreateRequest
 
-	err := ctx.ShouldBindJSON(&socialReq)
+	err := helpers.BindJSON(ctx, &socialReq)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
 	newSocial := models.Social{
 		Name:           socialReq.Name,
 		SocialMediaUrl: socialReq.SocialMediaUrl,
-		UserId:         uint(userId.(float64)),
+		UserId:         helpers.GetUserId(ctx),
 	}
 
-	err = s.db.Create(&newSocial).Error
+	err = s.socialService.Create(&newSocial)
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
+	recordAudit(ctx, s.auditService, models.AuditCreate, "social", newSocial.Id, nil, newSocial)
 
 	response := SocialCreateResponse{
 		Id:             newSocial.Id,
@@ -98,86 +93,85 @@ func (s *SocialController) Create(ctx *gin.Context) {
 }
 
 func (s *SocialController) Get(ctx *gin.Context) {
-	var socials []models.Social
+	pagination, err := helpers.ParsePagination(ctx, "updated_at", "name")
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
 
-	err := s.db.Preload("User").Find(&socials).Error
+	filter, err := helpers.ParseFilters(ctx, "user_id")
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.BadRequestResponse(ctx, err.Error())
+		return
+	}
+
+	socials, total, err := s.socialService.List(repositories.ListQuery{Pagination: pagination, Filter: filter})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	var response SocialGetResponse
+	response := make([]SocialData, 0, len(socials))
 	for _, social := range socials {
-		var userData UserSocialResponse
-		if social.User != nil {
-			userData = UserSocialResponse{
-				Id:       social.User.Id,
-				Username: social.User.Username,
-			}
-		}
+		response = append(response, newSocialData(social))
+	}
 
-		socialMediasResponse := SocialData{
-			Id:             social.Id,
-			Name:           social.Name,
-			SocialMediaUrl: social.SocialMediaUrl,
-			UserId:         social.UserId,
-			CreatedAt:      social.CreatedAt,
-			UpdatedAt:      social.UpdatedAt,
-			User:           userData,
-		}
+	var lastId uint
+	if len(socials) > 0 {
+		lastId = socials[len(socials)-1].Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(socials), total, lastId, pagination)
+}
 
-		response.SocialMedias = append(response.SocialMedias, socialMediasResponse)
+func (s *SocialController) GetById(ctx *gin.Context) {
+	socialMediaId, ok := helpers.GetParamId(ctx, "socialMediaId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "social media not found")
+		return
 	}
 
-	if len(response.SocialMedias) == 0 {
-		response.SocialMedias = make([]SocialData, 0)
+	social, err := s.socialService.Get(socialMediaId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
 	}
 
-	helpers.WriteJsonResponse(ctx, http.StatusOK, response)
+	helpers.WriteJsonResponse(ctx, http.StatusOK, newSocialData(*social))
 }
 
 func (s *SocialController) Update(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
-	socialMediaId := ctx.Param("socialMediaId")
 	var socialReq SocialCreateRequest
-	var social models.Social
 
-	err := ctx.ShouldBindJSON(&socialReq)
+	socialMediaId, ok := helpers.GetParamId(ctx, "socialMediaId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "social media not found")
+		return
+	}
+
+	err := helpers.BindJSON(ctx, &socialReq)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
 	updatedSocial := models.Social{
 		Name:           socialReq.Name,
 		SocialMediaUrl: socialReq.SocialMediaUrl,
-		UserId:         uint(userId.(float64)),
 	}
 
-	err = s.db.First(&social, socialMediaId).Error
+	before, err := s.socialService.Get(socialMediaId)
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, "data not found")
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
-		return
-	}
-
-	if social.UserId != uint(userId.(float64)) {
-		helpers.UnauthorizeJsonResponse(ctx, "you're not allowed to update or edit this social media")
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	err = s.db.Model(&social).Updates(updatedSocial).Error
+	social, err := s.socialService.Update(helpers.GetUserId(ctx), socialMediaId, updatedSocial)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
+	recordAudit(ctx, s.auditService, models.AuditUpdate, "social", social.Id, before, social)
 
 	response := SocialUpdateResponse{
 		Id:             social.Id,
@@ -191,37 +185,46 @@ func (s *SocialController) Update(ctx *gin.Context) {
 }
 
 func (s *SocialController) Delete(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
-	socialId := ctx.Param("socialMediaId")
-	var social models.Social
-
-	err := s.db.First(&social, socialId).Error
-	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, "data not found")
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+	socialMediaId, ok := helpers.GetParamId(ctx, "socialMediaId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "social media not found")
 		return
 	}
 
-	if social.UserId != uint(userId.(float64)) {
-		helpers.UnauthorizeJsonResponse(ctx, "you're not allowed to delete this social media")
+	before, err := s.socialService.Get(socialMediaId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	err = s.db.Delete(&social).Error
+	err = s.socialService.Delete(helpers.GetUserId(ctx), socialMediaId)
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
+	recordAudit(ctx, s.auditService, models.AuditDelete, "social", before.Id, before, nil)
 
 	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
 		"message": "Your social media has been successfully deleted",
 	})
+}
 
+func newSocialData(social models.Social) SocialData {
+	var userData UserSocialResponse
+	if social.User != nil {
+		userData = UserSocialResponse{
+			Id:       social.User.Id,
+			Username: social.User.Username,
+		}
+	}
+
+	return SocialData{
+		Id:             social.Id,
+		Name:           social.Name,
+		SocialMediaUrl: social.SocialMediaUrl,
+		UserId:         social.UserId,
+		CreatedAt:      social.CreatedAt,
+		UpdatedAt:      social.UpdatedAt,
+		User:           userData,
+	}
 }
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorController struct {
+	twoFactorService *services.TwoFactorService
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" valid:"required~code is required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" valid:"required~password is required"`
+	Code     string `json:"code" valid:"required~code is required"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" valid:"required~challenge_token is required"`
+	Code           string `json:"code" valid:"required~code is required"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled           bool  `json:"enabled"`
+	Pending           bool  `json:"pending"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri"`
+}
+
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func NewTwoFactorController(twoFactorService *services.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{
+		twoFactorService: twoFactorService,
+	}
+}
+
+func (t *TwoFactorController) Status(ctx *gin.Context) {
+	status, err := t.twoFactorService.Status(helpers.GetUserId(ctx))
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, TwoFactorStatusResponse{
+		Enabled:           status.Enabled,
+		Pending:           status.Pending,
+		RecoveryCodesLeft: status.RecoveryCodesLeft,
+	})
+}
+
+func (t *TwoFactorController) Enroll(ctx *gin.Context) {
+	enrollment, err := t.twoFactorService.Enroll(helpers.GetUserId(ctx))
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, TwoFactorEnrollResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningUri: enrollment.URI,
+	})
+}
+
+func (t *TwoFactorController) Confirm(ctx *gin.Context) {
+	var twoFactorReq TwoFactorCodeRequest
+
+	err := helpers.BindJSON(ctx, &twoFactorReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	codes, err := t.twoFactorService.Confirm(helpers.GetUserId(ctx), twoFactorReq.Code)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, TwoFactorRecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (t *TwoFactorController) RecoveryCodes(ctx *gin.Context) {
+	var twoFactorReq TwoFactorCodeRequest
+
+	err := helpers.BindJSON(ctx, &twoFactorReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	codes, err := t.twoFactorService.RegenerateRecoveryCodes(helpers.GetUserId(ctx), twoFactorReq.Code)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, TwoFactorRecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (t *TwoFactorController) Disable(ctx *gin.Context) {
+	var twoFactorReq TwoFactorDisableRequest
+
+	err := helpers.BindJSON(ctx, &twoFactorReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	err = t.twoFactorService.Disable(helpers.GetUserId(ctx), twoFactorReq.Password, twoFactorReq.Code)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": "Two factor authentication has been turned off",
+	})
+}
+
+// Login finishes a login that UserController.Login answered with a
+// challenge.
+func (t *TwoFactorController) Login(ctx *gin.Context) {
+	var twoFactorReq TwoFactorLoginRequest
+
+	err := helpers.BindJSON(ctx, &twoFactorReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	tokens, err := t.twoFactorService.CompleteLogin(twoFactorReq.ChallengeToken, twoFactorReq.Code)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, newUserTokenResponse(tokens))
+}
@@ -3,32 +3,58 @@ package controllers
 import (
 	"final-project-golang/helpers"
 	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type UserController struct {
-	db *gorm.DB
+	userService         *services.UserService
+	verificationService *services.VerificationService
+	auditService        *services.AuditService
 }
 
 type UserRegisterRequest struct {
-	Age      uint   `json:"age"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Username string `json:"username"`
+	Age      uint   `json:"age" valid:"required~age is required, minage~age must be at least 9"`
+	Email    string `json:"email" valid:"required~email is required, email~Invalid format email"`
+	Password string `json:"password" valid:"required~password is required, password~password must be at least 8 characters and contain a letter and a digit"`
+	Username string `json:"username" valid:"required~username is required, maxstringlength(50)~username must be at most 50 characters"`
 }
 
 type UserLoginRequest struct {
 	Email    string `json:"email" valid:"required~email is required, email~Invalid format email"`
-	Password string `json:"password" valid:"required~password is required, minstringlength(6)~password has to have minimum length of 6 characters"`
+	Password string `json:"password" valid:"required~password is required"`
+}
+
+type UserRefreshRequest struct {
+	RefreshToken string `json:"refresh_token" valid:"required~refresh_token is required"`
+}
+
+type UserLogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type UserTokenResponse struct {
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+// UserChallengeResponse is returned by login instead of tokens when the user
+// has to enter a two factor code.
+type UserChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
 }
 
 type UserUpdateRequest struct {
 	Email    string `json:"email" valid:"email~Invalid format email"`
-	Username string `json:"username"`
+	Username string `json:"username" valid:"maxstringlength(50)~username must be at most 50 characters"`
+	Private  *bool  `json:"private"`
 }
 
 type UserRegisterResponse struct {
@@ -43,21 +69,43 @@ type UserUpdateResponse struct {
 	Username  string     `json:"username"`
 	Email     string     `json:"email"`
 	Age       int        `json:"age"`
+	Private   bool       `json:"private"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+type UserGetResponse struct {
+	Id        uint       `json:"id"`
+	Username  string     `json:"username"`
+	Age       int        `json:"age"`
+	Private   bool       `json:"private"`
+	CreatedAt *time.Time `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
 }
 
-func NewUserController(db *gorm.DB) *UserController {
+type UserProfileResponse struct {
+	UserGetResponse
+	PhotoCount   int64 `json:"photo_count"`
+	CommentCount int64 `json:"comment_count"`
+	SocialCount  int64 `json:"social_media_count"`
+
+	FollowerCount  int64 `json:"follower_count"`
+	FollowingCount int64 `json:"following_count"`
+}
+
+func NewUserController(userService *services.UserService, verificationService *services.VerificationService, auditService *services.AuditService) *UserController {
 	return &UserController{
-		db: db,
+		userService:         userService,
+		verificationService: verificationService,
+		auditService:        auditService,
 	}
 }
 
 func (u *UserController) Register(ctx *gin.Context) {
 	var userReq UserRegisterRequest
 
-	err := ctx.ShouldBindJSON(&userReq)
+	err := helpers.BindJSON(ctx, &userReq)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
@@ -68,23 +116,13 @@ func (u *UserController) Register(ctx *gin.Context) {
 		Password: userReq.Password,
 	}
 
-	err = u.db.Create(&newUser).Error
+	err = u.userService.Register(&newUser)
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		if err.Error() == `ERROR: duplicate key value violates unique constraint "idx_users_username" (SQLSTATE 23505)` {
-			helpers.BadRequestResponse(ctx, "username is duplicated")
-			return
-		}
-		if err.Error() == `ERROR: duplicate key value violates unique constraint "idx_users_email" (SQLSTATE 23505)` {
-			helpers.BadRequestResponse(ctx, "email is duplicated")
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
+	recordAuditAs(ctx, u.auditService, newUser.Id, models.AuditCreate, "user", newUser.Id, nil, newUser)
+	sendVerification(u.verificationService, &newUser)
 
 	response := UserRegisterResponse{
 		Id:       newUser.Id,
@@ -99,53 +137,129 @@ func (u *UserController) Register(ctx *gin.Context) {
 func (u *UserController) Login(ctx *gin.Context) {
 	var userReq UserLoginRequest
 
-	err := ctx.ShouldBindJSON(&userReq)
+	err := helpers.BindJSON(ctx, &userReq)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	loginUser := models.User{
-		Email:    userReq.Email,
-		Password: userReq.Password,
+	result, err := u.userService.Login(userReq.Email, userReq.Password)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
 	}
 
-	err = u.db.First(&loginUser, "email=?", userReq.Email).Error
+	writeLoginResult(ctx, result)
+}
+
+func (u *UserController) Refresh(ctx *gin.Context) {
+	var userReq UserRefreshRequest
+
+	err := helpers.BindJSON(ctx, &userReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	tokens, err := u.userService.Refresh(userReq.RefreshToken)
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, "username / password is not match")
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, newUserTokenResponse(tokens))
+}
+
+func (u *UserController) Logout(ctx *gin.Context) {
+	jti, _ := ctx.Get("jti")
+	exp, _ := ctx.Get("exp")
+	var userReq UserLogoutRequest
+
+	if ctx.Request.ContentLength > 0 {
+		err := helpers.BindJSON(ctx, &userReq)
+		if err != nil {
+			helpers.ErrorResponse(ctx, err)
 			return
 		}
-		helpers.InternalServerJsonResponse(ctx, err)
+	}
+
+	tokenId, _ := jti.(string)
+	expiry, _ := exp.(float64)
+
+	err := u.userService.Logout(helpers.GetUserId(ctx), tokenId, time.Unix(int64(expiry), 0), userReq.RefreshToken)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	isValid := helpers.ComparePassword(loginUser.Password, userReq.Password)
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": "You have been successfully logged out",
+	})
+}
 
-	if !isValid {
-		helpers.UnauthorizeJsonResponse(ctx, "username / password is not match")
+func (u *UserController) Get(ctx *gin.Context) {
+	pagination, err := helpers.ParsePagination(ctx, "updated_at", "username")
+	if err != nil {
+		helpers.BadRequestResponse(ctx, err.Error())
 		return
 	}
 
-	token, err := helpers.GenerateToken(loginUser.Id, loginUser.Email)
+	filter, err := helpers.ParseFilters(ctx)
 	if err != nil {
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.BadRequestResponse(ctx, err.Error())
 		return
 	}
 
-	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
-		"token": token,
-	})
+	users, total, err := u.userService.List(repositories.ListQuery{Pagination: pagination, Filter: filter})
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	response := make([]UserGetResponse, 0, len(users))
+	for _, user := range users {
+		response = append(response, newUserGetResponse(user))
+	}
+
+	var lastId uint
+	if len(users) > 0 {
+		lastId = users[len(users)-1].Id
+	}
+
+	helpers.WritePaginatedResponse(ctx, response, len(users), total, lastId, pagination)
+}
+
+func (u *UserController) GetById(ctx *gin.Context) {
+	userId, ok := helpers.GetParamId(ctx, "userId")
+	if !ok {
+		helpers.NotFoundResponse(ctx, "user not found")
+		return
+	}
+
+	profile, err := u.userService.GetProfile(userId)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	response := UserProfileResponse{
+		UserGetResponse: newUserGetResponse(profile.User),
+		PhotoCount:      profile.PhotoCount,
+		CommentCount:    profile.CommentCount,
+		SocialCount:     profile.SocialCount,
+		FollowerCount:   profile.FollowerCount,
+		FollowingCount:  profile.FollowingCount,
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, response)
 }
 
 func (u *UserController) Update(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
 	var userReq UserUpdateRequest
-	var user models.User
 
-	err := ctx.ShouldBindJSON(&userReq)
+	err := helpers.BindJSON(ctx, &userReq)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
@@ -154,29 +268,37 @@ func (u *UserController) Update(ctx *gin.Context) {
 		Username: userReq.Username,
 	}
 
-	// Ga perlu awal
-	err = u.db.First(&user, userId).Error
+	before, err := u.userService.Get(helpers.GetUserId(ctx))
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.NotFoundResponse(ctx, "User data not found")
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
-	// Ga perlu akhir
 
-	err = u.db.Model(&user).Updates(updateUser).Error
+	user, err := u.userService.Update(helpers.GetUserId(ctx), updateUser)
 	if err != nil {
-		helpers.BadRequestResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
+	if userReq.Private != nil {
+		err = u.userService.SetPrivate(user.Id, *userReq.Private)
+		if err != nil {
+			helpers.ErrorResponse(ctx, err)
+			return
+		}
+		user.Private = *userReq.Private
+	}
+	recordAudit(ctx, u.auditService, models.AuditUpdate, "user", user.Id, before, user)
+	if user.Email != before.Email {
+		sendVerification(u.verificationService, user)
+	}
+
 	response := UserUpdateResponse{
 		Id:        user.Id,
 		Username:  user.Username,
 		Email:     user.Email,
 		Age:       user.Age,
+		Private:   user.Private,
 		UpdatedAt: user.UpdatedAt,
 	}
 
@@ -184,30 +306,54 @@ func (u *UserController) Update(ctx *gin.Context) {
 }
 
 func (u *UserController) Delete(ctx *gin.Context) {
-	userId, _ := ctx.Get("id")
-	var user models.User
-
-	err := u.db.First(&user, userId).Error
+	before, err := u.userService.Get(helpers.GetUserId(ctx))
 	if err != nil {
-		if err.Error() == gorm.ErrRecordNotFound.Error() {
-			helpers.BadRequestResponse(ctx, "User not found")
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
 
-	err = u.db.Delete(&user).Error
+	err = u.userService.Delete(before.Id)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			helpers.NotFoundResponse(ctx, err)
-			return
-		}
-		helpers.InternalServerJsonResponse(ctx, err)
+		helpers.ErrorResponse(ctx, err)
 		return
 	}
+	recordAudit(ctx, u.auditService, models.AuditDelete, "user", before.Id, before, nil)
 
 	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
 		"message": "Your account has been successfully deleted",
 	})
 }
+
+func newUserGetResponse(user models.User) UserGetResponse {
+	return UserGetResponse{
+		Id:        user.Id,
+		Username:  user.Username,
+		Age:       user.Age,
+		Private:   user.Private,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// writeLoginResult answers a sign-in with tokens, or with the challenge a
+// user with two factor authentication has to answer first.
+func writeLoginResult(ctx *gin.Context, result services.LoginResult) {
+	if result.Challenge != nil {
+		helpers.WriteJsonResponse(ctx, http.StatusOK, UserChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    result.Challenge.Token,
+			ExpiresAt:         result.Challenge.ExpiresAt,
+		})
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, newUserTokenResponse(result.Tokens))
+}
+
+func newUserTokenResponse(tokens services.TokenPair) UserTokenResponse {
+	return UserTokenResponse{
+		Token:        tokens.Token,
+		ExpiresAt:    tokens.ExpiresAt,
+		RefreshToken: tokens.RefreshToken,
+	}
+}
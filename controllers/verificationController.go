@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerificationController struct {
+	verificationService *services.VerificationService
+	auditService        *services.AuditService
+}
+
+type VerificationRequest struct {
+	Token string `json:"token" valid:"required~token is required"`
+}
+
+func NewVerificationController(verificationService *services.VerificationService, auditService *services.AuditService) *VerificationController {
+	return &VerificationController{
+		verificationService: verificationService,
+		auditService:        auditService,
+	}
+}
+
+func (v *VerificationController) Verify(ctx *gin.Context) {
+	var verificationReq VerificationRequest
+
+	err := helpers.BindJSON(ctx, &verificationReq)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	before, user, err := v.verificationService.Verify(verificationReq.Token)
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+	if !before.Verified() {
+		recordAuditAs(ctx, v.auditService, user.Id, models.AuditUpdate, "user", user.Id, before, user)
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusOK, gin.H{
+		"message": "Your email has been verified",
+	})
+}
+
+func (v *VerificationController) Resend(ctx *gin.Context) {
+	err := v.verificationService.Resend(helpers.GetUserId(ctx))
+	if err != nil {
+		helpers.ErrorResponse(ctx, err)
+		return
+	}
+
+	helpers.WriteJsonResponse(ctx, http.StatusAccepted, gin.H{
+		"message": "A new verification link has been sent to your email",
+	})
+}
+
+// sendVerification mails a verification link without failing the request,
+// since the user can ask for another one.
+func sendVerification(verificationService *services.VerificationService, user *models.User) {
+	if err := verificationService.Send(user); err != nil {
+		log.Printf("verification: could not send to user %d: %v", user.Id, err)
+	}
+}
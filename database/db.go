@@ -1,37 +1,37 @@
 package database
 
 import (
-	"final-project-golang/models"
+	"final-project-golang/config"
 	"fmt"
+	"log"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-const (
-	HOST_POSTGRES = "localhost"
-	PORT_POSTGRES = 5432
-	DB_POSTGRES   = "final_project_go"
-	USER_POSTGRES = "postgres"
-	PASS_POSTGRES = "Noerhick_02"
-)
-
-var (
-	db  *gorm.DB
-	err error
-)
-
-func ConnectDB() *gorm.DB {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		HOST_POSTGRES, PORT_POSTGRES, USER_POSTGRES, PASS_POSTGRES, DB_POSTGRES,
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		panic(err)
+const maxConnectBackoff = 30 * time.Second
+
+// Connect opens the database, retrying with exponential backoff so the
+// application can start before Postgres is ready.
+func Connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	backoff := time.Duration(cfg.ConnectBackoff)
+
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= cfg.ConnectAttempts {
+			return nil, fmt.Errorf("database: giving up after %d attempts: %w", attempt, err)
+		}
+
+		log.Printf("database: attempt %d/%d failed, retrying in %s: %v", attempt, cfg.ConnectAttempts, backoff, err)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
 	}
-
-	db.Debug().AutoMigrate(models.User{}, models.Social{}, models.Photo{}, models.Comment{})
-
-	return db
 }
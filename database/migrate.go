@@ -0,0 +1,338 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var ErrSchemaBehind = errors.New("database schema is behind, run `migrate up` first")
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+type SchemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// LoadMigrations reads the embedded <version>_<name>.<up|down>.sql files
+// ordered by version. Every version needs both directions.
+func LoadMigrations() ([]Migration, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return loadMigrations(files)
+}
+
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		base := strings.TrimSuffix(name, ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("migration %s: file name must look like 0001_name.up.sql", name)
+		}
+
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: parts[1]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != parts[1] {
+			return nil, fmt.Errorf("migration %s: version %04d is also used by %s", name, version, migration.Name)
+		}
+
+		switch direction {
+		case ".up":
+			migration.Up = string(content)
+		case ".down":
+			migration.Down = string(content)
+		default:
+			return nil, fmt.Errorf("migration %s: unknown direction %q", name, direction)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s: both up and down files are required", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// applied creates schema_migrations if needed and reads it.
+func (m *Migrator) applied() (map[uint]SchemaMigration, error) {
+	err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return m.readApplied()
+}
+
+func (m *Migrator) readApplied() (map[uint]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uint]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+// Current returns the highest applied version, or 0 on an empty database.
+func (m *Migrator) Current() (uint, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	var current uint
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+
+	return current, nil
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down() error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+
+	return m.To(m.previous(current))
+}
+
+// previous returns the version before current, 0 when it is the first.
+func (m *Migrator) previous(current uint) uint {
+	var target uint
+	for _, migration := range m.migrations {
+		if migration.Version < current {
+			target = migration.Version
+		}
+	}
+
+	return target
+}
+
+type migrationStep struct {
+	migration Migration
+	up        bool
+}
+
+// To migrates up or down until version is the latest applied migration.
+// Version 0 rolls back everything.
+func (m *Migrator) To(version uint) error {
+	if version != 0 && !m.exists(version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for _, step := range m.plan(applied, version) {
+		if err := m.run(step.migration, step.up); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// plan lists what To runs: the missing migrations up to version, oldest
+// first, then the applied ones above it, newest first.
+func (m *Migrator) plan(applied map[uint]SchemaMigration, version uint) []migrationStep {
+	var steps []migrationStep
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+		steps = append(steps, migrationStep{migration: migration, up: true})
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+		steps = append(steps, migrationStep{migration: migration, up: false})
+	}
+
+	return steps
+}
+
+// Check reports ErrSchemaBehind when embedded migrations have not been
+// applied yet. It only reads, so readiness probes can call it.
+func (m *Migrator) Check() error {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return fmt.Errorf("%w: the database is not migrated", ErrSchemaBehind)
+	}
+
+	applied, err := m.readApplied()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			return fmt.Errorf("%w: migration %04d_%s is pending", ErrSchemaBehind, migration.Version, migration.Name)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) exists(version uint) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Migrator) run(migration Migration, up bool) error {
+	sql := migration.Down
+	if up {
+		sql = migration.Up
+	}
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+
+		if !up {
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		}
+
+		return tx.Create(&SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		direction := "down"
+		if up {
+			direction = "up"
+		}
+		return fmt.Errorf("migration %04d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func migrationFS(names ...string) fstest.MapFS {
+	files := fstest.MapFS{}
+	for _, name := range names {
+		files[name] = &fstest.MapFile{Data: []byte("-- " + name)}
+	}
+
+	return files
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFS(
+		"0010_add_likes.down.sql",
+		"0002_create_photos.up.sql",
+		"0010_add_likes.up.sql",
+		"0001_create_users.up.sql",
+		"0002_create_photos.down.sql",
+		"0001_create_users.down.sql",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, migration := range migrations {
+		got = append(got, fmt.Sprintf("%d %s %q %q", migration.Version, migration.Name, migration.Up, migration.Down))
+	}
+	want := []string{
+		`1 create_users "-- 0001_create_users.up.sql" "-- 0001_create_users.down.sql"`,
+		`2 create_photos "-- 0002_create_photos.up.sql" "-- 0002_create_photos.down.sql"`,
+		`10 add_likes "-- 0010_add_likes.up.sql" "-- 0010_add_likes.down.sql"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("migrations =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		err   string
+	}{
+		{
+			name:  "no version",
+			files: []string{"create_users.up.sql", "create_users.down.sql"},
+			err:   "file name must look like",
+		},
+		{
+			name:  "no name",
+			files: []string{"0001.up.sql", "0001.down.sql"},
+			err:   "file name must look like",
+		},
+		{
+			name:  "unknown direction",
+			files: []string{"0001_create_users.up.sql", "0001_create_users.sideways.sql"},
+			err:   `unknown direction ".sideways"`,
+		},
+		{
+			name:  "no direction",
+			files: []string{"0001_create_users.sql"},
+			err:   `unknown direction ""`,
+		},
+		{
+			name:  "missing down",
+			files: []string{"0001_create_users.up.sql"},
+			err:   "0001_create_users: both up and down files are required",
+		},
+		{
+			name:  "missing up",
+			files: []string{"0001_create_users.up.sql", "0001_create_users.down.sql", "0002_create_photos.down.sql"},
+			err:   "0002_create_photos: both up and down files are required",
+		},
+		{
+			name: "duplicate version",
+			files: []string{
+				"0001_create_users.up.sql", "0001_create_users.down.sql",
+				"0001_create_photos.up.sql", "0001_create_photos.down.sql",
+			},
+			err: "version 0001 is also used by",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(migrationFS(tt.files...))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != uint(i+1) {
+			t.Fatalf("migration %d has version %d, want no gaps", i, migration.Version)
+		}
+	}
+}
+
+func testMigrator() *Migrator {
+	var migrations []Migration
+	for _, version := range []uint{1, 2, 3, 5} {
+		migrations = append(migrations, Migration{Version: version, Name: fmt.Sprintf("m%d", version)})
+	}
+
+	return &Migrator{migrations: migrations}
+}
+
+func TestMigratorPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		applied []uint
+		to      uint
+		want    string
+	}{
+		{name: "up from empty", to: 5, want: "up 1, up 2, up 3, up 5"},
+		{name: "up part way", to: 2, want: "up 1, up 2"},
+		{name: "up from the middle", applied: []uint{1, 2}, to: 5, want: "up 3, up 5"},
+		{name: "fills a gap", applied: []uint{1, 3}, to: 3, want: "up 2"},
+		{name: "nothing to do", applied: []uint{1, 2, 3, 5}, to: 5, want: ""},
+		{name: "down newest first", applied: []uint{1, 2, 3, 5}, to: 1, want: "down 5, down 3, down 2"},
+		{name: "down to nothing", applied: []uint{1, 2}, to: 0, want: "down 2, down 1"},
+		{name: "up before down", applied: []uint{1, 3, 5}, to: 3, want: "up 2, down 5"},
+	}
+
+	m := testMigrator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied := make(map[uint]SchemaMigration)
+			for _, version := range tt.applied {
+				applied[version] = SchemaMigration{Version: version}
+			}
+
+			var got []string
+			for _, step := range m.plan(applied, tt.to) {
+				direction := "down"
+				if step.up {
+					direction = "up"
+				}
+				got = append(got, fmt.Sprintf("%s %d", direction, step.migration.Version))
+			}
+
+			if strings.Join(got, ", ") != tt.want {
+				t.Fatalf("plan = %q, want %q", strings.Join(got, ", "), tt.want)
+			}
+		})
+	}
+}
+
+func TestMigratorPrevious(t *testing.T) {
+	m := testMigrator()
+
+	for current, want := range map[uint]uint{5: 3, 3: 2, 2: 1, 1: 0} {
+		if got := m.previous(current); got != want {
+			t.Errorf("previous(%d) = %d, want %d", current, got, want)
+		}
+	}
+	if m.Latest() != 5 {
+		t.Errorf("Latest() = %d, want 5", m.Latest())
+	}
+}
+
+func TestMigratorToUnknownVersion(t *testing.T) {
+	if err := testMigrator().To(4); err == nil || !strings.Contains(err.Error(), "unknown migration version 4") {
+		t.Fatalf("To(4): got %v", err)
+	}
+}
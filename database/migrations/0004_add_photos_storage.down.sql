@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_photos_storage_key;
+
+ALTER TABLE photos DROP COLUMN size;
+ALTER TABLE photos DROP COLUMN content_type;
+ALTER TABLE photos DROP COLUMN storage_key;
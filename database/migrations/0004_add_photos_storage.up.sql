@@ -0,0 +1,5 @@
+ALTER TABLE photos ADD COLUMN storage_key text;
+ALTER TABLE photos ADD COLUMN content_type text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN size bigint NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX idx_photos_storage_key ON photos (storage_key);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_photos_processing_pending;
+
+ALTER TABLE photos DROP COLUMN thumbnail_url;
+ALTER TABLE photos DROP COLUMN medium_url;
+ALTER TABLE photos DROP COLUMN blurhash;
+ALTER TABLE photos DROP COLUMN format;
+ALTER TABLE photos DROP COLUMN height;
+ALTER TABLE photos DROP COLUMN width;
+ALTER TABLE photos DROP COLUMN processing_status;
+ALTER TABLE photos DROP COLUMN upload_key;
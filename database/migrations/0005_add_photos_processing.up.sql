@@ -0,0 +1,10 @@
+ALTER TABLE photos ADD COLUMN upload_key text;
+ALTER TABLE photos ADD COLUMN processing_status text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN width bigint NOT NULL DEFAULT 0;
+ALTER TABLE photos ADD COLUMN height bigint NOT NULL DEFAULT 0;
+ALTER TABLE photos ADD COLUMN format text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN blurhash text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN medium_url text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN thumbnail_url text NOT NULL DEFAULT '';
+
+CREATE INDEX idx_photos_processing_pending ON photos (id) WHERE processing_status = 'pending';
@@ -0,0 +1,3 @@
+DROP TABLE follows;
+
+ALTER TABLE users DROP COLUMN private;
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN private boolean NOT NULL DEFAULT false;
+
+CREATE TABLE follows (
+    id bigserial PRIMARY KEY,
+    follower_id bigint NOT NULL,
+    following_id bigint NOT NULL,
+    accepted_at timestamptz,
+    created_at timestamptz,
+    CONSTRAINT fk_follows_follower FOREIGN KEY (follower_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_follows_following FOREIGN KEY (following_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT chk_follows_self CHECK (follower_id <> following_id)
+);
+
+CREATE UNIQUE INDEX idx_follows_pair ON follows (follower_id, following_id);
+CREATE INDEX idx_follows_following_id ON follows (following_id);
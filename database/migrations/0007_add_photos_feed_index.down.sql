@@ -0,0 +1 @@
+DROP INDEX idx_photos_user_id_created_at;
@@ -0,0 +1 @@
+CREATE INDEX idx_photos_user_id_created_at ON photos (user_id, created_at DESC, id DESC);
@@ -0,0 +1,14 @@
+CREATE TABLE likes (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    photo_id bigint,
+    comment_id bigint,
+    created_at timestamptz,
+    CONSTRAINT fk_likes_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_likes_photo FOREIGN KEY (photo_id) REFERENCES photos (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_likes_comment FOREIGN KEY (comment_id) REFERENCES comments (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT chk_likes_target CHECK ((photo_id IS NULL) <> (comment_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_likes_user_photo ON likes (photo_id, user_id) WHERE photo_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_likes_user_comment ON likes (comment_id, user_id) WHERE comment_id IS NOT NULL;
@@ -0,0 +1,6 @@
+DROP INDEX idx_comments_photo_roots;
+DROP INDEX idx_comments_parent_id;
+
+ALTER TABLE comments DROP CONSTRAINT fk_comments_replies;
+ALTER TABLE comments DROP COLUMN removed_at;
+ALTER TABLE comments DROP COLUMN parent_id;
@@ -0,0 +1,6 @@
+ALTER TABLE comments ADD COLUMN parent_id bigint;
+ALTER TABLE comments ADD COLUMN removed_at timestamptz;
+ALTER TABLE comments ADD CONSTRAINT fk_comments_replies FOREIGN KEY (parent_id) REFERENCES comments (id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+CREATE INDEX idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX idx_comments_photo_roots ON comments (photo_id, id) WHERE parent_id IS NULL;
@@ -0,0 +1,4 @@
+DROP TABLE moderation_actions;
+
+ALTER TABLE users DROP CONSTRAINT chk_users_role;
+ALTER TABLE users DROP COLUMN role;
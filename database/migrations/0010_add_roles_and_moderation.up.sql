@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN role text NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD CONSTRAINT chk_users_role CHECK (role IN ('user', 'moderator', 'admin'));
+
+CREATE TABLE moderation_actions (
+    id bigserial PRIMARY KEY,
+    actor_id bigint,
+    action text NOT NULL,
+    target_type text NOT NULL,
+    target_id bigint NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    detail text NOT NULL DEFAULT '',
+    created_at timestamptz,
+    CONSTRAINT fk_moderation_actions_actor FOREIGN KEY (actor_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+
+CREATE INDEX idx_moderation_actions_actor_id ON moderation_actions (actor_id);
+CREATE INDEX idx_moderation_actions_target ON moderation_actions (target_type, target_id);
@@ -0,0 +1,18 @@
+ALTER TABLE comments DROP CONSTRAINT fk_photos_comment;
+ALTER TABLE comments ADD CONSTRAINT fk_photos_comment FOREIGN KEY (photo_id) REFERENCES photos (id) ON UPDATE CASCADE ON DELETE SET NULL;
+ALTER TABLE comments DROP CONSTRAINT fk_users_comment;
+ALTER TABLE comments ADD CONSTRAINT fk_users_comment FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL;
+ALTER TABLE photos DROP CONSTRAINT fk_users_photo;
+ALTER TABLE photos ADD CONSTRAINT fk_users_photo FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL;
+ALTER TABLE socials DROP CONSTRAINT fk_users_sosial;
+ALTER TABLE socials ADD CONSTRAINT fk_users_sosial FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+DROP INDEX idx_socials_deleted_at;
+DROP INDEX idx_comments_deleted_at;
+DROP INDEX idx_photos_deleted_at;
+DROP INDEX idx_users_deleted_at;
+
+ALTER TABLE socials DROP COLUMN deleted_at;
+ALTER TABLE comments DROP COLUMN deleted_at;
+ALTER TABLE photos DROP COLUMN deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
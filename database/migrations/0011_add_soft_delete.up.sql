@@ -0,0 +1,20 @@
+ALTER TABLE users ADD COLUMN deleted_at timestamptz;
+ALTER TABLE photos ADD COLUMN deleted_at timestamptz;
+ALTER TABLE comments ADD COLUMN deleted_at timestamptz;
+ALTER TABLE socials ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX idx_photos_deleted_at ON photos (deleted_at);
+CREATE INDEX idx_comments_deleted_at ON comments (deleted_at);
+CREATE INDEX idx_socials_deleted_at ON socials (deleted_at);
+
+-- Rows are only hard deleted by the purge job now, and their content goes
+-- with them instead of being left without an owner.
+ALTER TABLE socials DROP CONSTRAINT fk_users_sosial;
+ALTER TABLE socials ADD CONSTRAINT fk_users_sosial FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE photos DROP CONSTRAINT fk_users_photo;
+ALTER TABLE photos ADD CONSTRAINT fk_users_photo FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE comments DROP CONSTRAINT fk_users_comment;
+ALTER TABLE comments ADD CONSTRAINT fk_users_comment FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE comments DROP CONSTRAINT fk_photos_comment;
+ALTER TABLE comments ADD CONSTRAINT fk_photos_comment FOREIGN KEY (photo_id) REFERENCES photos (id) ON UPDATE CASCADE ON DELETE CASCADE;
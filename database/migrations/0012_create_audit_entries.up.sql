@@ -0,0 +1,15 @@
+CREATE TABLE audit_entries (
+    id bigserial PRIMARY KEY,
+    actor_id bigint,
+    action text NOT NULL,
+    resource_type text NOT NULL,
+    resource_id bigint NOT NULL,
+    changes jsonb NOT NULL DEFAULT '{}',
+    ip text NOT NULL DEFAULT '',
+    user_agent text NOT NULL DEFAULT '',
+    created_at timestamptz,
+    CONSTRAINT fk_audit_entries_actor FOREIGN KEY (actor_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+
+CREATE INDEX idx_audit_entries_actor_id ON audit_entries (actor_id);
+CREATE INDEX idx_audit_entries_resource ON audit_entries (resource_type, resource_id);
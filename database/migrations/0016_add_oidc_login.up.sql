@@ -0,0 +1,24 @@
+CREATE TABLE identities (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    provider text NOT NULL,
+    subject text NOT NULL,
+    email text,
+    created_at timestamptz,
+    CONSTRAINT fk_identities_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_identities_provider_subject ON identities (provider, subject);
+CREATE INDEX idx_identities_user_id ON identities (user_id);
+
+CREATE TABLE login_states (
+    id bigserial PRIMARY KEY,
+    state_hash text NOT NULL,
+    provider text NOT NULL,
+    nonce text NOT NULL,
+    code_verifier text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+
+CREATE UNIQUE INDEX idx_login_states_state_hash ON login_states (state_hash);
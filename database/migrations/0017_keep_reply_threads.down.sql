@@ -0,0 +1,13 @@
+-- The old constraint needs an author on every comment. Deleting the
+-- tombstones of purged accounts would take other users' replies with them,
+-- so refuse to roll back until they have been dealt with by hand.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM comments WHERE user_id IS NULL) THEN
+        RAISE EXCEPTION 'comments of purged accounts remain; delete them and their replies with DELETE FROM comments WHERE user_id IS NULL before rolling back';
+    END IF;
+END
+$$;
+
+ALTER TABLE comments DROP CONSTRAINT fk_users_comment;
+ALTER TABLE comments ADD CONSTRAINT fk_users_comment FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE;
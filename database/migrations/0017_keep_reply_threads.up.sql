@@ -0,0 +1,4 @@
+-- Comments other users replied to outlive their author's account as
+-- tombstones, so purging the account leaves them without an author.
+ALTER TABLE comments DROP CONSTRAINT fk_users_comment;
+ALTER TABLE comments ADD CONSTRAINT fk_users_comment FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL;
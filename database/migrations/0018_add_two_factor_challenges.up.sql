@@ -0,0 +1,13 @@
+CREATE TABLE two_factor_challenges (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    token_hash text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    expires_at timestamptz NOT NULL,
+    used_at timestamptz,
+    created_at timestamptz,
+    CONSTRAINT fk_two_factor_challenges_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_two_factor_challenges_token_hash ON two_factor_challenges (token_hash);
+CREATE INDEX idx_two_factor_challenges_user_id ON two_factor_challenges (user_id);
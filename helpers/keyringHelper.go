@@ -0,0 +1,331 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"final-project-golang/config"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type SigningKey struct {
+	Id      string
+	Method  jwt.SigningMethod
+	Private interface{}
+	Public  interface{}
+}
+
+// Keyring signs tokens with its active key and verifies them with any key it
+// holds, looked up by the "kid" header.
+type Keyring struct {
+	mu     sync.RWMutex
+	active string
+	keys   map[string]SigningKey
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{
+		keys: make(map[string]SigningKey),
+	}
+}
+
+// NewHMACKeyring returns a keyring holding a single shared secret.
+func NewHMACKeyring(secret string) *Keyring {
+	keyring := NewKeyring()
+	keyring.Add(SigningKey{
+		Id:      "default",
+		Method:  jwt.SigningMethodHS256,
+		Private: []byte(secret),
+		Public:  []byte(secret),
+	}, true)
+
+	return keyring
+}
+
+// LoadKeyring builds a keyring from configuration, falling back to HS256
+// with the shared secret when no asymmetric keys are configured.
+func LoadKeyring(cfg config.JWTConfig) (*Keyring, error) {
+	if len(cfg.Keys) == 0 {
+		return NewHMACKeyring(cfg.Secret), nil
+	}
+
+	keyring := NewKeyring()
+	for _, keyCfg := range cfg.Keys {
+		key, err := loadSigningKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", keyCfg.Id, err)
+		}
+		keyring.Add(key, keyCfg.Id == cfg.ActiveKey)
+	}
+
+	return keyring, nil
+}
+
+func (k *Keyring) Add(key SigningKey, activate bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[key.Id] = key
+	if activate {
+		k.active = key.Id
+	}
+}
+
+func (k *Keyring) Remove(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if id == k.active {
+		return errors.New("cannot remove the active signing key")
+	}
+	delete(k.keys, id)
+
+	return nil
+}
+
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	key, ok := k.keys[k.active]
+	k.mu.RUnlock()
+	if !ok || key.Private == nil {
+		return "", errors.New("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Id
+
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc resolves the verification key for a parsed token and rejects tokens
+// whose algorithm does not match the key they claim to be signed with.
+func (k *Keyring) Keyfunc(t *jwt.Token) (interface{}, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = k.active
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key")
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	return key.Public, nil
+}
+
+// JWKS returns the public half of every asymmetric key. Shared secrets are
+// never published.
+func (k *Keyring) JWKS() JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwk := JWK{Kid: key.Id, Use: "sig", Alg: key.Method.Alg()}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = public.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+// PublicKey decodes the key published in j, the reverse of JWKS.
+func (j JWK) PublicKey() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() > math.MaxInt32 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !public.Curve.IsOnCurve(public.X, public.Y) {
+			return nil, errors.New("invalid EC key")
+		}
+		return public, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if j.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+}
+
+func loadSigningKey(cfg config.JWTKeyConfig) (SigningKey, error) {
+	key := SigningKey{Id: cfg.Id}
+
+	switch cfg.Algorithm {
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+	case "ES256":
+		key.Method = jwt.SigningMethodES256
+	case "EdDSA":
+		key.Method = SigningMethodEd25519
+	default:
+		return key, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	if cfg.PrivateKeyFile != "" {
+		block, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return key, err
+		}
+		private, err := parsePrivateKey(block)
+		if err != nil {
+			return key, err
+		}
+		key.Private = private
+		key.Public = private.(crypto.Signer).Public()
+	}
+
+	if cfg.PublicKeyFile != "" {
+		block, err := readPEM(cfg.PublicKeyFile)
+		if err != nil {
+			return key, err
+		}
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return key, err
+		}
+		key.Public = public
+	}
+
+	if err := checkKeyType(key); err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", path)
+	}
+
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (interface{}, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, errors.New("unsupported private key format")
+}
+
+func checkKeyType(key SigningKey) error {
+	var ok bool
+
+	switch key.Method.Alg() {
+	case "RS256":
+		_, ok = key.Public.(*rsa.PublicKey)
+	case "ES256":
+		var public *ecdsa.PublicKey
+		public, ok = key.Public.(*ecdsa.PublicKey)
+		ok = ok && public.Curve == elliptic.P256()
+	case "EdDSA":
+		_, ok = key.Public.(ed25519.PublicKey)
+	}
+
+	if !ok {
+		return fmt.Errorf("key does not match algorithm %s", key.Method.Alg())
+	}
+
+	return nil
+}
@@ -0,0 +1,263 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"final-project-golang/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func newTestKey(t *testing.T, id, alg string) SigningKey {
+	t.Helper()
+
+	switch alg {
+	case "RS256":
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return SigningKey{Id: id, Method: jwt.SigningMethodRS256, Private: private, Public: &private.PublicKey}
+	case "ES256":
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return SigningKey{Id: id, Method: jwt.SigningMethodES256, Private: private, Public: &private.PublicKey}
+	case "EdDSA":
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return SigningKey{Id: id, Method: SigningMethodEd25519, Private: private, Public: public}
+	}
+
+	t.Fatalf("unknown algorithm %s", alg)
+	return SigningKey{}
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"id": float64(7), "exp": float64(time.Now().Add(time.Hour).Unix())}
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	for _, alg := range []string{"HS256", "RS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			keyring := NewHMACKeyring("secret")
+			if alg != "HS256" {
+				keyring.Add(newTestKey(t, "key-"+alg, alg), true)
+			}
+
+			signed, err := keyring.Sign(testClaims())
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+
+			token, err := jwt.Parse(signed, keyring.Keyfunc)
+			if err != nil || !token.Valid {
+				t.Fatalf("Parse: %v", err)
+			}
+			if token.Method.Alg() != alg || token.Claims.(jwt.MapClaims)["id"] != float64(7) {
+				t.Fatalf("token = %+v", token)
+			}
+		})
+	}
+}
+
+func TestKeyringVerifiesRotatedKeys(t *testing.T) {
+	old := newTestKey(t, "2024-01", "ES256")
+	keyring := NewKeyring()
+	keyring.Add(old, true)
+	signedByOld, err := keyring.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotate: the new key signs, the old one is kept without its private half
+	// to verify tokens issued before.
+	keyring.Add(newTestKey(t, "2024-06", "EdDSA"), true)
+	keyring.Add(SigningKey{Id: old.Id, Method: old.Method, Public: old.Public}, false)
+
+	if _, err := jwt.Parse(signedByOld, keyring.Keyfunc); err != nil {
+		t.Fatalf("token of the rotated key: %v", err)
+	}
+
+	signed, err := keyring.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Parse(signed, keyring.Keyfunc)
+	if err != nil || token.Header["kid"] != "2024-06" || token.Method.Alg() != "EdDSA" {
+		t.Fatalf("token of the active key: %+v, %v", token, err)
+	}
+
+	if err := keyring.Remove("2024-06"); err == nil {
+		t.Fatal("removed the active key")
+	}
+	if err := keyring.Remove(old.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(signedByOld, keyring.Keyfunc); err == nil {
+		t.Fatal("token of a removed key was accepted")
+	}
+}
+
+func TestKeyringPublicOnlyKeyCantSign(t *testing.T) {
+	key := newTestKey(t, "verify-only", "RS256")
+	keyring := NewKeyring()
+	keyring.Add(SigningKey{Id: key.Id, Method: key.Method, Public: key.Public}, true)
+
+	if _, err := keyring.Sign(testClaims()); err == nil {
+		t.Fatal("signed without a private key")
+	}
+}
+
+func TestKeyringRejects(t *testing.T) {
+	rsaKey := newTestKey(t, "rsa", "RS256")
+	edKey := newTestKey(t, "ed", "EdDSA")
+	keyring := NewKeyring()
+	keyring.Add(rsaKey, true)
+	keyring.Add(SigningKey{Id: edKey.Id, Method: edKey.Method, Public: edKey.Public}, false)
+
+	rsaPublic, err := x509.MarshalPKIXPublicKey(rsaKey.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherEd := newTestKey(t, "ed", "EdDSA")
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, testClaims())
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		// Verifying an HMAC signature with the published RSA key would accept
+		// a token anyone can forge.
+		{name: "HS256 keyed with the RSA public key", token: sign(jwt.SigningMethodHS256, "rsa", rsaPublic)},
+		{name: "HS256 without a kid", token: sign(jwt.SigningMethodHS256, "", rsaPublic)},
+		{name: "EdDSA claiming the RSA key", token: sign(SigningMethodEd25519, "rsa", edKey.Private)},
+		{name: "unknown kid", token: sign(jwt.SigningMethodRS256, "unknown", rsaKey.Private)},
+		{name: "signed by another key with the same kid", token: sign(SigningMethodEd25519, "ed", otherEd.Private)},
+		{name: "alg none", token: sign(jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if token, err := jwt.Parse(tt.token, keyring.Keyfunc); err == nil {
+				t.Fatalf("accepted %+v", token)
+			}
+		})
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	writePEM := func(name, blockType string, der []byte) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	active := newTestKey(t, "new", "EdDSA")
+	privateDER, err := x509.MarshalPKCS8PrivateKey(active.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := newTestKey(t, "old", "RS256")
+	publicDER, err := x509.MarshalPKIXPublicKey(rotated.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := LoadKeyring(config.JWTConfig{
+		ActiveKey: "new",
+		Keys: []config.JWTKeyConfig{
+			{Id: "new", Algorithm: "EdDSA", PrivateKeyFile: writePEM("new.pem", "PRIVATE KEY", privateDER)},
+			{Id: "old", Algorithm: "RS256", PublicKeyFile: writePEM("old.pub.pem", "PUBLIC KEY", publicDER)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("LoadKeyring: %v", err)
+	}
+
+	signed, err := keyring.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(signed, keyring.Keyfunc); err != nil {
+		t.Fatalf("token of the active key: %v", err)
+	}
+
+	oldToken := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims())
+	oldToken.Header["kid"] = "old"
+	signedByOld, err := oldToken.SignedString(rotated.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(signedByOld, keyring.Keyfunc); err != nil {
+		t.Fatalf("token of the public-only key: %v", err)
+	}
+
+	// The algorithm has to match the key in the file.
+	_, err = LoadKeyring(config.JWTConfig{
+		ActiveKey: "wrong",
+		Keys: []config.JWTKeyConfig{
+			{Id: "wrong", Algorithm: "ES256", PrivateKeyFile: filepath.Join(dir, "new.pem")},
+		},
+	})
+	if err == nil {
+		t.Fatal("loaded an Ed25519 key as ES256")
+	}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	keyring := NewHMACKeyring("secret")
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		keyring.Add(newTestKey(t, alg, alg), false)
+	}
+
+	set := keyring.JWKS()
+	if len(set.Keys) != 3 {
+		t.Fatalf("JWKS = %+v, want the three asymmetric keys only", set)
+	}
+	for _, jwk := range set.Keys {
+		public, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("%s: %v", jwk.Kid, err)
+		}
+		got, err := x509.MarshalPKIXPublicKey(public)
+		if err != nil {
+			t.Fatalf("%s: %v", jwk.Kid, err)
+		}
+		want, err := x509.MarshalPKIXPublicKey(keyring.keys[jwk.Kid].Public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("%s: decoded a different key", jwk.Kid)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+type Pagination struct {
+	Page      int
+	Limit     int
+	After     uint
+	SortField string
+	SortDesc  bool
+}
+
+type PageMeta struct {
+	Total      int64  `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type PageLinks struct {
+	Self string  `json:"self"`
+	Next *string `json:"next"`
+	Prev *string `json:"prev"`
+}
+
+type PaginatedResponse struct {
+	Data  interface{} `json:"data"`
+	Meta  PageMeta    `json:"meta"`
+	Links PageLinks   `json:"links"`
+}
+
+type CursorMeta struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type CursorResponse struct {
+	Data  interface{} `json:"data"`
+	Meta  CursorMeta  `json:"meta"`
+	Links PageLinks   `json:"links"`
+}
+
+// ParsePagination reads ?page=, ?limit=, ?after= and ?sort=field:dir from the
+// request. sortable lists the columns a caller may sort by; "id" and
+// "created_at" are always allowed.
+func ParsePagination(ctx *gin.Context, sortable ...string) (Pagination, error) {
+	p := Pagination{
+		Page:      1,
+		Limit:     DefaultPageLimit,
+		SortField: "id",
+	}
+
+	if raw := ctx.Query("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			return p, fmt.Errorf("page must be a positive number")
+		}
+		p.Page = page
+	}
+
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return p, fmt.Errorf("limit must be a positive number")
+		}
+		if limit > MaxPageLimit {
+			limit = MaxPageLimit
+		}
+		p.Limit = limit
+	}
+
+	if raw := ctx.Query("after"); raw != "" {
+		after, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("after must be a valid cursor")
+		}
+		p.After = uint(after)
+	}
+
+	if raw := ctx.Query("sort"); raw != "" {
+		field, dir, _ := strings.Cut(raw, ":")
+		allowed := append([]string{"id", "created_at"}, sortable...)
+		if !containsString(allowed, field) {
+			return p, fmt.Errorf("sort field must be one of %s", strings.Join(allowed, ", "))
+		}
+		switch strings.ToLower(dir) {
+		case "", "asc":
+			p.SortDesc = false
+		case "desc":
+			p.SortDesc = true
+		default:
+			return p, fmt.Errorf("sort direction must be asc or desc")
+		}
+		p.SortField = field
+	}
+
+	return p, nil
+}
+
+type Filter struct {
+	Fields      map[string]uint
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// ParseFilters reads exact-match query parameters listed in fields plus the
+// created_from / created_to date range.
+func ParseFilters(ctx *gin.Context, fields ...string) (Filter, error) {
+	filter := Filter{Fields: make(map[string]uint)}
+
+	for _, field := range fields {
+		raw := ctx.Query(field)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be a number", field)
+		}
+		filter.Fields[field] = uint(value)
+	}
+
+	if raw := ctx.Query("created_from"); raw != "" {
+		from, err := parseDate(raw, false)
+		if err != nil {
+			return filter, fmt.Errorf("created_from must be a date (YYYY-MM-DD) or RFC3339 timestamp")
+		}
+		filter.CreatedFrom = &from
+	}
+
+	if raw := ctx.Query("created_to"); raw != "" {
+		to, err := parseDate(raw, true)
+		if err != nil {
+			return filter, fmt.Errorf("created_to must be a date (YYYY-MM-DD) or RFC3339 timestamp")
+		}
+		filter.CreatedTo = &to
+	}
+
+	return filter, nil
+}
+
+// WritePaginatedResponse writes data inside the list envelope. lastId is the
+// id of the last item in data and is used as the next cursor.
+func WritePaginatedResponse(ctx *gin.Context, data interface{}, count int, total int64, lastId uint, p Pagination) {
+	response := PaginatedResponse{
+		Data: data,
+		Meta: PageMeta{
+			Total: total,
+			Limit: p.Limit,
+		},
+		Links: PageLinks{
+			Self: ctx.Request.URL.RequestURI(),
+		},
+	}
+
+	if p.After > 0 {
+		if count == p.Limit && lastId > 0 {
+			response.Meta.NextCursor = strconv.FormatUint(uint64(lastId), 10)
+			next := pageLink(ctx, map[string]string{"after": response.Meta.NextCursor, "page": ""})
+			response.Links.Next = &next
+		}
+	} else {
+		response.Meta.Page = p.Page
+		if int64(p.Page*p.Limit) < total {
+			next := pageLink(ctx, map[string]string{"page": strconv.Itoa(p.Page + 1)})
+			response.Links.Next = &next
+			if lastId > 0 {
+				response.Meta.NextCursor = strconv.FormatUint(uint64(lastId), 10)
+			}
+		}
+		if p.Page > 1 {
+			prev := pageLink(ctx, map[string]string{"page": strconv.Itoa(p.Page - 1)})
+			response.Links.Prev = &prev
+		}
+	}
+
+	WriteJsonResponse(ctx, http.StatusOK, response)
+}
+
+// ParseCursor reads ?limit= and ?after= for lists that are only paged by
+// cursor and have a fixed order.
+func ParseCursor(ctx *gin.Context) (Pagination, error) {
+	if ctx.Query("page") != "" || ctx.Query("sort") != "" {
+		return Pagination{}, fmt.Errorf("only limit and after are supported")
+	}
+
+	return ParsePagination(ctx)
+}
+
+// WriteCursorResponse is WritePaginatedResponse without a total, for lists
+// too expensive to count.
+func WriteCursorResponse(ctx *gin.Context, data interface{}, count int, lastId uint, p Pagination) {
+	response := CursorResponse{
+		Data: data,
+		Meta: CursorMeta{
+			Limit: p.Limit,
+		},
+		Links: PageLinks{
+			Self: ctx.Request.URL.RequestURI(),
+		},
+	}
+
+	if count == p.Limit && lastId > 0 {
+		response.Meta.NextCursor = strconv.FormatUint(uint64(lastId), 10)
+		next := pageLink(ctx, map[string]string{"after": response.Meta.NextCursor})
+		response.Links.Next = &next
+	}
+
+	WriteJsonResponse(ctx, http.StatusOK, response)
+}
+
+func pageLink(ctx *gin.Context, params map[string]string) string {
+	query := ctx.Request.URL.Query()
+	for key, value := range params {
+		if value == "" {
+			query.Del(key)
+			continue
+		}
+		query.Set(key, value)
+	}
+
+	link := url.URL{Path: ctx.Request.URL.Path, RawQuery: query.Encode()}
+
+	return link.String()
+}
+
+func parseDate(raw string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return t, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,225 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestContext(target string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest("GET", target, nil)
+
+	return ctx, w
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Pagination
+		err   string
+	}{
+		{query: "", want: Pagination{Page: 1, Limit: DefaultPageLimit, SortField: "id"}},
+		{query: "page=3&limit=5", want: Pagination{Page: 3, Limit: 5, SortField: "id"}},
+		{query: "limit=1000", want: Pagination{Page: 1, Limit: MaxPageLimit, SortField: "id"}},
+		{query: "after=42", want: Pagination{Page: 1, Limit: DefaultPageLimit, After: 42, SortField: "id"}},
+		{query: "sort=username", want: Pagination{Page: 1, Limit: DefaultPageLimit, SortField: "username"}},
+		{query: "sort=created_at:DESC", want: Pagination{Page: 1, Limit: DefaultPageLimit, SortField: "created_at", SortDesc: true}},
+		{query: "sort=id:asc", want: Pagination{Page: 1, Limit: DefaultPageLimit, SortField: "id"}},
+		{query: "page=0", err: "page must be a positive number"},
+		{query: "page=two", err: "page must be a positive number"},
+		{query: "limit=-1", err: "limit must be a positive number"},
+		{query: "after=-1", err: "after must be a valid cursor"},
+		{query: "after=abc", err: "after must be a valid cursor"},
+		{query: "sort=password", err: "sort field must be one of id, created_at, username"},
+		{query: "sort=email:desc", err: "sort field must be one of id, created_at, username"},
+		{query: "sort=id:sideways", err: "sort direction must be asc or desc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ctx, _ := newTestContext("/users?" + tt.query)
+
+			got, err := ParsePagination(ctx, "username")
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	for _, query := range []string{"page=2", "sort=id:desc"} {
+		ctx, _ := newTestContext("/feed?" + query)
+		if _, err := ParseCursor(ctx); err == nil {
+			t.Errorf("%s: want an error", query)
+		}
+	}
+
+	ctx, _ := newTestContext("/feed?after=9&limit=2")
+	got, err := ParseCursor(ctx)
+	if err != nil || got.After != 9 || got.Limit != 2 {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	ctx, _ := newTestContext("/audit?actor_id=3&resource_id=&created_from=2024-10-01&created_to=2024-10-02&other=1")
+	filter, err := ParseFilters(ctx, "actor_id", "resource_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+	if !reflect.DeepEqual(filter.Fields, map[string]uint{"actor_id": 3}) ||
+		filter.CreatedFrom == nil || !filter.CreatedFrom.Equal(from) ||
+		filter.CreatedTo == nil || !filter.CreatedTo.Equal(to) {
+		t.Fatalf("filter = %+v", filter)
+	}
+
+	ctx, _ = newTestContext("/audit?created_from=2024-10-01T08:30:00%2B02:00")
+	filter, err = ParseFilters(ctx)
+	if err != nil || !filter.CreatedFrom.Equal(time.Date(2024, 10, 1, 6, 30, 0, 0, time.UTC)) {
+		t.Fatalf("RFC3339: %+v, %v", filter, err)
+	}
+
+	for query, want := range map[string]string{
+		"actor_id=me":             "actor_id must be a number",
+		"created_from=yesterday":  "created_from must be a date (YYYY-MM-DD) or RFC3339 timestamp",
+		"created_to=2024-13-01":   "created_to must be a date (YYYY-MM-DD) or RFC3339 timestamp",
+		"created_to=2024-10-01T1": "created_to must be a date (YYYY-MM-DD) or RFC3339 timestamp",
+	} {
+		ctx, _ := newTestContext("/audit?" + query)
+		if _, err := ParseFilters(ctx, "actor_id"); err == nil || err.Error() != want {
+			t.Errorf("%s: got %v, want %q", query, err, want)
+		}
+	}
+}
+
+func TestWritePaginatedResponse(t *testing.T) {
+	link := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		target string
+		count  int
+		total  int64
+		lastId uint
+		meta   PageMeta
+		links  PageLinks
+	}{
+		{
+			name:   "first page",
+			target: "/users?limit=2&sort=username",
+			count:  2, total: 5, lastId: 8,
+			meta:  PageMeta{Total: 5, Page: 1, Limit: 2, NextCursor: "8"},
+			links: PageLinks{Self: "/users?limit=2&sort=username", Next: link("/users?limit=2&page=2&sort=username")},
+		},
+		{
+			name:   "middle page",
+			target: "/users?limit=2&page=2",
+			count:  2, total: 5, lastId: 6,
+			meta:  PageMeta{Total: 5, Page: 2, Limit: 2, NextCursor: "6"},
+			links: PageLinks{Self: "/users?limit=2&page=2", Next: link("/users?limit=2&page=3"), Prev: link("/users?limit=2&page=1")},
+		},
+		{
+			name:   "last page",
+			target: "/users?limit=2&page=3",
+			count:  1, total: 5, lastId: 4,
+			meta:  PageMeta{Total: 5, Page: 3, Limit: 2},
+			links: PageLinks{Self: "/users?limit=2&page=3", Prev: link("/users?limit=2&page=2")},
+		},
+		{
+			name:   "empty",
+			target: "/users",
+			meta:   PageMeta{Page: 1, Limit: DefaultPageLimit},
+			links:  PageLinks{Self: "/users"},
+		},
+		{
+			name:   "cursor with more",
+			target: "/users?after=8&limit=2&page=4",
+			count:  2, total: 5, lastId: 6,
+			meta:  PageMeta{Total: 5, Limit: 2, NextCursor: "6"},
+			links: PageLinks{Self: "/users?after=8&limit=2&page=4", Next: link("/users?after=6&limit=2")},
+		},
+		{
+			name:   "cursor at the end",
+			target: "/users?after=6&limit=2",
+			count:  1, total: 5, lastId: 4,
+			meta:  PageMeta{Total: 5, Limit: 2},
+			links: PageLinks{Self: "/users?after=6&limit=2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, w := newTestContext(tt.target)
+			p, err := ParsePagination(ctx, "username")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			WritePaginatedResponse(ctx, []int{}, tt.count, tt.total, tt.lastId, p)
+
+			var got struct {
+				Meta  PageMeta  `json:"meta"`
+				Links PageLinks `json:"links"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Meta, tt.meta) {
+				t.Errorf("meta = %+v, want %+v", got.Meta, tt.meta)
+			}
+			if !reflect.DeepEqual(got.Links, tt.links) {
+				t.Errorf("links = %s, want %s", linksString(got.Links), linksString(tt.links))
+			}
+		})
+	}
+}
+
+func TestWriteCursorResponse(t *testing.T) {
+	ctx, w := newTestContext("/feed?limit=2")
+	p, err := ParseCursor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	WriteCursorResponse(ctx, []int{1, 2}, 2, 12, p)
+
+	var got CursorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Meta.NextCursor != "12" || got.Links.Next == nil || *got.Links.Next != "/feed?after=12&limit=2" || got.Links.Prev != nil {
+		t.Fatalf("meta %+v, links %s", got.Meta, linksString(got.Links))
+	}
+}
+
+func linksString(links PageLinks) string {
+	deref := func(s *string) string {
+		if s == nil {
+			return "<nil>"
+		}
+		return *s
+	}
+
+	return "self " + links.Self + ", next " + deref(links.Next) + ", prev " + deref(links.Prev)
+}
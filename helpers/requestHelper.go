@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetParamId(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func GetUserId(ctx *gin.Context) uint {
+	userId, _ := ctx.Get("id")
+	id, _ := userId.(float64)
+
+	return uint(id)
+}
+
+// GetRole returns the role Auth loaded for the current user.
+func GetRole(ctx *gin.Context) string {
+	role, _ := ctx.Get("role")
+	value, _ := role.(string)
+
+	return value
+}
+
+// IsEmailVerified reports whether Auth found the current user's email
+// verified.
+func IsEmailVerified(ctx *gin.Context) bool {
+	return ctx.GetBool("email_verified")
+}
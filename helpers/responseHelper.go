@@ -1,35 +1,128 @@
 package helpers
 
 import (
-	"net/http"
+	"encoding/json"
+	"errors"
+	"final-project-golang/apperrors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
+type ErrorResponseBody struct {
+	Error   string                 `json:"error"`
+	Code    apperrors.Code         `json:"code"`
+	Details []apperrors.FieldError `json:"details,omitempty"`
+}
+
 func WriteJsonResponse(ctx *gin.Context, status int, payload interface{}) {
 	ctx.JSON(status, payload)
 }
 
-func BadRequestResponse(ctx *gin.Context, payload interface{}) {
-	WriteJsonResponse(ctx, http.StatusBadRequest, gin.H{
-		"error": payload,
+// ErrorResponse writes err using the status that belongs to its code.
+// Anything that is not a typed error is reported as an internal error
+// without leaking its message.
+func ErrorResponse(ctx *gin.Context, err error) {
+	appErr := apperrors.As(err)
+	if appErr.Code == apperrors.CodeInternal {
+		log.Printf("%s %s: %v", ctx.Request.Method, ctx.Request.URL.Path, appErr.Err)
+	}
+	if appErr.RetryAfter > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(CeilSeconds(appErr.RetryAfter)))
+	}
+
+	WriteJsonResponse(ctx, apperrors.HTTPStatus(appErr.Code), ErrorResponseBody{
+		Error:   appErr.Message,
+		Code:    appErr.Code,
+		Details: appErr.Fields,
 	})
 }
 
+// CeilSeconds rounds d up to whole seconds, the unit of Retry-After and the
+// RateLimit headers.
+func CeilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+func AbortWithError(ctx *gin.Context, err error) {
+	ErrorResponse(ctx, err)
+	ctx.Abort()
+}
+
+func BadRequestResponse(ctx *gin.Context, payload interface{}) {
+	ErrorResponse(ctx, toError(apperrors.CodeBadRequest, payload))
+}
+
 func NotFoundResponse(ctx *gin.Context, payload interface{}) {
-	WriteJsonResponse(ctx, http.StatusNotFound, gin.H{
-		"error": payload,
-	})
+	ErrorResponse(ctx, toError(apperrors.CodeNotFound, payload))
 }
 
 func InternalServerJsonResponse(ctx *gin.Context, err interface{}) {
-	WriteJsonResponse(ctx, http.StatusInternalServerError, gin.H{
-		"error": err,
-	})
+	ErrorResponse(ctx, toError(apperrors.CodeInternal, err))
 }
 
 func UnauthorizeJsonResponse(ctx *gin.Context, err interface{}) {
-	WriteJsonResponse(ctx, http.StatusUnauthorized, gin.H{
-		"error": err,
-	})
+	ErrorResponse(ctx, toError(apperrors.CodeUnauthorized, err))
+}
+
+// toError turns the payload handed to the response helpers into a typed
+// error. Typed errors keep their own code; request binding errors become
+// validation errors.
+func toError(code apperrors.Code, payload interface{}) error {
+	switch value := payload.(type) {
+	case *apperrors.Error:
+		return value
+	case error:
+		var appErr *apperrors.Error
+		if errors.As(value, &appErr) {
+			return appErr
+		}
+		if bindErr := fromBinding(value); bindErr != nil {
+			return bindErr
+		}
+		if code == apperrors.CodeInternal {
+			return apperrors.Internal(value)
+		}
+		return apperrors.Wrap(value, code, value.Error())
+	case string:
+		if code == apperrors.CodeInternal {
+			return apperrors.Internal(errors.New(value))
+		}
+		return apperrors.New(code, value)
+	}
+
+	return apperrors.New(code, fmt.Sprint(payload))
+}
+
+func fromBinding(err error) *apperrors.Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]apperrors.FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, apperrors.FieldError{
+				Field:   fieldErr.Field(),
+				Rule:    fieldErr.Tag(),
+				Message: fmt.Sprintf("%s failed on the %s rule", fieldErr.Field(), fieldErr.Tag()),
+			})
+		}
+		return apperrors.Validation(fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		message := fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type)
+		return apperrors.Validation(apperrors.FieldError{Field: typeErr.Field, Rule: "type", Message: message})
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return apperrors.Wrap(err, apperrors.CodeBadRequest, "request body is not valid JSON")
+	}
+
+	return nil
 }
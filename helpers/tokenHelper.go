@@ -1,42 +1,169 @@
 package helpers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
-var secretKey = "secret"
+// Purposes mark tokens that only serve one step, such as verifying an email
+// address, so they can't be used as access tokens.
+const verifyEmailPurpose = "verify_email"
 
-func GenerateToken(id uint, email string) (string, error) {
+var (
+	keyring         = NewKeyring()
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+func InitToken(tokenKeyring *Keyring, accessTTL, refreshTTL time.Duration) {
+	keyring = tokenKeyring
+	accessTokenTTL = accessTTL
+	refreshTokenTTL = refreshTTL
+}
+
+func GenerateToken(id uint, email string) (string, time.Time, error) {
+	jti, err := randomString(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(accessTokenTTL)
+	// iat keeps microseconds so a token issued right after a revoke-all in
+	// the same second isn't mistaken for one it revoked.
 	claims := jwt.MapClaims{
 		"id":    id,
 		"email": email,
+		"jti":   jti,
+		"iat":   float64(now.UnixMicro()) / 1e6,
+		"exp":   expiresAt.Unix(),
 	}
 
-	parseToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signedToken, err := keyring.Sign(claims)
 
-	signedToken, err := parseToken.SignedString([]byte(secretKey))
+	return signedToken, expiresAt, err
+}
+
+// IssuedAt reads the iat claim of an access token to the microsecond.
+func IssuedAt(claims jwt.MapClaims) time.Time {
+	iat, _ := claims["iat"].(float64)
 
-	return signedToken, err
+	return time.UnixMicro(int64(math.Round(iat * 1e6)))
 }
 
 func ValidateToken(tokenString string) (interface{}, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unauthorized")
-		}
-
-		return []byte(secretKey), nil
-	})
+	token, err := jwt.Parse(tokenString, keyring.Keyfunc)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if _, ok := token.Claims.(jwt.MapClaims); !ok || !token.Valid {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("token has no expiry")
+	}
+	if _, ok := claims["purpose"]; ok {
 		return nil, fmt.Errorf("unauthorized")
 	}
 
-	return token.Claims.(jwt.MapClaims), nil
+	return claims, nil
+}
+
+// GenerateVerificationToken signs a link token proving that whoever holds it
+// can read mail sent to email.
+func GenerateVerificationToken(id uint, email string, ttl time.Duration) (string, time.Time, error) {
+	return generatePurposeToken(verifyEmailPurpose, id, email, ttl)
+}
+
+// ParseVerificationToken returns the user id and email a token from
+// GenerateVerificationToken was issued for.
+func ParseVerificationToken(tokenString string) (uint, string, error) {
+	return parsePurposeToken(verifyEmailPurpose, tokenString)
+}
+
+func generatePurposeToken(purpose string, id uint, email string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := jwt.MapClaims{
+		"sub":     id,
+		"email":   email,
+		"purpose": purpose,
+		"iat":     now.Unix(),
+		"exp":     expiresAt.Unix(),
+	}
+
+	signedToken, err := keyring.Sign(claims)
+
+	return signedToken, expiresAt, err
+}
+
+func parsePurposeToken(purpose, tokenString string) (uint, string, error) {
+	token, err := jwt.Parse(tokenString, keyring.Keyfunc)
+	if err != nil {
+		return 0, "", err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["purpose"] != purpose {
+		return 0, "", fmt.Errorf("invalid %s token", purpose)
+	}
+	if _, ok := claims["exp"]; !ok {
+		return 0, "", fmt.Errorf("token has no expiry")
+	}
+
+	id, _ := claims["sub"].(float64)
+	email, _ := claims["email"].(string)
+	if id <= 0 || email == "" {
+		return 0, "", fmt.Errorf("invalid %s token", purpose)
+	}
+
+	return uint(id), email, nil
+}
+
+// GenerateRefreshToken returns an opaque token for the client, the hash that
+// should be persisted in its place and its expiry.
+func GenerateRefreshToken() (string, string, time.Time, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return token, HashToken(token), time.Now().Add(refreshTokenTTL), nil
+}
+
+// GenerateOneTimeToken returns an opaque token to send to the user, such as
+// a password reset token, with its hash and expiry.
+func GenerateOneTimeToken(ttl time.Duration) (string, string, time.Time, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return token, HashToken(token), time.Now().Add(ttl), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
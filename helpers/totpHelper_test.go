@@ -0,0 +1,151 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTotpCodeRFC6238(t *testing.T) {
+	// The RFC lists eight digit codes; six digit ones are their last six.
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1111111111, code: "14050471"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 2000000000, code: "69279037"},
+		{unix: 20000000000, code: "65353130"},
+	}
+
+	for _, v := range vectors {
+		at := time.Unix(v.unix, 0)
+		want := v.code[len(v.code)-totpDigits:]
+
+		got, err := TotpCode(rfc6238Secret, TotpStep(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("TotpCode at %d = %s, want %s", v.unix, got, want)
+		}
+		if step, ok := ValidateTotp(strings.ToLower(rfc6238Secret), want, at); !ok || step != TotpStep(at) {
+			t.Errorf("ValidateTotp at %d = %d, %v", v.unix, step, ok)
+		}
+	}
+}
+
+func TestValidateTotpSkew(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	current := TotpStep(at)
+	code := func(step int64) string {
+		c, err := TotpCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name string
+		code string
+		step int64
+		ok   bool
+	}{
+		{name: "current step", code: code(current), step: current, ok: true},
+		{name: "one step behind", code: code(current - 1), step: current - 1, ok: true},
+		{name: "one step ahead", code: code(current + 1), step: current + 1, ok: true},
+		{name: "two steps behind", code: code(current - 2)},
+		{name: "two steps ahead", code: code(current + 2)},
+		{name: "surrounding spaces", code: " " + code(current) + "\n", step: current, ok: true},
+		{name: "too short", code: code(current)[1:]},
+		{name: "too long", code: code(current) + "0"},
+		{name: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTotp(rfc6238Secret, tt.code, at)
+			if ok != tt.ok || step != tt.step {
+				t.Fatalf("ValidateTotp(%q) = %d, %v; want %d, %v", tt.code, step, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateTotpStepBoundary(t *testing.T) {
+	// 59 is the last second of step 1, 60 the first of step 2: a code from
+	// step 0 is still accepted at 59 but not at 60.
+	stepZero, err := TotpCode(rfc6238Secret, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ValidateTotp(rfc6238Secret, stepZero, time.Unix(59, 0)); !ok {
+		t.Error("rejected at 59")
+	}
+	if _, ok := ValidateTotp(rfc6238Secret, stepZero, time.Unix(60, 0)); ok {
+		t.Error("accepted at 60")
+	}
+}
+
+func TestTotpInvalidSecret(t *testing.T) {
+	if _, err := TotpCode("not base32!", 1); err == nil {
+		t.Fatal("TotpCode accepted an invalid secret")
+	}
+	if _, ok := ValidateTotp("not base32!", "123456", time.Now()); ok {
+		t.Fatal("ValidateTotp accepted an invalid secret")
+	}
+}
+
+func TestGenerateTotpSecret(t *testing.T) {
+	secret, err := GenerateTotpSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 20 random bytes, the length RFC 4226 recommends for SHA-1.
+	if key, err := totpEncoding.DecodeString(secret); err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes, %v", secret, len(key), err)
+	}
+}
+
+func TestTotpURI(t *testing.T) {
+	got := TotpURI("Final Project", "alice@example.com", rfc6238Secret)
+	want := "otpauth://totp/Final%20Project:alice@example.com?algorithm=SHA1&digits=6&issuer=Final%20Project&period=30&secret=" + rfc6238Secret
+
+	if got != want {
+		t.Fatalf("TotpURI =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || code != strings.ToLower(code) || seen[code] {
+			t.Fatalf("bad or repeated recovery code %q", code)
+		}
+		seen[code] = true
+	}
+
+	typed := " " + strings.ToUpper(strings.Replace(codes[0], "-", " ", 1))
+	if HashRecoveryCode(typed) != HashRecoveryCode(codes[0]) {
+		t.Fatalf("%q does not hash like %q", typed, codes[0])
+	}
+	if HashRecoveryCode(codes[0]) == HashRecoveryCode(codes[1]) {
+		t.Fatal("different codes hash the same")
+	}
+}
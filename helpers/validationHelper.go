@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"final-project-golang/apperrors"
+	"strconv"
+	"unicode"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MinimumAge        = 9
+	MinPasswordLength = 8
+)
+
+func init() {
+	govalidator.TagMap["password"] = govalidator.Validator(IsStrongPassword)
+	govalidator.TagMap["minage"] = govalidator.Validator(func(str string) bool {
+		age, err := strconv.Atoi(str)
+		return err == nil && age >= MinimumAge
+	})
+}
+
+// IsStrongPassword requires at least MinPasswordLength characters with at
+// least one letter and one digit.
+func IsStrongPassword(password string) bool {
+	if len(password) < MinPasswordLength {
+		return false
+	}
+
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+
+	return letter && digit
+}
+
+// BindJSON decodes the request body into req and runs the `valid` rules of
+// its fields. Every failing field is reported, not only the first one.
+func BindJSON(ctx *gin.Context, req interface{}) error {
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		return toError(apperrors.CodeBadRequest, err)
+	}
+
+	return Validate(req)
+}
+
+// BindForm is BindJSON for form and multipart requests.
+func BindForm(ctx *gin.Context, req interface{}) error {
+	if err := ctx.ShouldBind(req); err != nil {
+		return toError(apperrors.CodeBadRequest, err)
+	}
+
+	return Validate(req)
+}
+
+func Validate(req interface{}) error {
+	if _, err := govalidator.ValidateStruct(req); err != nil {
+		return apperrors.As(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels bounds the decoded size so a small file can't expand into an
+// enormous bitmap.
+const MaxPixels = 50_000_000
+
+var ErrTooManyPixels = errors.New("imaging: image dimensions are too large")
+
+// Decode reads an image and applies its EXIF orientation, returning the
+// upright pixels and the source format name ("jpeg", "png", "gif", "webp").
+// Metadata is not carried over, so re-encoding the result strips EXIF and
+// GPS data.
+func Decode(data []byte) (image.Image, string, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, "", ErrTooManyPixels
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	return img, format, nil
+}
+
+// Fit scales img down so neither side exceeds maxSize. Smaller images and a
+// maxSize of 0 return a copy at the original size.
+func Fit(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if maxSize > 0 && (width > maxSize || height > maxSize) {
+		if width >= height {
+			height = max(1, height*maxSize/width)
+			width = maxSize
+		} else {
+			width = max(1, width*maxSize/height)
+			height = maxSize
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if width == bounds.Dx() && height == bounds.Dy() {
+		draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+		return dst
+	}
+
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	return dst
+}
+
+// Encode writes img as JPEG, or as PNG when format is "png".
+func Encode(w io.Writer, img image.Image, format string) error {
+	if format == "png" {
+		return png.Encode(w, img)
+	}
+
+	// JPEG has no alpha channel, so transparent areas become white instead
+	// of black.
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	return jpeg.Encode(w, flat, &jpeg.Options{Quality: 85})
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
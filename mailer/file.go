@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileMailer writes every message as an .eml file into a directory.
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFile(dir, from string) (Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("mailer: %w", err)
+	}
+
+	return &fileMailer{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+func (m *fileMailer) Send(message Message) error {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405"), hex.EncodeToString(buf))
+	content := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		m.from, message.To, message.Subject, now.Format(time.RFC1123Z), message.Body)
+
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o600)
+}
@@ -0,0 +1,20 @@
+package mailer
+
+import "log"
+
+// logMailer prints every message to the log instead of sending it.
+type logMailer struct {
+	from string
+}
+
+func NewLog(from string) Mailer {
+	return &logMailer{
+		from: from,
+	}
+}
+
+func (m *logMailer) Send(message Message) error {
+	log.Printf("mail from %s to %s: %s\n%s", m.from, message.To, message.Subject, message.Body)
+
+	return nil
+}
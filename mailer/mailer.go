@@ -0,0 +1,30 @@
+package mailer
+
+import (
+	"final-project-golang/config"
+	"fmt"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages to users. The log and file mailers are meant for
+// development; production needs an implementation backed by a mail server.
+type Mailer interface {
+	Send(message Message) error
+}
+
+// New builds the mailer selected in the configuration.
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "log":
+		return NewLog(cfg.From), nil
+	case "file":
+		return NewFile(cfg.FileDir, cfg.From)
+	}
+
+	return nil, fmt.Errorf("mailer: unknown driver %q", cfg.Driver)
+}
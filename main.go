@@ -1,9 +1,19 @@
 package main
 
-import "final-project-golang/routes"
+import (
+	"errors"
+	"final-project-golang/commands"
+	"flag"
+	"log"
+	"os"
+)
 
 func main() {
-	r := routes.StartApp()
-
-	r.Run(":8001")
+	err := commands.Run(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(commands.ErrorMessage(err))
+	}
 }
@@ -1,46 +1,69 @@
 package middlewares
 
 import (
+	"final-project-golang/apperrors"
 	"final-project-golang/helpers"
-	"net/http"
+	"final-project-golang/services"
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-func Auth() gin.HandlerFunc {
+// OptionalAuth authenticates requests that carry a token like Auth does and
+// lets the others through anonymously.
+func OptionalAuth(userService *services.UserService) gin.HandlerFunc {
+	auth := Auth(userService)
+
+	return func(ctx *gin.Context) {
+		if ctx.Request.Header.Get("Authorization") == "" {
+			ctx.Next()
+			return
+		}
+
+		auth(ctx)
+	}
+}
+
+func Auth(userService *services.UserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		headerToken := ctx.Request.Header.Get("Authorization")
 		if headerToken == "" {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "UNAUTHORIZED",
-			})
+			helpers.AbortWithError(ctx, apperrors.Unauthorized("UNAUTHORIZED"))
 			return
 		}
 
-		bearer := strings.HasPrefix(headerToken, "Bearer")
+		bearer := strings.HasPrefix(headerToken, "Bearer ")
 		if !bearer {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "UNAUTHORIZED",
-			})
+			helpers.AbortWithError(ctx, apperrors.Unauthorized("UNAUTHORIZED"))
 			return
 		}
 
-		bearerToken := strings.Split(headerToken, "Bearer ")[1]
+		bearerToken := strings.TrimPrefix(headerToken, "Bearer ")
 
 		verify, err := helpers.ValidateToken(bearerToken)
 
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": err,
-			})
+			helpers.AbortWithError(ctx, apperrors.Wrap(err, apperrors.CodeUnauthorized, err.Error()))
 			return
 		}
 		data := verify.(jwt.MapClaims)
 
+		userId, _ := data["id"].(float64)
+		jti, _ := data["jti"].(string)
+
+		user, err := userService.CheckSession(uint(userId), jti, helpers.IssuedAt(data))
+		if err != nil {
+			helpers.AbortWithError(ctx, err)
+			return
+		}
+
 		ctx.Set("id", data["id"])
 		ctx.Set("email", data["email"])
+		ctx.Set("jti", data["jti"])
+		ctx.Set("exp", data["exp"])
+		ctx.Set("role", user.Role)
+		ctx.Set("email_verified", user.Verified())
 		ctx.Next()
 	}
 }
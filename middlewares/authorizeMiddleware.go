@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize lets the request through only if the role Auth loaded grants
+// permission. It must run after Auth.
+func Authorize(permission services.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !services.Can(helpers.GetRole(ctx), permission) {
+			helpers.AbortWithError(ctx, apperrors.Forbidden("you're not allowed to access this resource"))
+			return
+		}
+
+		ctx.Next()
+	}
+}
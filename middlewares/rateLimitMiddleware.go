@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/ratelimit"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc picks the bucket a request is counted against.
+type KeyFunc func(ctx *gin.Context) string
+
+// ByIP counts requests per client IP.
+func ByIP(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+// ByUser counts requests per signed in user and falls back to the client IP.
+// It must run after Auth.
+func ByUser(ctx *gin.Context) string {
+	if userId := helpers.GetUserId(ctx); userId != 0 {
+		return fmt.Sprintf("user:%d", userId)
+	}
+
+	return ByIP(ctx)
+}
+
+// RateLimit counts every request against the bucket named by scope and key
+// and rejects it with 429 once the bucket is empty. A nil store lets every
+// request through, and so does a store that can't be reached, so an outage
+// doesn't take the API down with it.
+func RateLimit(store ratelimit.Store, scope string, limit ratelimit.Limit, key KeyFunc) gin.HandlerFunc {
+	if store == nil {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, helpers.CeilSeconds(limit.Per))
+
+	return func(ctx *gin.Context) {
+		result, err := store.Take(scope+":"+key(ctx), limit)
+		if err != nil {
+			log.Printf("ratelimit: %v", err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("RateLimit-Policy", policy)
+		ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("RateLimit-Reset", strconv.Itoa(helpers.CeilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			helpers.AbortWithError(ctx, apperrors.TooManyRequests("too many requests, try again later", result.RetryAfter))
+			return
+		}
+
+		ctx.Next()
+	}
+}
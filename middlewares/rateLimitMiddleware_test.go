@@ -0,0 +1,176 @@
+package middlewares
+
+import (
+	"errors"
+	"final-project-golang/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeStore answers every Take with result, or err, and remembers the key.
+type fakeStore struct {
+	ratelimit.Store
+	result ratelimit.Result
+	err    error
+	key    string
+}
+
+func (s *fakeStore) Take(key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	s.key = key
+
+	return s.result, s.err
+}
+
+func newRateLimitRouter(t *testing.T, store ratelimit.Store, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatal(err)
+	}
+	router.Use(RateLimit(store, "auth", ratelimit.Limit{Requests: 5, Per: time.Minute}, ByIP))
+	router.GET("/", func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	return router
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  ratelimit.Result
+		err     error
+		status  int
+		headers map[string]string
+	}{
+		{
+			name:   "allowed",
+			result: ratelimit.Result{Allowed: true, Limit: 5, Remaining: 3, Reset: 23500 * time.Millisecond},
+			status: http.StatusNoContent,
+			headers: map[string]string{
+				"RateLimit-Policy":    "5;w=60",
+				"RateLimit-Limit":     "5",
+				"RateLimit-Remaining": "3",
+				"RateLimit-Reset":     "24",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:   "rejected",
+			result: ratelimit.Result{Allowed: false, Limit: 5, Remaining: 0, Reset: time.Minute, RetryAfter: 11200 * time.Millisecond},
+			status: http.StatusTooManyRequests,
+			headers: map[string]string{
+				"RateLimit-Policy":    "5;w=60",
+				"RateLimit-Limit":     "5",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "12",
+			},
+		},
+		{
+			name:   "store unreachable",
+			err:    errors.New("connection refused"),
+			status: http.StatusNoContent,
+			headers: map[string]string{
+				"RateLimit-Policy": "",
+				"Retry-After":      "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRateLimitRouter(t, &fakeStore{result: tt.result, err: tt.err}, nil)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			for name, want := range tt.headers {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitWithoutStore(t *testing.T) {
+	router := newRateLimitRouter(t, nil, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "" {
+		t.Fatalf("status = %d, headers %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		key            string
+	}{
+		{
+			name:       "no proxy",
+			remoteAddr: "203.0.113.7:4000",
+			key:        "auth:ip:203.0.113.7",
+		},
+		{
+			name:         "forwarded without a trusted proxy",
+			remoteAddr:   "203.0.113.7:4000",
+			forwardedFor: "198.51.100.1",
+			key:          "auth:ip:203.0.113.7",
+		},
+		{
+			name:           "forwarded by a trusted proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:4000",
+			forwardedFor:   "203.0.113.7",
+			key:            "auth:ip:203.0.113.7",
+		},
+		{
+			name:           "client spoofs the start of the chain",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:4000",
+			forwardedFor:   "198.51.100.1, 203.0.113.7",
+			key:            "auth:ip:203.0.113.7",
+		},
+		{
+			name:           "forwarded by someone else",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "192.0.2.5:4000",
+			forwardedFor:   "203.0.113.7",
+			key:            "auth:ip:192.0.2.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{result: ratelimit.Result{Allowed: true, Limit: 5}}
+			router := newRateLimitRouter(t, store, tt.trustedProxies)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if store.key != tt.key {
+				t.Fatalf("key = %q, want %q", store.key, tt.key)
+			}
+		})
+	}
+}
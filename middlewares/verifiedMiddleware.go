@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerified turns away users who haven't verified their email when
+// required is set, and lets everyone through otherwise. It must run after
+// Auth.
+func RequireVerified(required bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if required && !helpers.IsEmailVerified(ctx) {
+			helpers.AbortWithError(ctx, services.ErrEmailNotVerified)
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	// AuditRestore records a row brought back within the restore window.
+	AuditRestore = "restore"
+)
+
+// AuditEntry records who created, changed or deleted a user, photo, comment
+// or social media and what the change was.
+type AuditEntry struct {
+	Id           uint   `gorm:"primaryKey" json:"id"`
+	ActorId      *uint  `json:"actor_id"`
+	Action       string `gorm:"not null" json:"action"`
+	ResourceType string `gorm:"not null" json:"resource_type"`
+	ResourceId   uint   `gorm:"not null" json:"resource_id"`
+	// Changes is a JSON object of every changed field with its old and new
+	// value.
+	Changes   string     `gorm:"type:jsonb;not null;default:'{}'" json:"changes"`
+	Ip        string     `gorm:"not null;default:''" json:"ip"`
+	UserAgent string     `gorm:"not null;default:''" json:"user_agent"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	Actor *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
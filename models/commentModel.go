@@ -7,16 +7,30 @@ import (
 	"gorm.io/gorm"
 )
 
+// CommentDeletedMessage replaces the message of a comment that was deleted
+// while it still had replies.
+const CommentDeletedMessage = "[deleted]"
+
 type Comment struct {
 	Id        uint       `gorm:"primaryKey" json:"id"`
 	UserId    uint       `json:"user_id"`
 	PhotoId   uint       `json:"photo_id"`
+	ParentId  *uint      `gorm:"index" json:"parent_id,omitempty"`
 	Message   string     `gorm:"not null" json:"message" valid:"required~message is required"`
 	CreatedAt *time.Time `json:"created_at,omitempty"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// RemovedAt marks a tombstone kept so its replies stay in place.
+	RemovedAt *time.Time `json:"removed_at,omitempty"`
+	// DeletedAt hides the comment until it is restored or purged.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	User    *User
+	Photo   *Photo
+	Replies []Comment `gorm:"foreignKey:ParentId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
 
-	User  *User
-	Photo *Photo
+func (c *Comment) Removed() bool {
+	return c.RemovedAt != nil
 }
 
 func (c *Comment) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Follow links a follower to the user they follow. Follows of private
+// accounts start as requests and only count once AcceptedAt is set.
+type Follow struct {
+	Id          uint       `gorm:"primaryKey" json:"id"`
+	FollowerId  uint       `gorm:"not null;uniqueIndex:idx_follows_pair" json:"follower_id"`
+	FollowingId uint       `gorm:"not null;uniqueIndex:idx_follows_pair;index" json:"following_id"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+
+	Follower  *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Following *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (f *Follow) Accepted() bool {
+	return f.AcceptedAt != nil
+}
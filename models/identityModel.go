@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Identity links a user to their account at an OpenID Connect provider, so
+// they can sign in there instead of with a password.
+type Identity struct {
+	Id        uint       `gorm:"primaryKey" json:"id"`
+	UserId    uint       `gorm:"not null;index" json:"user_id"`
+	Provider  string     `gorm:"not null;uniqueIndex:idx_identities_provider_subject" json:"provider"`
+	Subject   string     `gorm:"not null;uniqueIndex:idx_identities_provider_subject" json:"-"`
+	Email     string     `json:"email"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Like is a reaction of a user to either a photo or a comment; exactly one
+// of PhotoId and CommentId is set.
+type Like struct {
+	Id        uint       `gorm:"primaryKey" json:"id"`
+	UserId    uint       `gorm:"not null" json:"user_id"`
+	PhotoId   *uint      `json:"photo_id,omitempty"`
+	CommentId *uint      `json:"comment_id,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LoginState is kept from the start of an OpenID Connect sign-in until the
+// user comes back from the provider. Only the hash of the state is stored;
+// the nonce and PKCE verifier never leave the server.
+type LoginState struct {
+	Id           uint       `gorm:"primaryKey" json:"id"`
+	StateHash    string     `gorm:"not null;uniqueIndex" json:"-"`
+	Provider     string     `gorm:"not null" json:"provider"`
+	Nonce        string     `gorm:"not null" json:"-"`
+	CodeVerifier string     `gorm:"not null" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+}
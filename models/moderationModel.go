@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	ModerationDeletePhoto    = "photo.delete"
+	ModerationDeleteComment  = "comment.delete"
+	ModerationDeleteSocial   = "social.delete"
+	ModerationDisableUser    = "user.disable"
+	ModerationEnableUser     = "user.enable"
+	ModerationChangeRole     = "user.role"
+	ModerationResetTwoFactor = "user.2fa_reset"
+)
+
+// ModerationAction records what a moderator or admin did to someone else's
+// account or content.
+type ModerationAction struct {
+	Id         uint       `gorm:"primaryKey" json:"id"`
+	ActorId    *uint      `json:"actor_id"`
+	Action     string     `gorm:"not null" json:"action"`
+	TargetType string     `gorm:"not null" json:"target_type"`
+	TargetId   uint       `gorm:"not null" json:"target_id"`
+	Reason     string     `gorm:"not null;default:''" json:"reason"`
+	Detail     string     `gorm:"not null;default:''" json:"detail"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+
+	Actor *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
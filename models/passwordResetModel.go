@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PasswordReset is a single-use token mailed to a user who forgot their
+// password. Only its hash is stored.
+type PasswordReset struct {
+	Id        uint       `gorm:"primaryKey" json:"id"`
+	UserId    uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
@@ -7,19 +7,47 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	PhotoProcessingPending = "pending"
+	PhotoProcessingReady   = "ready"
+	PhotoProcessingFailed  = "failed"
+)
+
 type Photo struct {
-	Id        uint       `gorm:"primaryKey" json:"id"`
-	Title     string     `gorm:"not null" json:"title" valid:"required~title is required"`
-	Caption   string     `json:"caption"`
-	PhotoUrl  string     `gorm:"not null" json:"photo_url" valid:"required~photo_url is required"`
-	UserId    uint       `json:"user_id"`
-	CreatedAt *time.Time `json:"created_at,omitempty"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
-	Comment   []Comment  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Id       uint   `gorm:"primaryKey" json:"id"`
+	Title    string `gorm:"not null" json:"title" valid:"required~title is required"`
+	Caption  string `json:"caption"`
+	PhotoUrl string `gorm:"not null" json:"photo_url" valid:"required~photo_url is required"`
+	UserId   uint   `json:"user_id"`
+	// StorageKey is set for photos uploaded to this server instead of
+	// linked by URL. UploadKey holds the raw upload until it is processed.
+	StorageKey       *string    `gorm:"uniqueIndex" json:"-"`
+	UploadKey        *string    `json:"-"`
+	ContentType      string     `json:"content_type,omitempty"`
+	Size             int64      `json:"size,omitempty"`
+	ProcessingStatus string     `json:"processing_status,omitempty"`
+	Width            int        `json:"width,omitempty"`
+	Height           int        `json:"height,omitempty"`
+	Format           string     `json:"format,omitempty"`
+	Blurhash         string     `json:"blurhash,omitempty"`
+	MediumUrl        string     `json:"medium_url,omitempty"`
+	ThumbnailUrl     string     `json:"thumbnail_url,omitempty"`
+	CreatedAt        *time.Time `json:"created_at,omitempty"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+	// DeletedAt hides the photo until it is restored or purged. Stored files
+	// are kept until the purge.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	Comment   []Comment      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 
 	User *User
 }
 
+// Processed reports whether the photo can be shown to other users: uploads
+// once they are ready, and photos added by URL, which are never processed.
+func (p *Photo) Processed() bool {
+	return p.ProcessingStatus == "" || p.ProcessingStatus == PhotoProcessingReady
+}
+
 func (p *Photo) BeforeCreate(tx *gorm.DB) (err error) {
 	_, errCreate := govalidator.ValidateStruct(p)
 	if errCreate != nil {
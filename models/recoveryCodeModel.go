@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RecoveryCode lets a user with two factor authentication sign in once
+// without their authenticator. Only its hash is stored.
+type RecoveryCode struct {
+	Id        uint       `gorm:"primaryKey" json:"id"`
+	UserId    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
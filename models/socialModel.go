@@ -14,6 +14,8 @@ type Social struct {
 	UserId         uint       `json:"user_id"`
 	CreatedAt      *time.Time `json:"created_at,omitempty"`
 	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+	// DeletedAt hides the social media until it is restored or purged.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	User *User
 }
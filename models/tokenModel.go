@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	Id        uint       `gorm:"primaryKey" json:"id"`
+	UserId    uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+type RevokedToken struct {
+	Jti       string     `gorm:"primaryKey" json:"jti"`
+	UserId    uint       `gorm:"not null;index" json:"user_id"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// TwoFactorChallenge is handed out by login instead of tokens when the user
+// has two factor authentication on, and traded with a code for a session.
+// It works once and allows a few wrong codes. Only its hash is stored.
+type TwoFactorChallenge struct {
+	Id        uint       `gorm:"primaryKey" json:"id"`
+	UserId    uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
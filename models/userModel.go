@@ -8,6 +8,12 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
 	Id        uint       `gorm:"primaryKey" json:"id"`
 	Username  string     `gorm:"not null;uniqueIndex" json:"username" valid:"required~username is required"`
@@ -16,9 +22,35 @@ type User struct {
 	Age       int        `gorm:"not null" json:"age" valid:"required~age is required"`
 	CreatedAt *time.Time `json:"created_at,omitempty"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
-	Photo     []Photo    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	Comment   []Comment  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	Sosial    []Social   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	// TokensRevokedAt invalidates every access token issued before it.
+	TokensRevokedAt *time.Time `json:"-"`
+	DisabledAt      *time.Time `json:"-"`
+	// PasswordChangedAt is when the password was last changed or reset.
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	// EmailVerifiedAt is cleared whenever the email changes.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// TotpSecret is set on enrollment; two factor authentication is on once
+	// TotpEnabledAt is set. TotpLastStep is the last time step a code was
+	// accepted for, so a code can't be used twice.
+	TotpSecret    string     `gorm:"not null;default:''" json:"-"`
+	TotpEnabledAt *time.Time `json:"-"`
+	TotpLastStep  int64      `gorm:"not null;default:0" json:"-"`
+	// Private accounts approve every follower.
+	Private bool   `gorm:"not null;default:false" json:"private"`
+	Role    string `gorm:"not null;default:user" json:"role"`
+	// DeletedAt hides the account until it is restored or purged.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	Photo     []Photo        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Comment   []Comment      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Sosial    []Social       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (u *User) Verified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) TwoFactorEnabled() bool {
+	return u.TotpEnabledAt != nil
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -35,11 +67,29 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 
 	u.Password = hash
 
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
+
 	// validasi umur
 
 	return
 }
 
+// SetPassword hashes password into the user. Updates don't go through
+// BeforeCreate, so any password change has to be hashed here first.
+func (u *User) SetPassword(password string, at time.Time) error {
+	hash, err := helpers.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	u.Password = hash
+	u.PasswordChangedAt = &at
+
+	return nil
+}
+
 func (u *User) BeforeUpdate(tx *gorm.DB) (err error) {
 	_, errCreate := govalidator.ValidateStruct(u)
 	if errCreate != nil {
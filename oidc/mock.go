@@ -0,0 +1,221 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"final-project-golang/helpers"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const mockCodeTTL = time.Minute
+
+// Mock is an OpenID Connect provider for tests and local development. It
+// signs in the user named by the login_hint parameter without asking, and
+// otherwise checks requests the way a real provider does, PKCE included.
+// Its issuer is the URL it is served at, read from every request.
+type Mock struct {
+	clientId     string
+	clientSecret string
+	keyring      *helpers.Keyring
+
+	mu    sync.Mutex
+	users map[string]Claims
+	codes map[string]mockCode
+}
+
+type mockCode struct {
+	claims      Claims
+	redirectURI string
+	challenge   string
+	nonce       string
+	expiresAt   time.Time
+}
+
+func NewMock(clientId, clientSecret string) (*Mock, error) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring := helpers.NewKeyring()
+	keyring.Add(helpers.SigningKey{
+		Id:      "mock",
+		Method:  jwt.SigningMethodRS256,
+		Private: private,
+		Public:  &private.PublicKey,
+	}, true)
+
+	return &Mock{
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		keyring:      keyring,
+		users:        make(map[string]Claims),
+		codes:        make(map[string]mockCode),
+	}, nil
+}
+
+// AddUser lets login sign in with claims.
+func (m *Mock) AddUser(login string, claims Claims) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users[login] = claims
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		issuer := mockIssuer(r)
+		writeMockJSON(w, http.StatusOK, discovery{
+			Issuer:                issuer,
+			AuthorizationEndpoint: issuer + "/authorize",
+			TokenEndpoint:         issuer + "/token",
+			JwksURI:               issuer + "/jwks",
+		})
+	case "/authorize":
+		m.authorize(w, r)
+	case "/token":
+		m.token(w, r)
+	case "/jwks":
+		writeMockJSON(w, http.StatusOK, m.keyring.JWKS())
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *Mock) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirect.IsAbs() || query.Get("client_id") != m.clientId {
+		http.Error(w, "invalid client_id or redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	params := redirect.Query()
+	params.Set("state", query.Get("state"))
+
+	m.mu.Lock()
+	claims, ok := m.users[query.Get("login_hint")]
+	m.mu.Unlock()
+
+	switch {
+	case query.Get("response_type") != "code":
+		params.Set("error", "unsupported_response_type")
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		params.Set("error", "invalid_request")
+	case !ok:
+		params.Set("error", "access_denied")
+	default:
+		code, err := randomString(16)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		m.mu.Lock()
+		m.codes[code] = mockCode{
+			claims:      claims,
+			redirectURI: query.Get("redirect_uri"),
+			challenge:   query.Get("code_challenge"),
+			nonce:       query.Get("nonce"),
+			expiresAt:   time.Now().Add(mockCodeTTL),
+		}
+		m.mu.Unlock()
+		params.Set("code", code)
+	}
+
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (m *Mock) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeMockError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientId, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientId, _ = url.QueryUnescape(clientId)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientId, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientId != m.clientId || clientSecret != m.clientSecret {
+		writeMockError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeMockError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	// Codes are single use, even when the exchange fails.
+	m.mu.Lock()
+	code, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	if !ok || time.Now().After(code.expiresAt) ||
+		code.redirectURI != r.PostForm.Get("redirect_uri") ||
+		code.challenge != CodeChallenge(r.PostForm.Get("code_verifier")) {
+		writeMockError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	idToken, err := m.keyring.Sign(jwt.MapClaims{
+		"iss":            mockIssuer(r),
+		"sub":            code.claims.Subject,
+		"aud":            m.clientId,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          code.nonce,
+		"email":          code.claims.Email,
+		"email_verified": code.claims.EmailVerified,
+		"name":           code.claims.Name,
+	})
+	if err != nil {
+		writeMockError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	accessToken, err := randomString(16)
+	if err != nil {
+		writeMockError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	writeMockJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func mockIssuer(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+func writeMockError(w http.ResponseWriter, status int, code string) {
+	writeMockJSON(w, status, map[string]string{"error": code})
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
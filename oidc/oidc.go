@@ -0,0 +1,132 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"final-project-golang/config"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Claims is what a provider tells about the user who signed in.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// AuthRequest holds the values of one authorization request that have to be
+// kept until the user comes back: State is sent back as is, Nonce is echoed
+// in the ID token and Verifier proves the code was requested by us (PKCE).
+type AuthRequest struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// Provider signs users in through the authorization code flow. Providers
+// other than the discovery based one can be plugged in with Providers.Add.
+type Provider interface {
+	Name() string
+	// AuthURL is where the user is sent to sign in.
+	AuthURL(ctx context.Context, req AuthRequest) (string, error)
+	// Exchange trades the code for an ID token and returns its claims once
+	// the token is verified to carry nonce.
+	Exchange(ctx context.Context, code, verifier, nonce string) (Claims, error)
+}
+
+// Providers holds the providers users can sign in with, by name.
+type Providers struct {
+	providers map[string]Provider
+}
+
+func NewProviders(providers ...Provider) *Providers {
+	p := &Providers{providers: make(map[string]Provider)}
+	for _, provider := range providers {
+		p.Add(provider)
+	}
+
+	return p
+}
+
+// New builds a discovery based provider for every configured one.
+func New(cfg config.OIDCConfig) *Providers {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	p := NewProviders()
+	for _, providerCfg := range cfg.Providers {
+		p.Add(NewProvider(providerCfg, client))
+	}
+
+	return p
+}
+
+func (p *Providers) Add(provider Provider) {
+	p.providers[provider.Name()] = provider
+}
+
+func (p *Providers) Get(name string) (Provider, bool) {
+	if p == nil {
+		return nil, false
+	}
+	provider, ok := p.providers[name]
+
+	return provider, ok
+}
+
+func (p *Providers) Names() []string {
+	if p == nil {
+		return []string{}
+	}
+
+	names := make([]string, 0, len(p.providers))
+	for name := range p.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewAuthRequest draws fresh random values for an authorization request.
+func NewAuthRequest() (AuthRequest, error) {
+	var req AuthRequest
+	var err error
+
+	if req.State, err = randomString(32); err != nil {
+		return req, err
+	}
+	if req.Nonce, err = randomString(32); err != nil {
+		return req, err
+	}
+	if req.Verifier, err = randomString(32); err != nil {
+		return req, err
+	}
+
+	return req, nil
+}
+
+// Challenge is the S256 PKCE code challenge of the verifier.
+func (r AuthRequest) Challenge() string {
+	return CodeChallenge(r.Verifier)
+}
+
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("oidc: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
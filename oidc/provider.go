@@ -0,0 +1,361 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"final-project-golang/config"
+	"final-project-golang/helpers"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var defaultScopes = []string{"openid", "email", "profile"}
+
+const (
+	// leeway absorbs clock drift between us and the provider.
+	leeway = time.Minute
+	// keysRefreshInterval keeps tokens with an unknown kid from making us
+	// download the provider keys on every request.
+	keysRefreshInterval = time.Minute
+	maxResponseSize     = 1 << 20
+)
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// provider reads its endpoints from the issuer's discovery document and
+// verifies ID tokens with the keys it publishes. Both are fetched on first
+// use and cached; keys are fetched again when a token names an unknown one.
+type provider struct {
+	cfg    config.OIDCProviderConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]interface{}
+	// keysFetchedAt is when the keys were last fetched; keysFetching is
+	// closed when the fetch in progress, if any, is done.
+	keysFetchedAt time.Time
+	keysFetching  chan struct{}
+}
+
+func NewProvider(cfg config.OIDCProviderConfig, client *http.Client) Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultScopes
+	}
+
+	return &provider{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+func (p *provider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *provider) AuthURL(ctx context.Context, req AuthRequest) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, err := url.Parse(doc.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: authorization endpoint: %w", err)
+	}
+
+	query := endpoint.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientId)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", req.State)
+	query.Set("nonce", req.Nonce)
+	query.Set("code_challenge", req.Challenge())
+	query.Set("code_challenge_method", "S256")
+	endpoint.RawQuery = query.Encode()
+
+	return endpoint.String(), nil
+}
+
+func (p *provider) Exchange(ctx context.Context, code, verifier, nonce string) (Claims, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {p.cfg.ClientId},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, fmt.Errorf("oidc: token endpoint: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.cfg.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(p.cfg.ClientId), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token tokenResponse
+	status, err := p.fetchJSON(request, &token)
+	if err != nil {
+		return Claims{}, fmt.Errorf("oidc: token endpoint: %w", err)
+	}
+	if token.Error != "" {
+		return Claims{}, fmt.Errorf("oidc: token endpoint: %s %s", token.Error, token.ErrorDescription)
+	}
+	if status != http.StatusOK || token.IdToken == "" {
+		return Claims{}, fmt.Errorf("oidc: token endpoint answered %d without an ID token", status)
+	}
+
+	return p.verify(ctx, doc, token.IdToken, nonce)
+}
+
+// verify checks the signature, issuer, audience, expiry and nonce of an ID
+// token. Only asymmetric algorithms are accepted, so the client secret can't
+// be used to forge tokens.
+func (p *provider) verify(ctx context.Context, doc *discovery, idToken, nonce string) (Claims, error) {
+	parser := jwt.Parser{
+		ValidMethods:         []string{"RS256", "ES256"},
+		SkipClaimsValidation: true,
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, doc, kid)
+	})
+	if err != nil {
+		return Claims{}, fmt.Errorf("oidc: id token: %w", err)
+	}
+
+	if iss, _ := claims["iss"].(string); iss != doc.Issuer {
+		return Claims{}, errors.New("oidc: id token: wrong issuer")
+	}
+	if !hasAudience(claims["aud"], p.cfg.ClientId) {
+		return Claims{}, errors.New("oidc: id token: wrong audience")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || time.Now().After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return Claims{}, errors.New("oidc: id token: expired")
+	}
+	if got, _ := claims["nonce"].(string); nonce == "" || got != nonce {
+		return Claims{}, errors.New("oidc: id token: wrong nonce")
+	}
+
+	result := Claims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+	// Some providers send email_verified as a string.
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+	if result.Subject == "" {
+		return Claims{}, errors.New("oidc: id token: missing subject")
+	}
+
+	return result, nil
+}
+
+// discover returns the cached discovery document, fetching it first if
+// needed. The lock is not held during the request; when two callers race,
+// the first document stored wins.
+func (p *provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	cached := p.discovery
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	doc, err := p.fetchDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery == nil {
+		p.discovery = doc
+	}
+
+	return p.discovery, nil
+}
+
+func (p *provider) fetchDiscovery(ctx context.Context) (*discovery, error) {
+	issuer := strings.TrimSuffix(p.cfg.Issuer, "/")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+
+	var doc discovery
+	status, err := p.fetchJSON(request, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc: discovery answered %d", status)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: discovery: issuer %q does not match %q", doc.Issuer, p.cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JwksURI == "" {
+		return nil, errors.New("oidc: discovery: missing endpoints")
+	}
+
+	return &doc, nil
+}
+
+// key returns the provider key named kid. A token without kid is accepted
+// when the provider publishes a single key. Unknown keys trigger one fetch
+// of the key set at a time, made without holding the lock; callers asking
+// meanwhile wait for it instead of fetching again.
+func (p *provider) key(ctx context.Context, doc *discovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	if key, ok := p.lookupKey(kid); ok {
+		p.mu.Unlock()
+		return key, nil
+	}
+
+	if fetching := p.keysFetching; fetching != nil {
+		p.mu.Unlock()
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if key, ok := p.lookupKey(kid); ok {
+			return key, nil
+		}
+		return nil, errors.New("unknown signing key")
+	}
+
+	if time.Since(p.keysFetchedAt) < keysRefreshInterval {
+		p.mu.Unlock()
+		return nil, errors.New("unknown signing key")
+	}
+	fetching := make(chan struct{})
+	p.keysFetching = fetching
+	p.mu.Unlock()
+
+	keys, err := p.fetchKeys(ctx, doc)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keysFetching = nil
+	close(fetching)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+
+	return nil, errors.New("unknown signing key")
+}
+
+func (p *provider) fetchKeys(ctx context.Context, doc *discovery) (map[string]interface{}, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, doc.JwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set helpers.JWKSet
+	status, err := p.fetchJSON(request, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("jwks answered %d", status)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped rather than failing the set.
+		if key, err := jwk.PublicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+func (p *provider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+
+	return key, ok
+}
+
+func (p *provider) fetchJSON(request *http.Request, target interface{}) (int, error) {
+	request.Header.Set("Accept", "application/json")
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxResponseSize)).Decode(target); err != nil {
+		return response.StatusCode, fmt.Errorf("status %d: %w", response.StatusCode, err)
+	}
+
+	return response.StatusCode, nil
+}
+
+// hasAudience accepts aud as a single string or a list.
+func hasAudience(aud interface{}, clientId string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientId
+	case []interface{}:
+		for _, item := range aud {
+			if item == clientId {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"final-project-golang/config"
+	"final-project-golang/helpers"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// blockingIssuer serves a discovery document and a key set. Key set
+// requests are announced on fetched and held until release is closed.
+type blockingIssuer struct {
+	*httptest.Server
+	fetches int32
+	fetched chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+// unblock answers the held key set requests and every later one.
+func (i *blockingIssuer) unblock() {
+	i.once.Do(func() { close(i.release) })
+}
+
+func newBlockingIssuer(t *testing.T, keys helpers.JWKSet) *blockingIssuer {
+	t.Helper()
+
+	issuer := &blockingIssuer{fetched: make(chan struct{}, 16), release: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discovery{
+			Issuer:                issuer.URL,
+			AuthorizationEndpoint: issuer.URL + "/authorize",
+			TokenEndpoint:         issuer.URL + "/token",
+			JwksURI:               issuer.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&issuer.fetches, 1)
+		issuer.fetched <- struct{}{}
+		<-issuer.release
+		json.NewEncoder(w).Encode(keys)
+	})
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	t.Cleanup(issuer.unblock)
+
+	return issuer
+}
+
+func TestProviderFetchesKeysOutsideTheLock(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := helpers.NewKeyring()
+	keyring.Add(helpers.SigningKey{Id: "k1", Method: jwt.SigningMethodES256, Private: private, Public: &private.PublicKey}, true)
+
+	issuer := newBlockingIssuer(t, keyring.JWKS())
+	p := NewProvider(config.OIDCProviderConfig{Name: "test", Issuer: issuer.URL, ClientId: "app"}, issuer.Client()).(*provider)
+
+	ctx := context.Background()
+	doc, err := p.discover(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.key(ctx, doc, "k1")
+			errs <- err
+		}()
+	}
+	<-issuer.fetched
+
+	// The key set request is still open; the cached document must not wait
+	// for it.
+	discovered := make(chan error, 1)
+	go func() {
+		_, err := p.discover(ctx)
+		discovered <- err
+	}()
+	select {
+	case err := <-discovered:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("discover waited for the key set request")
+	}
+
+	issuer.unblock()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("key: %v", err)
+		}
+	}
+	if fetches := atomic.LoadInt32(&issuer.fetches); fetches != 1 {
+		t.Fatalf("key set fetched %d times, want once", fetches)
+	}
+
+	// An unknown key right after a fetch is rejected without another one.
+	if _, err := p.key(ctx, doc, "k2"); err == nil {
+		t.Fatal("accepted an unknown key")
+	}
+	if fetches := atomic.LoadInt32(&issuer.fetches); fetches != 1 {
+		t.Fatalf("key set fetched %d times after an unknown key, want once", fetches)
+	}
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"strings"
+	"time"
+)
+
+// LockoutPolicy locks a key for Base once it reaches Threshold failures and
+// doubles the lock with every further failure, up to Max. Failures are
+// forgotten after Window without a new one.
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	Window    time.Duration
+}
+
+// Lockout tracks failed logins per email. A nil Lockout never locks.
+type Lockout struct {
+	store  Store
+	policy LockoutPolicy
+	now    func() time.Time
+}
+
+func NewLockout(store Store, policy LockoutPolicy) *Lockout {
+	return &Lockout{
+		store:  store,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Check returns how long email stays locked, zero when it isn't.
+func (l *Lockout) Check(email string) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	failures, err := l.store.Failures(lockoutKey(email))
+	if err != nil {
+		return 0, err
+	}
+
+	return l.remaining(failures), nil
+}
+
+// Fail records a failed login and returns how long email is now locked.
+func (l *Lockout) Fail(email string) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	failures, err := l.store.Fail(lockoutKey(email), l.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	return l.remaining(failures), nil
+}
+
+// Succeed clears the failures of email after a successful login.
+func (l *Lockout) Succeed(email string) error {
+	if l == nil {
+		return nil
+	}
+
+	return l.store.Reset(lockoutKey(email))
+}
+
+func (l *Lockout) remaining(failures Failures) time.Duration {
+	if failures.Count < l.policy.Threshold {
+		return 0
+	}
+
+	lock := l.policy.Base
+	for i := l.policy.Threshold; i < failures.Count && lock < l.policy.Max; i++ {
+		lock *= 2
+	}
+	if lock > l.policy.Max {
+		lock = l.policy.Max
+	}
+
+	remaining := failures.Last.Add(lock).Sub(l.now())
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
+
+func lockoutKey(email string) string {
+	return "login:" + strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLockout() (*Lockout, *testClock) {
+	store, clock := newTestMemory()
+	lockout := NewLockout(store, LockoutPolicy{
+		Threshold: 3,
+		Base:      time.Minute,
+		Max:       4 * time.Minute,
+		Window:    time.Hour,
+	})
+	lockout.now = clock.Now
+
+	return lockout, clock
+}
+
+func TestLockoutDoublesUpToMax(t *testing.T) {
+	lockout, _ := newTestLockout()
+
+	for i, want := range []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute, 4 * time.Minute} {
+		locked, err := lockout.Fail("alice@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if locked != want {
+			t.Fatalf("failure %d: locked for %s, want %s", i+1, locked, want)
+		}
+	}
+}
+
+func TestLockoutCheck(t *testing.T) {
+	steps := []struct {
+		name    string
+		advance time.Duration
+		fail    bool
+		succeed bool
+		locked  time.Duration
+	}{
+		{name: "first failure", fail: true},
+		{name: "second failure", fail: true},
+		{name: "third failure locks", fail: true, locked: time.Minute},
+		{name: "lock counts down", advance: 20 * time.Second, locked: 40 * time.Second},
+		{name: "lock runs out", advance: 40 * time.Second},
+		{name: "next failure locks twice as long", fail: true, locked: 2 * time.Minute},
+		{name: "lock runs out again", advance: 2 * time.Minute},
+		{name: "failures are forgotten after the window", advance: time.Hour, fail: true},
+		{name: "so two more don't lock", fail: true},
+		{name: "but three do", fail: true, locked: time.Minute},
+		{name: "success clears the lock", succeed: true},
+		{name: "and the count", fail: true},
+	}
+
+	lockout, clock := newTestLockout()
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		if step.fail {
+			if _, err := lockout.Fail("alice@example.com"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if step.succeed {
+			if err := lockout.Succeed("alice@example.com"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		locked, err := lockout.Check("alice@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if locked != step.locked {
+			t.Fatalf("%s: locked for %s, want %s", step.name, locked, step.locked)
+		}
+	}
+}
+
+func TestLockoutKeysByEmail(t *testing.T) {
+	lockout, _ := newTestLockout()
+
+	for _, email := range []string{"alice@example.com", " Alice@Example.com", "ALICE@EXAMPLE.COM "} {
+		if _, err := lockout.Fail(email); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if locked, _ := lockout.Check("alice@example.com"); locked != time.Minute {
+		t.Fatalf("alice: locked for %s, want the spellings to add up to a lock", locked)
+	}
+	if locked, _ := lockout.Check("bob@example.com"); locked != 0 {
+		t.Fatalf("bob: locked for %s, want 0", locked)
+	}
+}
+
+func TestNilLockoutNeverLocks(t *testing.T) {
+	var lockout *Lockout
+
+	for i := 0; i < 10; i++ {
+		if locked, err := lockout.Fail("alice@example.com"); locked != 0 || err != nil {
+			t.Fatalf("Fail: %s, %v", locked, err)
+		}
+	}
+	if locked, err := lockout.Check("alice@example.com"); locked != 0 || err != nil {
+		t.Fatalf("Check: %s, %v", locked, err)
+	}
+	if err := lockout.Succeed("alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+}
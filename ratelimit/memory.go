@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory store drops idle entries.
+const sweepInterval = time.Minute
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket will have refilled, after which it can be
+	// dropped.
+	full time.Time
+}
+
+type memoryFailures struct {
+	failures Failures
+	expires  time.Time
+}
+
+// memoryStore keeps buckets and counters in this process only.
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]memoryBucket
+	failures  map[string]memoryFailures
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemory() Store {
+	return &memoryStore{
+		buckets:  make(map[string]memoryBucket),
+		failures: make(map[string]memoryFailures),
+		now:      time.Now,
+	}
+}
+
+func (s *memoryStore) Take(key string, limit Limit) (Result, error) {
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		return Result{}, ErrInvalidLimit
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = memoryBucket{tokens: capacity, updated: now}
+	}
+	bucket.tokens = math.Min(capacity, bucket.tokens+float64(now.Sub(bucket.updated))*limit.rate())
+	bucket.updated = now
+
+	result := Result{Limit: limit.Requests}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) / limit.rate())
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = time.Duration((capacity - bucket.tokens) / limit.rate())
+
+	bucket.full = now.Add(result.Reset)
+	s.buckets[key] = bucket
+
+	return result, nil
+}
+
+func (s *memoryStore) Fail(key string, ttl time.Duration) (Failures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry := s.failures[key]
+	if !entry.expires.After(now) {
+		entry = memoryFailures{}
+	}
+	entry.failures.Count++
+	entry.failures.Last = now
+	entry.expires = now.Add(ttl)
+	s.failures[key] = entry
+
+	return entry.failures, nil
+}
+
+func (s *memoryStore) Failures(key string) (Failures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.failures[key]
+	if !ok || !entry.expires.After(s.now()) {
+		return Failures{}, nil
+	}
+
+	return entry.failures, nil
+}
+
+func (s *memoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+
+	return nil
+}
+
+// sweep drops full buckets and expired counters so that one-off clients
+// don't pile up.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if !bucket.full.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, entry := range s.failures {
+		if !entry.expires.After(now) {
+			delete(s.failures, key)
+		}
+	}
+}
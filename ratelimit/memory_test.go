@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestMemory() (*memoryStore, *testClock) {
+	clock := &testClock{now: time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemory().(*memoryStore)
+	store.now = clock.Now
+
+	return store, clock
+}
+
+func TestMemoryTake(t *testing.T) {
+	// Three requests, refilling one a second.
+	limit := Limit{Requests: 3, Per: 3 * time.Second}
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		allowed    bool
+		remaining  int
+		reset      time.Duration
+		retryAfter time.Duration
+	}{
+		{name: "first of the burst", allowed: true, remaining: 2, reset: time.Second},
+		{name: "second of the burst", allowed: true, remaining: 1, reset: 2 * time.Second},
+		{name: "last of the burst", allowed: true, remaining: 0, reset: 3 * time.Second},
+		{name: "empty", allowed: false, remaining: 0, reset: 3 * time.Second, retryAfter: time.Second},
+		{name: "half refilled", advance: 500 * time.Millisecond, allowed: false, remaining: 0, reset: 2500 * time.Millisecond, retryAfter: 500 * time.Millisecond},
+		{name: "one token refilled", advance: 500 * time.Millisecond, allowed: true, remaining: 0, reset: 3 * time.Second},
+		{name: "refill stops at the burst", advance: time.Minute, allowed: true, remaining: 2, reset: time.Second},
+	}
+
+	store, clock := newTestMemory()
+	for _, step := range steps {
+		clock.Advance(step.advance)
+
+		result, err := store.Take("ip:1.2.3.4", limit)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if result.Allowed != step.allowed || result.Limit != 3 || result.Remaining != step.remaining ||
+			result.Reset.Round(time.Millisecond) != step.reset || result.RetryAfter.Round(time.Millisecond) != step.retryAfter {
+			t.Fatalf("%s: got %+v, want allowed %v, remaining %d, reset %s, retry after %s",
+				step.name, result, step.allowed, step.remaining, step.reset, step.retryAfter)
+		}
+	}
+
+	// Other keys have their own bucket.
+	if result, err := store.Take("ip:5.6.7.8", limit); err != nil || !result.Allowed || result.Remaining != 2 {
+		t.Fatalf("other key: got %+v, %v", result, err)
+	}
+}
+
+func TestMemoryTakeInvalidLimit(t *testing.T) {
+	store, _ := newTestMemory()
+
+	for _, limit := range []Limit{{Requests: 0, Per: time.Second}, {Requests: 1, Per: 0}, {Requests: -1, Per: time.Second}} {
+		if _, err := store.Take("key", limit); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Take(%+v): got %v, want ErrInvalidLimit", limit, err)
+		}
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	store, clock := newTestMemory()
+	limit := Limit{Requests: 1, Per: time.Second}
+
+	if _, err := store.Take("a", limit); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Fail("b", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(sweepInterval)
+	if _, err := store.Take("c", limit); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := store.buckets["a"]; ok {
+		t.Error("full bucket was kept")
+	}
+	if _, ok := store.failures["b"]; ok {
+		t.Error("expired failures were kept")
+	}
+	if _, ok := store.buckets["c"]; !ok {
+		t.Error("bucket in use was dropped")
+	}
+}
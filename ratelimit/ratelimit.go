@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"errors"
+	"final-project-golang/config"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New("ratelimit: requests and period must be positive")
+
+// Limit is a token bucket holding Requests tokens that refills completely
+// over Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / float64(l.Per)
+}
+
+// Result describes the bucket after a request was counted against it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again, RetryAfter how long
+	// until the next request is allowed.
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Failures counts failed attempts against one key, such as a login email.
+type Failures struct {
+	Count int
+	Last  time.Time
+}
+
+// Store keeps buckets and failure counters. The memory store works for a
+// single instance; a shared store such as Redis lets several instances
+// enforce the same limits.
+type Store interface {
+	// Take removes a token from the bucket at key.
+	Take(key string, limit Limit) (Result, error)
+	// Fail counts a failure against key. Counters are forgotten once ttl has
+	// passed without a new failure.
+	Fail(key string, ttl time.Duration) (Failures, error)
+	Failures(key string) (Failures, error)
+	Reset(key string) error
+}
+
+// Limits are the configured limits and the store they are kept in.
+type Limits struct {
+	Store   Store
+	Auth    Limit
+	Writes  Limit
+	Lockout *Lockout
+}
+
+// New builds the limits in the configuration, or returns nil when rate
+// limiting is turned off.
+func New(cfg config.RateLimitConfig) (*Limits, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var store Store
+	switch cfg.Store {
+	case "memory":
+		store = NewMemory()
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown store %q", cfg.Store)
+	}
+
+	return &Limits{
+		Store:  store,
+		Auth:   Limit{Requests: cfg.Auth.Requests, Per: time.Duration(cfg.Auth.Per)},
+		Writes: Limit{Requests: cfg.Writes.Requests, Per: time.Duration(cfg.Writes.Per)},
+		Lockout: NewLockout(store, LockoutPolicy{
+			Threshold: cfg.Lockout.Threshold,
+			Base:      time.Duration(cfg.Lockout.Base),
+			Max:       time.Duration(cfg.Lockout.Max),
+			Window:    time.Duration(cfg.Lockout.Window),
+		}),
+	}, nil
+}
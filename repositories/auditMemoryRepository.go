@@ -0,0 +1,72 @@
+package repositories
+
+import "final-project-golang/models"
+
+type auditMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewAuditMemoryRepository(store *MemoryStore) AuditRepository {
+	return &auditMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *auditMemoryRepository) Create(entry *models.AuditEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entry.Id = r.store.nextId("audit_entries")
+	entry.CreatedAt = timestamp()
+	if entry.Changes == "" {
+		entry.Changes = "{}"
+	}
+	stored := *entry
+	stored.Actor = nil
+	r.store.auditEntries[entry.Id] = stored
+
+	return nil
+}
+
+func (r *auditMemoryRepository) List(query ListQuery, filter AuditFilter) ([]models.AuditEntry, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entries := make([]models.AuditEntry, 0, len(r.store.auditEntries))
+	for _, entry := range r.store.auditEntries {
+		if filter.ResourceType != "" && entry.ResourceType != filter.ResourceType {
+			continue
+		}
+		if filter.Action != "" && entry.Action != filter.Action {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	page, total := listRecords(entries, query, auditField)
+	for i := range page {
+		if page[i].ActorId != nil {
+			page[i].Actor = r.store.userRef(*page[i].ActorId)
+		}
+	}
+
+	return page, total, nil
+}
+
+func auditField(entry models.AuditEntry, field string) interface{} {
+	switch field {
+	case "id":
+		return entry.Id
+	case "actor_id":
+		if entry.ActorId == nil {
+			return uint(0)
+		}
+		return *entry.ActorId
+	case "resource_id":
+		return entry.ResourceId
+	case "created_at":
+		return entry.CreatedAt
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditFilter narrows an audit listing down to one kind of resource or
+// action. Empty fields match everything.
+type AuditFilter struct {
+	ResourceType string
+	Action       string
+}
+
+type AuditRepository interface {
+	Create(entry *models.AuditEntry) error
+	List(query ListQuery, filter AuditFilter) ([]models.AuditEntry, int64, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+func (r *auditRepository) Create(entry *models.AuditEntry) error {
+	return translateError(r.db.Create(entry).Error)
+}
+
+func (r *auditRepository) List(query ListQuery, filter AuditFilter) ([]models.AuditEntry, int64, error) {
+	var entries []models.AuditEntry
+
+	db := r.db.Preload("Actor")
+	if filter.ResourceType != "" {
+		db = db.Where("audit_entries.resource_type = ?", filter.ResourceType)
+	}
+	if filter.Action != "" {
+		db = db.Where("audit_entries.action = ?", filter.Action)
+	}
+
+	total, err := list(db, &models.AuditEntry{}, &entries, query, "audit_entries")
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,313 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type commentMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewCommentMemoryRepository(store *MemoryStore) CommentRepository {
+	return &commentMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *commentMemoryRepository) Create(comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := comment.BeforeCreate(nil); err != nil {
+		return translateError(err)
+	}
+	if _, ok := r.store.photos[comment.PhotoId]; !ok {
+		return ErrPhotoNotFound
+	}
+	if comment.ParentId != nil {
+		if _, ok := r.store.comments[*comment.ParentId]; !ok {
+			return ErrParentCommentNotFound
+		}
+	}
+
+	comment.Id = r.store.nextId("comments")
+	comment.CreatedAt = timestamp()
+	comment.UpdatedAt = comment.CreatedAt
+	r.store.comments[comment.Id] = stripComment(*comment)
+
+	return nil
+}
+
+func (r *commentMemoryRepository) FindById(id uint) (*models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comment, ok := r.store.comments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	r.attach(&comment)
+
+	return &comment, nil
+}
+
+func (r *commentMemoryRepository) List(viewerId uint, query ListQuery) ([]models.Comment, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comments := make([]models.Comment, 0, len(r.store.comments))
+	for _, comment := range r.store.comments {
+		photo, ok := r.store.photos[comment.PhotoId]
+		if ok && r.store.canSee(viewerId, photo.UserId) {
+			comments = append(comments, comment)
+		}
+	}
+
+	page, total := listRecords(comments, query, commentField)
+	for i := range page {
+		r.attach(&page[i])
+	}
+
+	return page, total, nil
+}
+
+func (r *commentMemoryRepository) Update(comment *models.Comment, changes models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.comments[comment.Id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if changes.Message != "" {
+		stored.Message = changes.Message
+	}
+
+	if err := stored.BeforeUpdate(nil); err != nil {
+		return translateError(err)
+	}
+
+	stored.UpdatedAt = timestamp()
+	r.store.comments[comment.Id] = stored
+	r.attach(&stored)
+	*comment = stored
+
+	return nil
+}
+
+func (r *commentMemoryRepository) Delete(comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.comments[comment.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	at := deletedAt(time.Now())
+	r.store.trashComment(stored, at)
+	comment.DeletedAt = at
+
+	return nil
+}
+
+func (r *commentMemoryRepository) FindDeleted(id uint) (*models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comment, ok := r.store.deletedComments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &comment, nil
+}
+
+func (r *commentMemoryRepository) Restore(comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.deletedComments[comment.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	r.store.restoreComment(stored)
+	comment.DeletedAt = gorm.DeletedAt{}
+
+	return nil
+}
+
+func (r *commentMemoryRepository) Purge(comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, live := r.store.comments[comment.Id]
+	_, deleted := r.store.deletedComments[comment.Id]
+	if !live && !deleted {
+		return ErrNotFound
+	}
+	r.store.purgeComment(comment.Id)
+
+	return nil
+}
+
+func (r *commentMemoryRepository) PurgeDeleted(before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	replied := make(map[uint]bool)
+	for _, comment := range r.store.comments {
+		if comment.ParentId != nil {
+			replied[*comment.ParentId] = true
+		}
+	}
+
+	var total int64
+	for id, comment := range r.store.deletedComments {
+		if comment.DeletedAt.Time.Before(before) && !replied[id] {
+			r.store.purgeComment(id)
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (r *commentMemoryRepository) CountByUser(userId uint) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var total int64
+	for _, comment := range r.store.comments {
+		if comment.UserId == userId {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (r *commentMemoryRepository) ListRoots(photoId uint, query ListQuery) ([]models.Comment, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comments := make([]models.Comment, 0)
+	for _, comment := range r.store.comments {
+		if comment.PhotoId == photoId && comment.ParentId == nil {
+			comments = append(comments, comment)
+		}
+	}
+
+	page, total := listRecords(comments, query, commentField)
+	for i := range page {
+		page[i].User = r.store.userRef(page[i].UserId)
+	}
+
+	return page, total, nil
+}
+
+func (r *commentMemoryRepository) ListDescendants(ids []uint) ([]models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	parents := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		parents[id] = true
+	}
+
+	comments := make([]models.Comment, 0)
+	for found := true; found; {
+		found = false
+		for _, comment := range r.store.comments {
+			if comment.ParentId != nil && parents[*comment.ParentId] && !parents[comment.Id] {
+				parents[comment.Id] = true
+				comment.User = r.store.userRef(comment.UserId)
+				comments = append(comments, comment)
+				found = true
+			}
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		if result := compareValues(comments[i].CreatedAt, comments[j].CreatedAt); result != 0 {
+			return result < 0
+		}
+		return comments[i].Id < comments[j].Id
+	})
+
+	return comments, nil
+}
+
+func (r *commentMemoryRepository) CountReplies(id uint) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var total int64
+	for _, comment := range r.store.comments {
+		if comment.ParentId != nil && *comment.ParentId == id {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (r *commentMemoryRepository) Tombstone(comment *models.Comment, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.comments[comment.Id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	stored.Message = models.CommentDeletedMessage
+	stored.RemovedAt = &at
+	stored.UpdatedAt = &at
+	r.store.comments[comment.Id] = stored
+
+	comment.Message = stored.Message
+	comment.RemovedAt = stored.RemovedAt
+	comment.UpdatedAt = stored.UpdatedAt
+
+	return nil
+}
+
+func (r *commentMemoryRepository) attach(comment *models.Comment) {
+	comment.User = r.store.userRef(comment.UserId)
+	comment.Photo = r.store.photoRef(comment.PhotoId)
+}
+
+func stripComment(comment models.Comment) models.Comment {
+	comment.User = nil
+	comment.Photo = nil
+	comment.Replies = nil
+
+	return comment
+}
+
+func commentField(comment models.Comment, field string) interface{} {
+	switch field {
+	case "id":
+		return comment.Id
+	case "user_id":
+		return comment.UserId
+	case "photo_id":
+		return comment.PhotoId
+	case "parent_id":
+		if comment.ParentId == nil {
+			return uint(0)
+		}
+		return *comment.ParentId
+	case "created_at":
+		return comment.CreatedAt
+	case "updated_at":
+		return comment.UpdatedAt
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CommentRepository interface {
+	Create(comment *models.Comment) error
+	FindById(id uint) (*models.Comment, error)
+	// List leaves out comments on the photos of users viewerId may not see.
+	List(viewerId uint, query ListQuery) ([]models.Comment, int64, error)
+	Update(comment *models.Comment, changes models.Comment) error
+	// Delete hides the comment until Restore or a purge.
+	Delete(comment *models.Comment) error
+	FindDeleted(id uint) (*models.Comment, error)
+	Restore(comment *models.Comment) error
+	Purge(comment *models.Comment) error
+	// PurgeDeleted hard deletes the comments deleted before the given time.
+	PurgeDeleted(before time.Time) (int64, error)
+	CountByUser(userId uint) (int64, error)
+	// ListRoots pages through the comments of a photo that are not replies.
+	ListRoots(photoId uint, query ListQuery) ([]models.Comment, int64, error)
+	// ListDescendants returns every reply below the given comments, oldest
+	// first.
+	ListDescendants(ids []uint) ([]models.Comment, error)
+	CountReplies(id uint) (int64, error)
+	Tombstone(comment *models.Comment, at time.Time) error
+}
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{
+		db: db,
+	}
+}
+
+func (r *commentRepository) Create(comment *models.Comment) error {
+	return translateError(r.db.Create(comment).Error)
+}
+
+func (r *commentRepository) FindById(id uint) (*models.Comment, error) {
+	var comment models.Comment
+
+	err := r.db.Preload("User").Preload("Photo").First(&comment, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &comment, nil
+}
+
+func (r *commentRepository) List(viewerId uint, query ListQuery) ([]models.Comment, int64, error) {
+	var comments []models.Comment
+
+	photos := r.db.Model(&models.Photo{}).Select("id").Where("user_id IN (?)", visibleOwners(r.db, viewerId))
+	db := r.db.Preload("User").Preload("Photo").Where("comments.photo_id IN (?)", photos)
+	total, err := list(db, &models.Comment{}, &comments, query, "comments")
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return comments, total, nil
+}
+
+func (r *commentRepository) Update(comment *models.Comment, changes models.Comment) error {
+	return translateError(r.db.Model(comment).Updates(changes).Error)
+}
+
+func (r *commentRepository) Delete(comment *models.Comment) error {
+	return translateError(r.db.Delete(comment).Error)
+}
+
+func (r *commentRepository) CountByUser(userId uint) (int64, error) {
+	var total int64
+
+	err := r.db.Model(&models.Comment{}).Where("user_id = ?", userId).Count(&total).Error
+
+	return total, translateError(err)
+}
+
+func (r *commentRepository) ListRoots(photoId uint, query ListQuery) ([]models.Comment, int64, error) {
+	var comments []models.Comment
+
+	db := r.db.Preload("User").Where("comments.photo_id = ? AND comments.parent_id IS NULL", photoId)
+
+	total, err := list(db, &models.Comment{}, &comments, query, "comments")
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return comments, total, nil
+}
+
+func (r *commentRepository) ListDescendants(ids []uint) ([]models.Comment, error) {
+	comments := []models.Comment{}
+	if len(ids) == 0 {
+		return comments, nil
+	}
+
+	var replyIds []uint
+
+	err := r.db.Raw(`WITH RECURSIVE thread AS (
+		SELECT id FROM comments WHERE parent_id IN ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT comments.id FROM comments JOIN thread ON comments.parent_id = thread.id WHERE comments.deleted_at IS NULL
+	) SELECT id FROM thread`, ids).Scan(&replyIds).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if len(replyIds) == 0 {
+		return comments, nil
+	}
+
+	err = r.db.Preload("User").Where("id IN ?", replyIds).Order("created_at ASC, id ASC").Find(&comments).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return comments, nil
+}
+
+func (r *commentRepository) CountReplies(id uint) (int64, error) {
+	var total int64
+
+	err := r.db.Model(&models.Comment{}).Where("parent_id = ?", id).Count(&total).Error
+
+	return total, translateError(err)
+}
+
+// Tombstone blanks the comment in place, bypassing the model hooks.
+func (r *commentRepository) Tombstone(comment *models.Comment, at time.Time) error {
+	err := r.db.Model(comment).UpdateColumns(map[string]interface{}{
+		"message":    models.CommentDeletedMessage,
+		"removed_at": at,
+		"updated_at": at,
+	}).Error
+	if err != nil {
+		return translateError(err)
+	}
+
+	comment.Message = models.CommentDeletedMessage
+	comment.RemovedAt = &at
+	comment.UpdatedAt = &at
+
+	return nil
+}
+
+func (r *commentRepository) FindDeleted(id uint) (*models.Comment, error) {
+	var comment models.Comment
+
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&comment, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &comment, nil
+}
+
+func (r *commentRepository) Restore(comment *models.Comment) error {
+	err := r.db.Unscoped().Model(&models.Comment{}).Where("id = ?", comment.Id).UpdateColumn("deleted_at", nil).Error
+	if err != nil {
+		return translateError(err)
+	}
+	comment.DeletedAt = gorm.DeletedAt{}
+
+	return nil
+}
+
+func (r *commentRepository) Purge(comment *models.Comment) error {
+	return translateError(r.db.Unscoped().Delete(comment).Error)
+}
+
+// PurgeDeleted keeps deleted comments that still have visible replies, which
+// the reply foreign key would otherwise take along.
+func (r *commentRepository) PurgeDeleted(before time.Time) (int64, error) {
+	replies := r.db.Model(&models.Comment{}).Select("parent_id").Where("parent_id IS NOT NULL")
+	result := r.db.Unscoped().Where("deleted_at < ? AND id NOT IN (?)", before, replies).Delete(&models.Comment{})
+
+	return result.RowsAffected, translateError(result.Error)
+}
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+)
+
+type followMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewFollowMemoryRepository(store *MemoryStore) FollowRepository {
+	return &followMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *followMemoryRepository) Create(follow *models.Follow) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.find(follow.FollowerId, follow.FollowingId) != nil {
+		return ErrDuplicateFollow
+	}
+	if r.store.userRef(follow.FollowerId) == nil || r.store.userRef(follow.FollowingId) == nil {
+		return ErrNotFound
+	}
+
+	follow.Id = r.store.nextId("follows")
+	follow.CreatedAt = timestamp()
+	r.store.follows[follow.Id] = stripFollow(*follow)
+
+	return nil
+}
+
+func (r *followMemoryRepository) Find(followerId, followingId uint) (*models.Follow, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	follow := r.find(followerId, followingId)
+	if follow == nil {
+		return nil, ErrNotFound
+	}
+
+	return follow, nil
+}
+
+func (r *followMemoryRepository) Accept(follow *models.Follow, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.follows[follow.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.AcceptedAt = &at
+	r.store.follows[follow.Id] = stored
+	follow.AcceptedAt = &at
+
+	return nil
+}
+
+func (r *followMemoryRepository) AcceptPending(followingId uint, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, follow := range r.store.follows {
+		if follow.FollowingId == followingId && follow.AcceptedAt == nil {
+			accepted := at
+			follow.AcceptedAt = &accepted
+			r.store.follows[id] = follow
+		}
+	}
+
+	return nil
+}
+
+func (r *followMemoryRepository) Delete(follow *models.Follow) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.follows[follow.Id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.follows, follow.Id)
+
+	return nil
+}
+
+func (r *followMemoryRepository) ListFollowers(userId uint, pending bool, query ListQuery) ([]models.Follow, int64, error) {
+	return r.list(query, func(follow models.Follow) bool {
+		return follow.FollowingId == userId && follow.Accepted() != pending && r.store.userRef(follow.FollowerId) != nil
+	})
+}
+
+func (r *followMemoryRepository) ListFollowing(userId uint, query ListQuery) ([]models.Follow, int64, error) {
+	return r.list(query, func(follow models.Follow) bool {
+		return follow.FollowerId == userId && follow.Accepted() && r.store.userRef(follow.FollowingId) != nil
+	})
+}
+
+func (r *followMemoryRepository) CountFollowers(userId uint) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var total int64
+	for _, follow := range r.store.follows {
+		if follow.FollowingId == userId && follow.Accepted() && r.store.userRef(follow.FollowerId) != nil {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (r *followMemoryRepository) CountFollowing(userId uint) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var total int64
+	for _, follow := range r.store.follows {
+		if follow.FollowerId == userId && follow.Accepted() && r.store.userRef(follow.FollowingId) != nil {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (r *followMemoryRepository) list(query ListQuery, match func(models.Follow) bool) ([]models.Follow, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	follows := make([]models.Follow, 0)
+	for _, follow := range r.store.follows {
+		if match(follow) {
+			follows = append(follows, follow)
+		}
+	}
+
+	page, total := listRecords(follows, query, followField)
+	for i := range page {
+		page[i].Follower = r.store.userRef(page[i].FollowerId)
+		page[i].Following = r.store.userRef(page[i].FollowingId)
+	}
+
+	return page, total, nil
+}
+
+func (r *followMemoryRepository) find(followerId, followingId uint) *models.Follow {
+	for _, follow := range r.store.follows {
+		if follow.FollowerId == followerId && follow.FollowingId == followingId {
+			return &follow
+		}
+	}
+
+	return nil
+}
+
+func stripFollow(follow models.Follow) models.Follow {
+	follow.Follower = nil
+	follow.Following = nil
+
+	return follow
+}
+
+func followField(follow models.Follow, field string) interface{} {
+	switch field {
+	case "id":
+		return follow.Id
+	case "follower_id":
+		return follow.FollowerId
+	case "following_id":
+		return follow.FollowingId
+	case "created_at":
+		return follow.CreatedAt
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FollowRepository interface {
+	Create(follow *models.Follow) error
+	Find(followerId, followingId uint) (*models.Follow, error)
+	Accept(follow *models.Follow, at time.Time) error
+	AcceptPending(followingId uint, at time.Time) error
+	Delete(follow *models.Follow) error
+	// ListFollowers returns the accepted followers of a user, or the
+	// pending requests when pending is true.
+	ListFollowers(userId uint, pending bool, query ListQuery) ([]models.Follow, int64, error)
+	ListFollowing(userId uint, query ListQuery) ([]models.Follow, int64, error)
+	CountFollowers(userId uint) (int64, error)
+	CountFollowing(userId uint) (int64, error)
+}
+
+type followRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowRepository(db *gorm.DB) FollowRepository {
+	return &followRepository{
+		db: db,
+	}
+}
+
+func (r *followRepository) Create(follow *models.Follow) error {
+	return translateError(r.db.Create(follow).Error)
+}
+
+func (r *followRepository) Find(followerId, followingId uint) (*models.Follow, error) {
+	var follow models.Follow
+
+	err := r.db.First(&follow, "follower_id = ? AND following_id = ?", followerId, followingId).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &follow, nil
+}
+
+func (r *followRepository) Accept(follow *models.Follow, at time.Time) error {
+	err := r.db.Model(follow).UpdateColumn("accepted_at", at).Error
+	if err != nil {
+		return translateError(err)
+	}
+	follow.AcceptedAt = &at
+
+	return nil
+}
+
+func (r *followRepository) AcceptPending(followingId uint, at time.Time) error {
+	err := r.db.Model(&models.Follow{}).
+		Where("following_id = ? AND accepted_at IS NULL", followingId).
+		UpdateColumn("accepted_at", at).Error
+
+	return translateError(err)
+}
+
+func (r *followRepository) Delete(follow *models.Follow) error {
+	return translateError(r.db.Delete(follow).Error)
+}
+
+func (r *followRepository) ListFollowers(userId uint, pending bool, query ListQuery) ([]models.Follow, int64, error) {
+	db := r.db.Where("follows.following_id = ? AND follows.follower_id IN ("+liveUsers+")", userId)
+	if pending {
+		db = db.Where("follows.accepted_at IS NULL")
+	} else {
+		db = db.Where("follows.accepted_at IS NOT NULL")
+	}
+
+	return r.list(db.Preload("Follower"), query)
+}
+
+func (r *followRepository) ListFollowing(userId uint, query ListQuery) ([]models.Follow, int64, error) {
+	db := r.db.Where("follows.follower_id = ? AND follows.accepted_at IS NOT NULL AND follows.following_id IN ("+liveUsers+")", userId)
+
+	return r.list(db.Preload("Following"), query)
+}
+
+func (r *followRepository) CountFollowers(userId uint) (int64, error) {
+	var total int64
+
+	err := r.db.Model(&models.Follow{}).Where("following_id = ? AND accepted_at IS NOT NULL AND follower_id IN ("+liveUsers+")", userId).Count(&total).Error
+
+	return total, translateError(err)
+}
+
+func (r *followRepository) CountFollowing(userId uint) (int64, error) {
+	var total int64
+
+	err := r.db.Model(&models.Follow{}).Where("follower_id = ? AND accepted_at IS NOT NULL AND following_id IN ("+liveUsers+")", userId).Count(&total).Error
+
+	return total, translateError(err)
+}
+
+func (r *followRepository) list(db *gorm.DB, query ListQuery) ([]models.Follow, int64, error) {
+	var follows []models.Follow
+
+	total, err := list(db, &models.Follow{}, &follows, query, "follows")
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return follows, total, nil
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"sort"
+	"time"
+)
+
+type identityMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewIdentityMemoryRepository(store *MemoryStore) IdentityRepository {
+	return &identityMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *identityMemoryRepository) Create(identity *models.Identity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return ErrDuplicateIdentity
+		}
+	}
+
+	identity.Id = r.store.nextId("identities")
+	identity.CreatedAt = timestamp()
+	stored := *identity
+	stored.User = nil
+	r.store.identities[identity.Id] = stored
+
+	return nil
+}
+
+func (r *identityMemoryRepository) FindBySubject(provider, subject string) (*models.Identity, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, identity := range r.store.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			identity.User = r.store.userRef(identity.UserId)
+			return &identity, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *identityMemoryRepository) ListByUser(userId uint) ([]models.Identity, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	identities := []models.Identity{}
+	for _, identity := range r.store.identities {
+		if identity.UserId == userId {
+			identities = append(identities, identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].Id < identities[j].Id
+	})
+
+	return identities, nil
+}
+
+func (r *identityMemoryRepository) Delete(userId, id uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	identity, ok := r.store.identities[id]
+	if !ok || identity.UserId != userId {
+		return ErrNotFound
+	}
+	delete(r.store.identities, id)
+
+	return nil
+}
+
+func (r *identityMemoryRepository) CreateState(state *models.LoginState) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	state.Id = r.store.nextId("login_states")
+	state.CreatedAt = timestamp()
+	r.store.loginStates[state.Id] = *state
+
+	return nil
+}
+
+func (r *identityMemoryRepository) UseState(hash string, at time.Time) (*models.LoginState, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, state := range r.store.loginStates {
+		if state.StateHash == hash && state.ExpiresAt.After(at) {
+			delete(r.store.loginStates, id)
+			return &state, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *identityMemoryRepository) PurgeExpiredStates(now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, state := range r.store.loginStates {
+		if state.ExpiresAt.Before(now) {
+			delete(r.store.loginStates, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	Create(identity *models.Identity) error
+	FindBySubject(provider, subject string) (*models.Identity, error)
+	ListByUser(userId uint) ([]models.Identity, error)
+	// Delete returns ErrNotFound unless the identity belongs to the user.
+	Delete(userId, id uint) error
+	CreateState(state *models.LoginState) error
+	// UseState deletes the state and returns it, or ErrNotFound when it is
+	// unknown, was used already or has expired.
+	UseState(hash string, at time.Time) (*models.LoginState, error)
+	PurgeExpiredStates(now time.Time) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{
+		db: db,
+	}
+}
+
+func (r *identityRepository) Create(identity *models.Identity) error {
+	return translateError(r.db.Create(identity).Error)
+}
+
+func (r *identityRepository) FindBySubject(provider, subject string) (*models.Identity, error) {
+	var identity models.Identity
+
+	err := r.db.Preload("User").First(&identity, "provider = ? AND subject = ?", provider, subject).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &identity, nil
+}
+
+func (r *identityRepository) ListByUser(userId uint) ([]models.Identity, error) {
+	identities := []models.Identity{}
+
+	err := r.db.Where("user_id = ?", userId).Order("id ASC").Find(&identities).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return identities, nil
+}
+
+func (r *identityRepository) Delete(userId, id uint) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userId).Delete(&models.Identity{})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *identityRepository) CreateState(state *models.LoginState) error {
+	return translateError(r.db.Create(state).Error)
+}
+
+func (r *identityRepository) UseState(hash string, at time.Time) (*models.LoginState, error) {
+	var state models.LoginState
+
+	err := r.db.First(&state, "state_hash = ? AND expires_at > ?", hash, at).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	// Only the request that deletes the row may use it.
+	result := r.db.Where("id = ?", state.Id).Delete(&models.LoginState{})
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &state, nil
+}
+
+func (r *identityRepository) PurgeExpiredStates(now time.Time) error {
+	return translateError(r.db.Where("expires_at < ?", now).Delete(&models.LoginState{}).Error)
+}
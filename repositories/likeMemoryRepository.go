@@ -0,0 +1,131 @@
+package repositories
+
+import "final-project-golang/models"
+
+type likeMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewLikeMemoryRepository(store *MemoryStore) LikeRepository {
+	return &likeMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *likeMemoryRepository) Create(like *models.Like) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	target, targetId := likeTarget(*like)
+	for _, existing := range r.store.likes {
+		existingTarget, existingId := likeTarget(existing)
+		if existing.UserId == like.UserId && existingTarget == target && existingId == targetId {
+			return ErrDuplicateLike
+		}
+	}
+
+	if _, ok := r.store.users[like.UserId]; !ok {
+		return ErrNotFound
+	}
+	switch target {
+	case LikePhoto:
+		if _, ok := r.store.photos[targetId]; !ok {
+			return ErrPhotoNotFound
+		}
+	case LikeComment:
+		if _, ok := r.store.comments[targetId]; !ok {
+			return ErrNotFound
+		}
+	}
+
+	like.Id = r.store.nextId("likes")
+	like.CreatedAt = timestamp()
+	stored := *like
+	stored.User = nil
+	r.store.likes[like.Id] = stored
+
+	return nil
+}
+
+func (r *likeMemoryRepository) Delete(userId uint, target LikeTarget, targetId uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, like := range r.store.likes {
+		likeTarget, likeTargetId := likeTarget(like)
+		if like.UserId == userId && likeTarget == target && likeTargetId == targetId {
+			delete(r.store.likes, id)
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (r *likeMemoryRepository) ListByTarget(target LikeTarget, targetId uint, query ListQuery) ([]models.Like, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	likes := make([]models.Like, 0)
+	for _, like := range r.store.likes {
+		likeTarget, likeTargetId := likeTarget(like)
+		if likeTarget == target && likeTargetId == targetId && r.store.userRef(like.UserId) != nil {
+			likes = append(likes, like)
+		}
+	}
+
+	page, total := listRecords(likes, query, likeField)
+	for i := range page {
+		page[i].User = r.store.userRef(page[i].UserId)
+	}
+
+	return page, total, nil
+}
+
+func (r *likeMemoryRepository) Count(target LikeTarget, targetIds []uint, userId uint) (map[uint]LikeCount, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	wanted := make(map[uint]bool, len(targetIds))
+	for _, id := range targetIds {
+		wanted[id] = true
+	}
+
+	counts := make(map[uint]LikeCount)
+	for _, like := range r.store.likes {
+		likeTarget, likeTargetId := likeTarget(like)
+		if likeTarget != target || !wanted[likeTargetId] || r.store.userRef(like.UserId) == nil {
+			continue
+		}
+		count := counts[likeTargetId]
+		count.Total++
+		count.LikedByMe = count.LikedByMe || like.UserId == userId
+		counts[likeTargetId] = count
+	}
+
+	return counts, nil
+}
+
+func likeTarget(like models.Like) (LikeTarget, uint) {
+	if like.PhotoId != nil {
+		return LikePhoto, *like.PhotoId
+	}
+	if like.CommentId != nil {
+		return LikeComment, *like.CommentId
+	}
+
+	return "", 0
+}
+
+func likeField(like models.Like, field string) interface{} {
+	switch field {
+	case "id":
+		return like.Id
+	case "user_id":
+		return like.UserId
+	case "created_at":
+		return like.CreatedAt
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+
+	"gorm.io/gorm"
+)
+
+// LikeTarget names the column a like points at.
+type LikeTarget string
+
+const (
+	LikePhoto   LikeTarget = "photo_id"
+	LikeComment LikeTarget = "comment_id"
+)
+
+type LikeCount struct {
+	Total     int64
+	LikedByMe bool
+}
+
+type LikeRepository interface {
+	Create(like *models.Like) error
+	Delete(userId uint, target LikeTarget, targetId uint) error
+	ListByTarget(target LikeTarget, targetId uint, query ListQuery) ([]models.Like, int64, error)
+	// Count returns the like count of every target id that has likes and
+	// whether userId is among the likers.
+	Count(target LikeTarget, targetIds []uint, userId uint) (map[uint]LikeCount, error)
+}
+
+type likeRepository struct {
+	db *gorm.DB
+}
+
+func NewLikeRepository(db *gorm.DB) LikeRepository {
+	return &likeRepository{
+		db: db,
+	}
+}
+
+func (r *likeRepository) Create(like *models.Like) error {
+	return translateError(r.db.Create(like).Error)
+}
+
+func (r *likeRepository) Delete(userId uint, target LikeTarget, targetId uint) error {
+	result := r.db.Where("user_id = ? AND "+string(target)+" = ?", userId, targetId).Delete(&models.Like{})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *likeRepository) ListByTarget(target LikeTarget, targetId uint, query ListQuery) ([]models.Like, int64, error) {
+	var likes []models.Like
+
+	db := r.db.Preload("User").Where("likes."+string(target)+" = ? AND likes.user_id IN ("+liveUsers+")", targetId)
+
+	total, err := list(db, &models.Like{}, &likes, query, "likes")
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return likes, total, nil
+}
+
+func (r *likeRepository) Count(target LikeTarget, targetIds []uint, userId uint) (map[uint]LikeCount, error) {
+	counts := make(map[uint]LikeCount)
+	if len(targetIds) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		TargetId  uint
+		Total     int64
+		LikedByMe bool
+	}
+
+	err := r.db.Model(&models.Like{}).
+		Select(string(target)+" AS target_id, count(*) AS total, bool_or(user_id = ?) AS liked_by_me", userId).
+		Where(string(target)+" IN ? AND user_id IN ("+liveUsers+")", targetIds).
+		Group(string(target)).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	for _, row := range rows {
+		counts[row.TargetId] = LikeCount{Total: row.Total, LikedByMe: row.LikedByMe}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,417 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryStore holds the tables used by the in-memory repositories. Sharing
+// one store between them lets relations such as Photo.User resolve the same
+// way they do with Postgres.
+type MemoryStore struct {
+	mu            sync.RWMutex
+	lastIds       map[string]uint
+	users         map[uint]models.User
+	photos        map[uint]models.Photo
+	comments      map[uint]models.Comment
+	socials       map[uint]models.Social
+	refreshTokens map[uint]models.RefreshToken
+	revokedTokens map[string]models.RevokedToken
+	follows       map[uint]models.Follow
+	likes         map[uint]models.Like
+
+	passwordResets      map[uint]models.PasswordReset
+	recoveryCodes       map[uint]models.RecoveryCode
+	twoFactorChallenges map[uint]models.TwoFactorChallenge
+	identities          map[uint]models.Identity
+	loginStates         map[uint]models.LoginState
+	moderationActions   map[uint]models.ModerationAction
+	auditEntries        map[uint]models.AuditEntry
+
+	// Soft deleted rows are moved out of the maps above so every read skips
+	// them, the way the deleted_at scope does with gorm.
+	deletedUsers    map[uint]models.User
+	deletedPhotos   map[uint]models.Photo
+	deletedComments map[uint]models.Comment
+	deletedSocials  map[uint]models.Social
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		lastIds:       make(map[string]uint),
+		users:         make(map[uint]models.User),
+		photos:        make(map[uint]models.Photo),
+		comments:      make(map[uint]models.Comment),
+		socials:       make(map[uint]models.Social),
+		refreshTokens: make(map[uint]models.RefreshToken),
+		revokedTokens: make(map[string]models.RevokedToken),
+		follows:       make(map[uint]models.Follow),
+		likes:         make(map[uint]models.Like),
+
+		passwordResets:      make(map[uint]models.PasswordReset),
+		recoveryCodes:       make(map[uint]models.RecoveryCode),
+		twoFactorChallenges: make(map[uint]models.TwoFactorChallenge),
+		identities:          make(map[uint]models.Identity),
+		loginStates:         make(map[uint]models.LoginState),
+		moderationActions:   make(map[uint]models.ModerationAction),
+		auditEntries:        make(map[uint]models.AuditEntry),
+
+		deletedUsers:    make(map[uint]models.User),
+		deletedPhotos:   make(map[uint]models.Photo),
+		deletedComments: make(map[uint]models.Comment),
+		deletedSocials:  make(map[uint]models.Social),
+	}
+}
+
+func (s *MemoryStore) nextId(table string) uint {
+	s.lastIds[table]++
+
+	return s.lastIds[table]
+}
+
+func (s *MemoryStore) userRef(id uint) *models.User {
+	user, ok := s.users[id]
+	if !ok {
+		return nil
+	}
+
+	return &user
+}
+
+func (s *MemoryStore) photoRef(id uint) *models.Photo {
+	photo, ok := s.photos[id]
+	if !ok {
+		return nil
+	}
+
+	return &photo
+}
+
+// canSee mirrors visibleOwners: viewerId sees what public accounts, itself
+// and the private accounts it follows share.
+func (s *MemoryStore) canSee(viewerId, userId uint) bool {
+	if viewerId == userId {
+		return true
+	}
+	if user, ok := s.users[userId]; ok && !user.Private {
+		return true
+	}
+	for _, follow := range s.follows {
+		if follow.FollowerId == viewerId && follow.FollowingId == userId && follow.Accepted() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deleteLikes mirrors the ON DELETE CASCADE constraints of the likes table.
+func (s *MemoryStore) deleteLikes(match func(like models.Like) bool) {
+	for id, like := range s.likes {
+		if match(like) {
+			delete(s.likes, id)
+		}
+	}
+}
+
+func (s *MemoryStore) trashPhoto(photo models.Photo, at gorm.DeletedAt) {
+	for _, comment := range s.comments {
+		if comment.PhotoId == photo.Id {
+			s.trashComment(comment, at)
+		}
+	}
+	photo.DeletedAt = at
+	delete(s.photos, photo.Id)
+	s.deletedPhotos[photo.Id] = photo
+}
+
+// repliedByOthers mirrors the ancestors query of userRepository.Delete: the
+// ids of the visible comments that have a visible reply by someone other
+// than userId somewhere below them.
+func (s *MemoryStore) repliedByOthers(userId uint) map[uint]bool {
+	replied := make(map[uint]bool)
+	for _, comment := range s.comments {
+		if comment.UserId == userId {
+			continue
+		}
+		for parentId := comment.ParentId; parentId != nil && !replied[*parentId]; {
+			replied[*parentId] = true
+			parent, ok := s.comments[*parentId]
+			if !ok {
+				break
+			}
+			parentId = parent.ParentId
+		}
+	}
+
+	return replied
+}
+
+func (s *MemoryStore) trashComment(comment models.Comment, at gorm.DeletedAt) {
+	comment.DeletedAt = at
+	delete(s.comments, comment.Id)
+	s.deletedComments[comment.Id] = comment
+}
+
+func (s *MemoryStore) restoreComment(comment models.Comment) {
+	comment.DeletedAt = gorm.DeletedAt{}
+	delete(s.deletedComments, comment.Id)
+	s.comments[comment.Id] = comment
+}
+
+// purgeUser, purgePhoto and purgeComment mirror the ON DELETE CASCADE
+// constraints of a hard delete, reaching hidden rows as well.
+func (s *MemoryStore) purgeUser(id uint) {
+	delete(s.users, id)
+	delete(s.deletedUsers, id)
+
+	for photoId, photo := range s.allPhotos() {
+		if photo.UserId == id {
+			s.purgePhoto(photoId)
+		}
+	}
+	// The comments that survive the purge are tombstones; like the foreign
+	// key they lose their author.
+	for commentId, comment := range s.comments {
+		if comment.UserId == id {
+			comment.UserId = 0
+			s.comments[commentId] = comment
+		}
+	}
+	for commentId, comment := range s.deletedComments {
+		if comment.UserId == id {
+			comment.UserId = 0
+			s.deletedComments[commentId] = comment
+		}
+	}
+	for socialId, social := range s.socials {
+		if social.UserId == id {
+			delete(s.socials, socialId)
+		}
+	}
+	for socialId, social := range s.deletedSocials {
+		if social.UserId == id {
+			delete(s.deletedSocials, socialId)
+		}
+	}
+	for tokenId, token := range s.refreshTokens {
+		if token.UserId == id {
+			delete(s.refreshTokens, tokenId)
+		}
+	}
+	for resetId, reset := range s.passwordResets {
+		if reset.UserId == id {
+			delete(s.passwordResets, resetId)
+		}
+	}
+	for codeId, code := range s.recoveryCodes {
+		if code.UserId == id {
+			delete(s.recoveryCodes, codeId)
+		}
+	}
+	for challengeId, challenge := range s.twoFactorChallenges {
+		if challenge.UserId == id {
+			delete(s.twoFactorChallenges, challengeId)
+		}
+	}
+	for identityId, identity := range s.identities {
+		if identity.UserId == id {
+			delete(s.identities, identityId)
+		}
+	}
+	s.deleteLikes(func(like models.Like) bool {
+		return like.UserId == id
+	})
+	for actionId, action := range s.moderationActions {
+		if action.ActorId != nil && *action.ActorId == id {
+			action.ActorId = nil
+			s.moderationActions[actionId] = action
+		}
+	}
+	for entryId, entry := range s.auditEntries {
+		if entry.ActorId != nil && *entry.ActorId == id {
+			entry.ActorId = nil
+			s.auditEntries[entryId] = entry
+		}
+	}
+	for followId, follow := range s.follows {
+		if follow.FollowerId == id || follow.FollowingId == id {
+			delete(s.follows, followId)
+		}
+	}
+}
+
+func (s *MemoryStore) purgePhoto(id uint) {
+	delete(s.photos, id)
+	delete(s.deletedPhotos, id)
+
+	for commentId, comment := range s.allComments() {
+		if comment.PhotoId == id {
+			s.purgeComment(commentId)
+		}
+	}
+	s.deleteLikes(func(like models.Like) bool {
+		return like.PhotoId != nil && *like.PhotoId == id
+	})
+}
+
+func (s *MemoryStore) purgeComment(id uint) {
+	delete(s.comments, id)
+	delete(s.deletedComments, id)
+	s.deleteLikes(func(like models.Like) bool {
+		return like.CommentId != nil && *like.CommentId == id
+	})
+
+	for replyId, reply := range s.allComments() {
+		if reply.ParentId != nil && *reply.ParentId == id {
+			s.purgeComment(replyId)
+		}
+	}
+}
+
+func (s *MemoryStore) allPhotos() map[uint]models.Photo {
+	photos := make(map[uint]models.Photo, len(s.photos)+len(s.deletedPhotos))
+	for id, photo := range s.photos {
+		photos[id] = photo
+	}
+	for id, photo := range s.deletedPhotos {
+		photos[id] = photo
+	}
+
+	return photos
+}
+
+func (s *MemoryStore) allComments() map[uint]models.Comment {
+	comments := make(map[uint]models.Comment, len(s.comments)+len(s.deletedComments))
+	for id, comment := range s.comments {
+		comments[id] = comment
+	}
+	for id, comment := range s.deletedComments {
+		comments[id] = comment
+	}
+
+	return comments
+}
+
+func deletedAt(at time.Time) gorm.DeletedAt {
+	return gorm.DeletedAt{Time: at, Valid: true}
+}
+
+func timestamp() *time.Time {
+	t := time.Now()
+
+	return &t
+}
+
+// fieldFunc returns the value of a column for filtering and sorting. Supported
+// value types are uint, string and *time.Time.
+type fieldFunc[T any] func(record T, field string) interface{}
+
+func listRecords[T any](records []T, query ListQuery, field fieldFunc[T]) ([]T, int64) {
+	filtered := make([]T, 0, len(records))
+	for _, record := range records {
+		if matchesFilter(record, query, field) {
+			filtered = append(filtered, record)
+		}
+	}
+
+	p := query.Pagination
+	less := func(a, b T) bool {
+		result := compareValues(field(a, p.SortField), field(b, p.SortField))
+		if result == 0 {
+			result = compareValues(field(a, "id"), field(b, "id"))
+		}
+		if p.SortDesc {
+			return result > 0
+		}
+		return result < 0
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return less(filtered[i], filtered[j])
+	})
+
+	total := int64(len(filtered))
+	start := 0
+
+	if p.After > 0 {
+		start = len(filtered)
+		for i, record := range records {
+			if field(record, "id").(uint) != p.After {
+				continue
+			}
+			anchor := records[i]
+			start = sort.Search(len(filtered), func(j int) bool {
+				return less(anchor, filtered[j])
+			})
+			break
+		}
+	} else {
+		start = (p.Page - 1) * p.Limit
+	}
+
+	if start >= len(filtered) {
+		return []T{}, total
+	}
+	end := start + p.Limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	return filtered[start:end], total
+}
+
+func matchesFilter[T any](record T, query ListQuery, field fieldFunc[T]) bool {
+	for name, value := range query.Filter.Fields {
+		if field(record, name) != value {
+			return false
+		}
+	}
+
+	createdAt, _ := field(record, "created_at").(*time.Time)
+	if query.Filter.CreatedFrom != nil && (createdAt == nil || createdAt.Before(*query.Filter.CreatedFrom)) {
+		return false
+	}
+	if query.Filter.CreatedTo != nil && (createdAt == nil || createdAt.After(*query.Filter.CreatedTo)) {
+		return false
+	}
+
+	return true
+}
+
+func compareValues(a, b interface{}) int {
+	switch left := a.(type) {
+	case uint:
+		right := b.(uint)
+		if left < right {
+			return -1
+		}
+		if left > right {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(left, b.(string))
+	case *time.Time:
+		right := b.(*time.Time)
+		switch {
+		case left == nil && right == nil:
+			return 0
+		case left == nil:
+			return -1
+		case right == nil:
+			return 1
+		case left.Before(*right):
+			return -1
+		case left.After(*right):
+			return 1
+		}
+		return 0
+	}
+
+	return 0
+}
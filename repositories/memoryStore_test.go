@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"errors"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"testing"
+)
+
+func newTestUser(t *testing.T, repos Repositories, name string) *models.User {
+	t.Helper()
+
+	user := &models.User{Username: name, Email: name + "@example.com", Password: "secret123", Age: 20}
+	if err := repos.Users.Create(user); err != nil {
+		t.Fatalf("create %s: %v", name, err)
+	}
+
+	return user
+}
+
+func newTestPhoto(t *testing.T, repos Repositories, userId uint, title string) *models.Photo {
+	t.Helper()
+
+	photo := &models.Photo{Title: title, PhotoUrl: "http://example.com/" + title + ".jpg", UserId: userId}
+	if err := repos.Photos.Create(photo); err != nil {
+		t.Fatalf("create photo %s: %v", title, err)
+	}
+
+	return photo
+}
+
+func TestMemoryUserUniqueness(t *testing.T) {
+	repos := NewMemoryRepositories(NewMemoryStore())
+	alice := newTestUser(t, repos, "alice")
+
+	err := repos.Users.Create(&models.User{Username: "alice", Email: "other@example.com", Password: "secret123", Age: 20})
+	if !errors.Is(err, ErrDuplicateUsername) {
+		t.Fatalf("duplicate username: got %v", err)
+	}
+
+	// A deleted user keeps their email until purged.
+	if err := repos.Users.Delete(alice); err != nil {
+		t.Fatal(err)
+	}
+	err = repos.Users.Create(&models.User{Username: "alice2", Email: "alice@example.com", Password: "secret123", Age: 20})
+	if !errors.Is(err, ErrDuplicateEmail) {
+		t.Fatalf("email of deleted user: got %v", err)
+	}
+}
+
+func TestMemoryPhotoSoftDelete(t *testing.T) {
+	repos := NewMemoryRepositories(NewMemoryStore())
+	alice := newTestUser(t, repos, "alice")
+	photo := newTestPhoto(t, repos, alice.Id, "sunset")
+
+	if err := repos.Photos.Delete(photo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Photos.FindById(photo.Id); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("FindById after delete: got %v", err)
+	}
+	if _, total, _ := repos.Photos.List(alice.Id, ListQuery{Pagination: helpers.Pagination{Page: 1, Limit: 10, SortField: "id"}}); total != 0 {
+		t.Fatalf("List after delete: total %d", total)
+	}
+	if _, err := repos.Photos.FindDeleted(photo.Id); err != nil {
+		t.Fatalf("FindDeleted: %v", err)
+	}
+
+	if err := repos.Photos.Restore(photo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Photos.FindById(photo.Id); err != nil {
+		t.Fatalf("FindById after restore: %v", err)
+	}
+}
+
+func TestMemoryPhotoKeysetPagination(t *testing.T) {
+	repos := NewMemoryRepositories(NewMemoryStore())
+	alice := newTestUser(t, repos, "alice")
+	for _, title := range []string{"a", "b", "c", "d"} {
+		newTestPhoto(t, repos, alice.Id, title)
+	}
+
+	photos, _, err := repos.Photos.List(alice.Id, ListQuery{Pagination: helpers.Pagination{Limit: 2, After: 2, SortField: "id"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(photos) != 2 || photos[0].Id != 3 || photos[1].Id != 4 {
+		t.Fatalf("photos after 2: %+v", photos)
+	}
+}
+
+func TestMemoryPhotoListHidesUnprocessed(t *testing.T) {
+	repos := NewMemoryRepositories(NewMemoryStore())
+	alice := newTestUser(t, repos, "alice")
+	bob := newTestUser(t, repos, "bob")
+	newTestPhoto(t, repos, alice.Id, "ready")
+	for _, status := range []string{models.PhotoProcessingPending, models.PhotoProcessingFailed} {
+		photo := &models.Photo{Title: status, PhotoUrl: "http://example.com/" + status + ".jpg", UserId: alice.Id, ProcessingStatus: status}
+		if err := repos.Photos.Create(photo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	query := ListQuery{Pagination: helpers.Pagination{Page: 1, Limit: 10, SortField: "id"}}
+
+	if _, total, _ := repos.Photos.List(alice.Id, query); total != 3 {
+		t.Fatalf("owner sees %d photos, want 3", total)
+	}
+	if photos, total, _ := repos.Photos.List(bob.Id, query); total != 1 || photos[0].Title != "ready" {
+		t.Fatalf("others see %+v, want only the ready photo", photos)
+	}
+}
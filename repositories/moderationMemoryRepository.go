@@ -0,0 +1,63 @@
+package repositories
+
+import "final-project-golang/models"
+
+type moderationMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewModerationMemoryRepository(store *MemoryStore) ModerationRepository {
+	return &moderationMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *moderationMemoryRepository) Create(action *models.ModerationAction) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	action.Id = r.store.nextId("moderation_actions")
+	action.CreatedAt = timestamp()
+	stored := *action
+	stored.Actor = nil
+	r.store.moderationActions[action.Id] = stored
+
+	return nil
+}
+
+func (r *moderationMemoryRepository) List(query ListQuery) ([]models.ModerationAction, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	actions := make([]models.ModerationAction, 0, len(r.store.moderationActions))
+	for _, action := range r.store.moderationActions {
+		actions = append(actions, action)
+	}
+
+	page, total := listRecords(actions, query, moderationField)
+	for i := range page {
+		if page[i].ActorId != nil {
+			page[i].Actor = r.store.userRef(*page[i].ActorId)
+		}
+	}
+
+	return page, total, nil
+}
+
+func moderationField(action models.ModerationAction, field string) interface{} {
+	switch field {
+	case "id":
+		return action.Id
+	case "actor_id":
+		if action.ActorId == nil {
+			return uint(0)
+		}
+		return *action.ActorId
+	case "target_id":
+		return action.TargetId
+	case "created_at":
+		return action.CreatedAt
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+
+	"gorm.io/gorm"
+)
+
+type ModerationRepository interface {
+	Create(action *models.ModerationAction) error
+	List(query ListQuery) ([]models.ModerationAction, int64, error)
+}
+
+type moderationRepository struct {
+	db *gorm.DB
+}
+
+func NewModerationRepository(db *gorm.DB) ModerationRepository {
+	return &moderationRepository{
+		db: db,
+	}
+}
+
+func (r *moderationRepository) Create(action *models.ModerationAction) error {
+	return translateError(r.db.Create(action).Error)
+}
+
+func (r *moderationRepository) List(query ListQuery) ([]models.ModerationAction, int64, error) {
+	var actions []models.ModerationAction
+
+	total, err := list(r.db.Preload("Actor"), &models.ModerationAction{}, &actions, query, "moderation_actions")
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return actions, total, nil
+}
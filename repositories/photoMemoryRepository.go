@@ -0,0 +1,328 @@
+package repositories
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type photoMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewPhotoMemoryRepository(store *MemoryStore) PhotoRepository {
+	return &photoMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *photoMemoryRepository) Create(photo *models.Photo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := photo.BeforeCreate(nil); err != nil {
+		return translateError(err)
+	}
+
+	photo.Id = r.store.nextId("photos")
+	photo.CreatedAt = timestamp()
+	photo.UpdatedAt = photo.CreatedAt
+	r.store.photos[photo.Id] = stripPhoto(*photo)
+
+	return nil
+}
+
+func (r *photoMemoryRepository) FindById(id uint) (*models.Photo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	photo, ok := r.store.photos[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	photo.User = r.store.userRef(photo.UserId)
+
+	return &photo, nil
+}
+
+func (r *photoMemoryRepository) FindWithComments(id uint) (*models.Photo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	photo, ok := r.store.photos[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	photo.User = r.store.userRef(photo.UserId)
+
+	for _, comment := range r.store.comments {
+		if comment.PhotoId == id {
+			comment.User = r.store.userRef(comment.UserId)
+			photo.Comment = append(photo.Comment, comment)
+		}
+	}
+	sort.Slice(photo.Comment, func(i, j int) bool {
+		return compareValues(photo.Comment[i].CreatedAt, photo.Comment[j].CreatedAt) < 0
+	})
+
+	return &photo, nil
+}
+
+func (r *photoMemoryRepository) FindByStorageKey(key string) (*models.Photo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, photo := range r.store.allPhotos() {
+		if photo.StorageKey != nil && *photo.StorageKey == key {
+			photo.User = r.store.userRef(photo.UserId)
+			return &photo, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *photoMemoryRepository) List(viewerId uint, query ListQuery) ([]models.Photo, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	photos := make([]models.Photo, 0, len(r.store.photos))
+	for _, photo := range r.store.photos {
+		if photo.UserId == viewerId || (photo.Processed() && r.store.canSee(viewerId, photo.UserId)) {
+			photos = append(photos, photo)
+		}
+	}
+
+	page, total := listRecords(photos, query, photoField)
+	for i := range page {
+		page[i].User = r.store.userRef(page[i].UserId)
+	}
+
+	return page, total, nil
+}
+
+func (r *photoMemoryRepository) Update(photo *models.Photo, changes models.Photo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.photos[photo.Id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if changes.Title != "" {
+		stored.Title = changes.Title
+	}
+	if changes.Caption != "" {
+		stored.Caption = changes.Caption
+	}
+	if changes.PhotoUrl != "" {
+		stored.PhotoUrl = changes.PhotoUrl
+	}
+
+	if err := stored.BeforeUpdate(nil); err != nil {
+		return translateError(err)
+	}
+
+	stored.UpdatedAt = timestamp()
+	r.store.photos[photo.Id] = stored
+	stored.User = photo.User
+	*photo = stored
+
+	return nil
+}
+
+func (r *photoMemoryRepository) Delete(photo *models.Photo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.photos[photo.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	at := deletedAt(time.Now())
+	r.store.trashPhoto(stored, at)
+	photo.DeletedAt = at
+
+	return nil
+}
+
+func (r *photoMemoryRepository) FindDeleted(id uint) (*models.Photo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	photo, ok := r.store.deletedPhotos[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &photo, nil
+}
+
+func (r *photoMemoryRepository) Restore(photo *models.Photo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.deletedPhotos[photo.Id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	for _, comment := range r.store.deletedComments {
+		if comment.PhotoId == photo.Id && comment.DeletedAt == stored.DeletedAt {
+			r.store.restoreComment(comment)
+		}
+	}
+
+	stored.DeletedAt = gorm.DeletedAt{}
+	delete(r.store.deletedPhotos, photo.Id)
+	r.store.photos[photo.Id] = stored
+	photo.DeletedAt = stored.DeletedAt
+
+	return nil
+}
+
+func (r *photoMemoryRepository) Purge(photo *models.Photo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, live := r.store.photos[photo.Id]
+	_, deleted := r.store.deletedPhotos[photo.Id]
+	if !live && !deleted {
+		return ErrNotFound
+	}
+	r.store.purgePhoto(photo.Id)
+
+	return nil
+}
+
+func (r *photoMemoryRepository) ListDeleted(before time.Time, limit int) ([]models.Photo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	photos := make([]models.Photo, 0)
+	for _, photo := range r.store.deletedPhotos {
+		if photo.DeletedAt.Time.Before(before) {
+			photos = append(photos, photo)
+		}
+	}
+	sort.Slice(photos, func(i, j int) bool {
+		return photos[i].Id < photos[j].Id
+	})
+	if len(photos) > limit {
+		photos = photos[:limit]
+	}
+
+	return photos, nil
+}
+
+func (r *photoMemoryRepository) CountByUser(userId uint) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var total int64
+	for _, photo := range r.store.photos {
+		if photo.UserId == userId {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (r *photoMemoryRepository) SaveProcessing(photo *models.Photo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	photos := r.store.photos
+	stored, ok := photos[photo.Id]
+	if !ok {
+		photos = r.store.deletedPhotos
+		stored, ok = photos[photo.Id]
+	}
+	if !ok {
+		return ErrNotFound
+	}
+
+	stored.UploadKey = photo.UploadKey
+	stored.ProcessingStatus = photo.ProcessingStatus
+	stored.Width = photo.Width
+	stored.Height = photo.Height
+	stored.Format = photo.Format
+	stored.Blurhash = photo.Blurhash
+	stored.MediumUrl = photo.MediumUrl
+	stored.ThumbnailUrl = photo.ThumbnailUrl
+	photos[photo.Id] = stored
+
+	return nil
+}
+
+func (r *photoMemoryRepository) ListPendingIds() ([]uint, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var ids []uint
+	for id, photo := range r.store.allPhotos() {
+		if photo.ProcessingStatus == models.PhotoProcessingPending {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	return ids, nil
+}
+
+func (r *photoMemoryRepository) Feed(userId uint, p helpers.Pagination) ([]models.Photo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	following := make(map[uint]bool)
+	for _, follow := range r.store.follows {
+		if follow.FollowerId == userId && follow.Accepted() {
+			following[follow.FollowingId] = true
+		}
+	}
+
+	photos := make([]models.Photo, 0)
+	for _, photo := range r.store.photos {
+		if photo.UserId == userId || (following[photo.UserId] && photo.Processed()) {
+			photos = append(photos, photo)
+		}
+	}
+
+	page, _ := listRecords(photos, ListQuery{Pagination: p}, photoField)
+	for i := range page {
+		page[i].User = r.store.userRef(page[i].UserId)
+	}
+
+	return page, nil
+}
+
+func stripPhoto(photo models.Photo) models.Photo {
+	photo.User = nil
+	photo.Comment = nil
+
+	return photo
+}
+
+func photoField(photo models.Photo, field string) interface{} {
+	switch field {
+	case "id":
+		return photo.Id
+	case "user_id":
+		return photo.UserId
+	case "title":
+		return photo.Title
+	case "created_at":
+		return photo.CreatedAt
+	case "updated_at":
+		return photo.UpdatedAt
+	}
+
+	return nil
+}
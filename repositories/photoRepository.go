@@ -0,0 +1,233 @@
+package repositories
+
+import (
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PhotoRepository interface {
+	Create(photo *models.Photo) error
+	FindById(id uint) (*models.Photo, error)
+	FindWithComments(id uint) (*models.Photo, error)
+	// FindByStorageKey finds the uploaded photo whose original is stored
+	// under key, deleted or not.
+	FindByStorageKey(key string) (*models.Photo, error)
+	// List leaves out the photos viewerId may not see, and those of other
+	// users until they are processed.
+	List(viewerId uint, query ListQuery) ([]models.Photo, int64, error)
+	Update(photo *models.Photo, changes models.Photo) error
+	// Delete hides the photo and its comments until Restore or a purge.
+	Delete(photo *models.Photo) error
+	FindDeleted(id uint) (*models.Photo, error)
+	Restore(photo *models.Photo) error
+	// Purge hard deletes the photo; its comments and likes go with it.
+	Purge(photo *models.Photo) error
+	// ListDeleted returns up to limit photos deleted before the given time.
+	ListDeleted(before time.Time, limit int) ([]models.Photo, error)
+	CountByUser(userId uint) (int64, error)
+	SaveProcessing(photo *models.Photo) error
+	ListPendingIds() ([]uint, error)
+	// Feed lists the photos of userId and of the users they follow, newest
+	// first. Other users' photos are left out until they are processed.
+	Feed(userId uint, p helpers.Pagination) ([]models.Photo, error)
+}
+
+// processedStatuses are those of photos that can be shown: ready uploads
+// and photos added by URL, which are never processed.
+var processedStatuses = []string{"", models.PhotoProcessingReady}
+
+type photoRepository struct {
+	db *gorm.DB
+}
+
+func NewPhotoRepository(db *gorm.DB) PhotoRepository {
+	return &photoRepository{
+		db: db,
+	}
+}
+
+func (r *photoRepository) Create(photo *models.Photo) error {
+	return translateError(r.db.Create(photo).Error)
+}
+
+func (r *photoRepository) FindById(id uint) (*models.Photo, error) {
+	var photo models.Photo
+
+	err := r.db.Preload("User").First(&photo, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &photo, nil
+}
+
+func (r *photoRepository) FindWithComments(id uint) (*models.Photo, error) {
+	var photo models.Photo
+
+	err := r.db.Preload("User").Preload("Comment", func(db *gorm.DB) *gorm.DB {
+		return db.Order("comments.created_at ASC")
+	}).Preload("Comment.User").First(&photo, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &photo, nil
+}
+
+func (r *photoRepository) FindByStorageKey(key string) (*models.Photo, error) {
+	var photo models.Photo
+
+	err := r.db.Unscoped().Preload("User").Where("storage_key = ?", key).First(&photo).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &photo, nil
+}
+
+func (r *photoRepository) List(viewerId uint, query ListQuery) ([]models.Photo, int64, error) {
+	var photos []models.Photo
+
+	db := r.db.Preload("User").
+		Where("photos.user_id = ? OR (photos.processing_status IN ? AND photos.user_id IN (?))",
+			viewerId, processedStatuses, visibleOwners(r.db, viewerId))
+	total, err := list(db, &models.Photo{}, &photos, query, "photos")
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return photos, total, nil
+}
+
+func (r *photoRepository) Update(photo *models.Photo, changes models.Photo) error {
+	return translateError(r.db.Model(photo).Updates(changes).Error)
+}
+
+func (r *photoRepository) Delete(photo *models.Photo) error {
+	at := time.Now()
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Comment{}).Where("photo_id = ?", photo.Id).UpdateColumn("deleted_at", at).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Photo{}).Where("id = ?", photo.Id).UpdateColumn("deleted_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	photo.DeletedAt = gorm.DeletedAt{Time: at, Valid: true}
+
+	return nil
+}
+
+func (r *photoRepository) FindDeleted(id uint) (*models.Photo, error) {
+	var photo models.Photo
+
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&photo, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &photo, nil
+}
+
+func (r *photoRepository) Restore(photo *models.Photo) error {
+	at := photo.DeletedAt.Time
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&models.Comment{}).Where("photo_id = ? AND deleted_at = ?", photo.Id, at).UpdateColumn("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&models.Photo{}).Where("id = ?", photo.Id).UpdateColumn("deleted_at", nil).Error
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	photo.DeletedAt = gorm.DeletedAt{}
+
+	return nil
+}
+
+func (r *photoRepository) Purge(photo *models.Photo) error {
+	return translateError(r.db.Unscoped().Delete(photo).Error)
+}
+
+func (r *photoRepository) ListDeleted(before time.Time, limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := r.db.Unscoped().Where("deleted_at < ?", before).Order("id").Limit(limit).Find(&photos).Error
+
+	return photos, translateError(err)
+}
+
+func (r *photoRepository) CountByUser(userId uint) (int64, error) {
+	var total int64
+
+	err := r.db.Model(&models.Photo{}).Where("user_id = ?", userId).Count(&total).Error
+
+	return total, translateError(err)
+}
+
+// SaveProcessing stores the result of image processing, including clearing
+// the upload key, without touching the fields the owner can edit. Deleted
+// photos are still processed so they are ready if restored.
+func (r *photoRepository) SaveProcessing(photo *models.Photo) error {
+	result := r.db.Unscoped().Model(&models.Photo{}).Where("id = ?", photo.Id).UpdateColumns(map[string]interface{}{
+		"upload_key":        photo.UploadKey,
+		"processing_status": photo.ProcessingStatus,
+		"width":             photo.Width,
+		"height":            photo.Height,
+		"format":            photo.Format,
+		"blurhash":          photo.Blurhash,
+		"medium_url":        photo.MediumUrl,
+		"thumbnail_url":     photo.ThumbnailUrl,
+	})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *photoRepository) ListPendingIds() ([]uint, error) {
+	var ids []uint
+
+	err := r.db.Unscoped().Model(&models.Photo{}).Where("processing_status = ?", models.PhotoProcessingPending).Order("id").Pluck("id", &ids).Error
+
+	return ids, translateError(err)
+}
+
+func (r *photoRepository) Feed(userId uint, p helpers.Pagination) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	following := r.db.Model(&models.Follow{}).Select("following_id").
+		Where("follower_id = ? AND accepted_at IS NOT NULL", userId)
+
+	err := r.db.Preload("User").
+		Where("photos.user_id = ? OR (photos.user_id IN (?) AND photos.processing_status IN ?)",
+			userId, following, processedStatuses).
+		Scopes(pageScope(p, "photos")).
+		Find(&photos).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return photos, nil
+}
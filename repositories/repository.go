@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound              = apperrors.NotFound("record not found")
+	ErrDuplicateUsername     = apperrors.Conflict("username", "username is duplicated")
+	ErrDuplicateEmail        = apperrors.Conflict("email", "email is duplicated")
+	ErrPhotoNotFound         = apperrors.NotFound("photo not found").WithField("photo_id", "exists", "photo not found")
+	ErrDuplicateFollow       = apperrors.Conflict("user_id", "you already follow this user")
+	ErrParentCommentNotFound = apperrors.NotFound("comment not found").WithField("parent_id", "exists", "parent comment not found")
+	ErrDuplicateLike         = apperrors.Conflict("user_id", "you already like this")
+	ErrDuplicateIdentity     = apperrors.Conflict("provider", "this provider account is already linked")
+)
+
+// liveUsers selects the ids of users that are not deleted, for relations
+// such as likes and follows that are kept while an account is hidden.
+const liveUsers = "SELECT id FROM users WHERE deleted_at IS NULL"
+
+type ListQuery struct {
+	Pagination helpers.Pagination
+	Filter     helpers.Filter
+}
+
+// translateError maps gorm, Postgres and validation errors onto typed errors
+// so callers never depend on driver messages. Constraints that callers match
+// on get their own sentinel.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.ConstraintName {
+		case "idx_users_username":
+			return ErrDuplicateUsername
+		case "idx_users_email":
+			return ErrDuplicateEmail
+		case "fk_photos_comment":
+			return ErrPhotoNotFound
+		case "idx_follows_pair":
+			return ErrDuplicateFollow
+		case "idx_likes_user_photo", "idx_likes_user_comment":
+			return ErrDuplicateLike
+		case "fk_likes_photo":
+			return ErrPhotoNotFound
+		case "fk_comments_replies":
+			return ErrParentCommentNotFound
+		case "idx_identities_provider_subject":
+			return ErrDuplicateIdentity
+		}
+	}
+
+	return apperrors.FromDatabase(err)
+}
+
+func filterScope(filter helpers.Filter, table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for field, value := range filter.Fields {
+			db = db.Where(fmt.Sprintf("%s.%s = ?", table, field), value)
+		}
+		if filter.CreatedFrom != nil {
+			db = db.Where(table+".created_at >= ?", *filter.CreatedFrom)
+		}
+		if filter.CreatedTo != nil {
+			db = db.Where(table+".created_at <= ?", *filter.CreatedTo)
+		}
+		return db
+	}
+}
+
+// pageScope adds ordering, limit and either offset or keyset conditions.
+func pageScope(p helpers.Pagination, table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		direction, comparator := "ASC", ">"
+		if p.SortDesc {
+			direction, comparator = "DESC", "<"
+		}
+
+		if p.After > 0 {
+			if p.SortField == "id" {
+				db = db.Where(fmt.Sprintf("%s.id %s ?", table, comparator), p.After)
+			} else {
+				db = db.Where(fmt.Sprintf("(%[1]s.%[2]s, %[1]s.id) %[3]s (SELECT %[2]s, id FROM %[1]s WHERE id = ?)", table, p.SortField, comparator), p.After)
+			}
+		} else {
+			db = db.Offset((p.Page - 1) * p.Limit)
+		}
+
+		db = db.Order(fmt.Sprintf("%s.%s %s", table, p.SortField, direction))
+		if p.SortField != "id" {
+			db = db.Order(fmt.Sprintf("%s.id %s", table, direction))
+		}
+
+		return db.Limit(p.Limit)
+	}
+}
+
+// visibleOwners selects the ids of the users whose content viewerId may
+// see: public accounts, viewerId itself and the private accounts it follows.
+func visibleOwners(db *gorm.DB, viewerId uint) *gorm.DB {
+	following := db.Model(&models.Follow{}).Select("following_id").
+		Where("follower_id = ? AND accepted_at IS NOT NULL", viewerId)
+
+	return db.Model(&models.User{}).Select("id").
+		Where("NOT private OR id = ? OR id IN (?)", viewerId, following)
+}
+
+func list(db *gorm.DB, model interface{}, dest interface{}, query ListQuery, table string) (int64, error) {
+	var total int64
+
+	filter := filterScope(query.Filter, table)
+
+	err := db.Model(model).Scopes(filter).Count(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	err = db.Scopes(filter, pageScope(query.Pagination, table)).Find(dest).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Repositories groups every repository so services can be wired from a
+// single value, backed either by gorm or by a MemoryStore.
+type Repositories struct {
+	Users    UserRepository
+	Photos   PhotoRepository
+	Comments CommentRepository
+	Socials  SocialRepository
+	Tokens   TokenRepository
+	Follows  FollowRepository
+	Likes    LikeRepository
+
+	Identities IdentityRepository
+	Moderation ModerationRepository
+	Audit      AuditRepository
+}
+
+func NewRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:    NewUserRepository(db),
+		Photos:   NewPhotoRepository(db),
+		Comments: NewCommentRepository(db),
+		Socials:  NewSocialRepository(db),
+		Tokens:   NewTokenRepository(db),
+		Follows:  NewFollowRepository(db),
+		Likes:    NewLikeRepository(db),
+
+		Identities: NewIdentityRepository(db),
+		Moderation: NewModerationRepository(db),
+		Audit:      NewAuditRepository(db),
+	}
+}
+
+func NewMemoryRepositories(store *MemoryStore) Repositories {
+	return Repositories{
+		Users:    NewUserMemoryRepository(store),
+		Photos:   NewPhotoMemoryRepository(store),
+		Comments: NewCommentMemoryRepository(store),
+		Socials:  NewSocialMemoryRepository(store),
+		Tokens:   NewTokenMemoryRepository(store),
+		Follows:  NewFollowMemoryRepository(store),
+		Likes:    NewLikeMemoryRepository(store),
+
+		Identities: NewIdentityMemoryRepository(store),
+		Moderation: NewModerationMemoryRepository(store),
+		Audit:      NewAuditMemoryRepository(store),
+	}
+}
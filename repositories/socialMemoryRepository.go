@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type socialMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewSocialMemoryRepository(store *MemoryStore) SocialRepository {
+	return &socialMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *socialMemoryRepository) Create(social *models.Social) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := social.BeforeCreate(nil); err != nil {
+		return translateError(err)
+	}
+
+	social.Id = r.store.nextId("socials")
+	social.CreatedAt = timestamp()
+	social.UpdatedAt = social.CreatedAt
+	social.User = nil
+	r.store.socials[social.Id] = *social
+
+	return nil
+}
+
+func (r *socialMemoryRepository) FindById(id uint) (*models.Social, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	social, ok := r.store.socials[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	social.User = r.store.userRef(social.UserId)
+
+	return &social, nil
+}
+
+func (r *socialMemoryRepository) List(query ListQuery) ([]models.Social, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	socials := make([]models.Social, 0, len(r.store.socials))
+	for _, social := range r.store.socials {
+		socials = append(socials, social)
+	}
+
+	page, total := listRecords(socials, query, socialField)
+	for i := range page {
+		page[i].User = r.store.userRef(page[i].UserId)
+	}
+
+	return page, total, nil
+}
+
+func (r *socialMemoryRepository) Update(social *models.Social, changes models.Social) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.socials[social.Id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if changes.Name != "" {
+		stored.Name = changes.Name
+	}
+	if changes.SocialMediaUrl != "" {
+		stored.SocialMediaUrl = changes.SocialMediaUrl
+	}
+
+	if err := stored.BeforeUpdate(nil); err != nil {
+		return translateError(err)
+	}
+
+	stored.UpdatedAt = timestamp()
+	r.store.socials[social.Id] = stored
+	stored.User = r.store.userRef(stored.UserId)
+	*social = stored
+
+	return nil
+}
+
+func (r *socialMemoryRepository) Delete(social *models.Social) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.socials[social.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.DeletedAt = deletedAt(time.Now())
+	delete(r.store.socials, social.Id)
+	r.store.deletedSocials[social.Id] = stored
+	social.DeletedAt = stored.DeletedAt
+
+	return nil
+}
+
+func (r *socialMemoryRepository) FindDeleted(id uint) (*models.Social, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	social, ok := r.store.deletedSocials[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &social, nil
+}
+
+func (r *socialMemoryRepository) Restore(social *models.Social) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.deletedSocials[social.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.DeletedAt = gorm.DeletedAt{}
+	delete(r.store.deletedSocials, social.Id)
+	r.store.socials[social.Id] = stored
+	social.DeletedAt = stored.DeletedAt
+
+	return nil
+}
+
+func (r *socialMemoryRepository) Purge(social *models.Social) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, live := r.store.socials[social.Id]
+	_, deleted := r.store.deletedSocials[social.Id]
+	if !live && !deleted {
+		return ErrNotFound
+	}
+	delete(r.store.socials, social.Id)
+	delete(r.store.deletedSocials, social.Id)
+
+	return nil
+}
+
+func (r *socialMemoryRepository) PurgeDeleted(before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var total int64
+	for id, social := range r.store.deletedSocials {
+		if social.DeletedAt.Time.Before(before) {
+			delete(r.store.deletedSocials, id)
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (r *socialMemoryRepository) CountByUser(userId uint) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var total int64
+	for _, social := range r.store.socials {
+		if social.UserId == userId {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func socialField(social models.Social, field string) interface{} {
+	switch field {
+	case "id":
+		return social.Id
+	case "user_id":
+		return social.UserId
+	case "name":
+		return social.Name
+	case "created_at":
+		return social.CreatedAt
+	case "updated_at":
+		return social.UpdatedAt
+	}
+
+	return nil
+}
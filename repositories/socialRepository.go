@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SocialRepository interface {
+	Create(social *models.Social) error
+	FindById(id uint) (*models.Social, error)
+	List(query ListQuery) ([]models.Social, int64, error)
+	Update(social *models.Social, changes models.Social) error
+	// Delete hides the social until Restore or a purge.
+	Delete(social *models.Social) error
+	FindDeleted(id uint) (*models.Social, error)
+	Restore(social *models.Social) error
+	Purge(social *models.Social) error
+	// PurgeDeleted hard deletes the socials deleted before the given time.
+	PurgeDeleted(before time.Time) (int64, error)
+	CountByUser(userId uint) (int64, error)
+}
+
+type socialRepository struct {
+	db *gorm.DB
+}
+
+func NewSocialRepository(db *gorm.DB) SocialRepository {
+	return &socialRepository{
+		db: db,
+	}
+}
+
+func (r *socialRepository) Create(social *models.Social) error {
+	return translateError(r.db.Create(social).Error)
+}
+
+func (r *socialRepository) FindById(id uint) (*models.Social, error) {
+	var social models.Social
+
+	err := r.db.Preload("User").First(&social, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &social, nil
+}
+
+func (r *socialRepository) List(query ListQuery) ([]models.Social, int64, error) {
+	var socials []models.Social
+
+	total, err := list(r.db.Preload("User"), &models.Social{}, &socials, query, "socials")
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return socials, total, nil
+}
+
+func (r *socialRepository) Update(social *models.Social, changes models.Social) error {
+	return translateError(r.db.Model(social).Updates(changes).Error)
+}
+
+func (r *socialRepository) Delete(social *models.Social) error {
+	return translateError(r.db.Delete(social).Error)
+}
+
+func (r *socialRepository) CountByUser(userId uint) (int64, error) {
+	var total int64
+
+	err := r.db.Model(&models.Social{}).Where("user_id = ?", userId).Count(&total).Error
+
+	return total, translateError(err)
+}
+
+func (r *socialRepository) FindDeleted(id uint) (*models.Social, error) {
+	var social models.Social
+
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&social, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &social, nil
+}
+
+func (r *socialRepository) Restore(social *models.Social) error {
+	err := r.db.Unscoped().Model(&models.Social{}).Where("id = ?", social.Id).UpdateColumn("deleted_at", nil).Error
+	if err != nil {
+		return translateError(err)
+	}
+	social.DeletedAt = gorm.DeletedAt{}
+
+	return nil
+}
+
+func (r *socialRepository) Purge(social *models.Social) error {
+	return translateError(r.db.Unscoped().Delete(social).Error)
+}
+
+func (r *socialRepository) PurgeDeleted(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("deleted_at < ?", before).Delete(&models.Social{})
+
+	return result.RowsAffected, translateError(result.Error)
+}
@@ -0,0 +1,283 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+)
+
+type tokenMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewTokenMemoryRepository(store *MemoryStore) TokenRepository {
+	return &tokenMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *tokenMemoryRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token.Id = r.store.nextId("refresh_tokens")
+	token.CreatedAt = timestamp()
+	token.User = nil
+	r.store.refreshTokens[token.Id] = *token
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) FindRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, token := range r.store.refreshTokens {
+		if token.TokenHash == hash {
+			token.User = r.store.userRef(token.UserId)
+			return &token, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *tokenMemoryRepository) RevokeRefreshToken(id uint, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, ok := r.store.refreshTokens[id]
+	if !ok || token.RevokedAt != nil {
+		return ErrNotFound
+	}
+	token.RevokedAt = &at
+	r.store.refreshTokens[id] = token
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) RevokeRefreshTokenByHash(userId uint, hash string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, token := range r.store.refreshTokens {
+		if token.TokenHash == hash && token.UserId == userId && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			r.store.refreshTokens[id] = token
+		}
+	}
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) RevokeAllRefreshTokens(userId uint, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, token := range r.store.refreshTokens {
+		if token.UserId == userId && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			r.store.refreshTokens[id] = token
+		}
+	}
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) RevokeAccessToken(token *models.RevokedToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token.CreatedAt = timestamp()
+	r.store.revokedTokens[token.Jti] = *token
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	_, ok := r.store.revokedTokens[jti]
+
+	return ok, nil
+}
+
+func (r *tokenMemoryRepository) PurgeExpired(now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for jti, token := range r.store.revokedTokens {
+		if token.ExpiresAt.Before(now) {
+			delete(r.store.revokedTokens, jti)
+		}
+	}
+	for id, reset := range r.store.passwordResets {
+		if reset.ExpiresAt.Before(now) {
+			delete(r.store.passwordResets, id)
+		}
+	}
+	for id, challenge := range r.store.twoFactorChallenges {
+		if challenge.ExpiresAt.Before(now) {
+			delete(r.store.twoFactorChallenges, id)
+		}
+	}
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) CreatePasswordReset(reset *models.PasswordReset) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	reset.Id = r.store.nextId("password_resets")
+	reset.CreatedAt = timestamp()
+	reset.User = nil
+	r.store.passwordResets[reset.Id] = *reset
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) FindPasswordResetByHash(hash string) (*models.PasswordReset, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, reset := range r.store.passwordResets {
+		if reset.TokenHash == hash {
+			reset.User = r.store.userRef(reset.UserId)
+			return &reset, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *tokenMemoryRepository) UsePasswordReset(id uint, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	reset, ok := r.store.passwordResets[id]
+	if !ok || reset.UsedAt != nil || !reset.ExpiresAt.After(at) {
+		return ErrNotFound
+	}
+	reset.UsedAt = &at
+	r.store.passwordResets[id] = reset
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) UseAllPasswordResets(userId uint, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, reset := range r.store.passwordResets {
+		if reset.UserId == userId && reset.UsedAt == nil {
+			reset.UsedAt = &at
+			r.store.passwordResets[id] = reset
+		}
+	}
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) ReplaceRecoveryCodes(userId uint, codes []models.RecoveryCode) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, code := range r.store.recoveryCodes {
+		if code.UserId == userId {
+			delete(r.store.recoveryCodes, id)
+		}
+	}
+	for i := range codes {
+		codes[i].Id = r.store.nextId("recovery_codes")
+		codes[i].UserId = userId
+		codes[i].CreatedAt = timestamp()
+		codes[i].User = nil
+		r.store.recoveryCodes[codes[i].Id] = codes[i]
+	}
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) UseRecoveryCode(userId uint, hash string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, code := range r.store.recoveryCodes {
+		if code.UserId == userId && code.CodeHash == hash && code.UsedAt == nil {
+			code.UsedAt = &at
+			r.store.recoveryCodes[id] = code
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (r *tokenMemoryRepository) CountRecoveryCodes(userId uint) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var total int64
+	for _, code := range r.store.recoveryCodes {
+		if code.UserId == userId && code.UsedAt == nil {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (r *tokenMemoryRepository) CreateTwoFactorChallenge(challenge *models.TwoFactorChallenge) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	challenge.Id = r.store.nextId("two_factor_challenges")
+	challenge.CreatedAt = timestamp()
+	challenge.User = nil
+	r.store.twoFactorChallenges[challenge.Id] = *challenge
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) FindTwoFactorChallengeByHash(hash string) (*models.TwoFactorChallenge, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, challenge := range r.store.twoFactorChallenges {
+		if challenge.TokenHash == hash {
+			challenge.User = r.store.userRef(challenge.UserId)
+			return &challenge, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *tokenMemoryRepository) AttemptTwoFactorChallenge(id uint, maxAttempts int, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	challenge, ok := r.store.twoFactorChallenges[id]
+	if !ok || challenge.UsedAt != nil || !challenge.ExpiresAt.After(at) || challenge.Attempts >= maxAttempts {
+		return ErrNotFound
+	}
+	challenge.Attempts++
+	r.store.twoFactorChallenges[id] = challenge
+
+	return nil
+}
+
+func (r *tokenMemoryRepository) UseTwoFactorChallenge(id uint, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	challenge, ok := r.store.twoFactorChallenges[id]
+	if !ok || challenge.UsedAt != nil || !challenge.ExpiresAt.After(at) {
+		return ErrNotFound
+	}
+	challenge.UsedAt = &at
+	r.store.twoFactorChallenges[id] = challenge
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(token *models.RefreshToken) error
+	FindRefreshTokenByHash(hash string) (*models.RefreshToken, error)
+	// RevokeRefreshToken returns ErrNotFound when the token was already revoked,
+	// so concurrent refreshes cannot both succeed.
+	RevokeRefreshToken(id uint, at time.Time) error
+	RevokeRefreshTokenByHash(userId uint, hash string, at time.Time) error
+	RevokeAllRefreshTokens(userId uint, at time.Time) error
+	RevokeAccessToken(token *models.RevokedToken) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	// PurgeExpired deletes revoked access tokens, password resets and two
+	// factor challenges that have expired.
+	PurgeExpired(now time.Time) error
+	CreatePasswordReset(reset *models.PasswordReset) error
+	FindPasswordResetByHash(hash string) (*models.PasswordReset, error)
+	// UsePasswordReset returns ErrNotFound when the reset was already used or
+	// has expired, so a token can't be used twice.
+	UsePasswordReset(id uint, at time.Time) error
+	// UseAllPasswordResets voids every outstanding reset of the user.
+	UseAllPasswordResets(userId uint, at time.Time) error
+	// ReplaceRecoveryCodes deletes every recovery code of the user and stores
+	// codes in their place.
+	ReplaceRecoveryCodes(userId uint, codes []models.RecoveryCode) error
+	// UseRecoveryCode returns ErrNotFound when the user has no unused code
+	// with that hash.
+	UseRecoveryCode(userId uint, hash string, at time.Time) error
+	CountRecoveryCodes(userId uint) (int64, error)
+	CreateTwoFactorChallenge(challenge *models.TwoFactorChallenge) error
+	FindTwoFactorChallengeByHash(hash string) (*models.TwoFactorChallenge, error)
+	// AttemptTwoFactorChallenge counts a code tried against the challenge. It
+	// returns ErrNotFound when the challenge was used, has expired or had
+	// maxAttempts already.
+	AttemptTwoFactorChallenge(id uint, maxAttempts int, at time.Time) error
+	// UseTwoFactorChallenge returns ErrNotFound when the challenge was already
+	// used or has expired, so it can't start two sessions.
+	UseTwoFactorChallenge(id uint, at time.Time) error
+}
+
+type tokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{
+		db: db,
+	}
+}
+
+func (r *tokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	return translateError(r.db.Create(token).Error)
+}
+
+func (r *tokenRepository) FindRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+
+	err := r.db.Preload("User").First(&token, "token_hash = ?", hash).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &token, nil
+}
+
+func (r *tokenRepository) RevokeRefreshToken(id uint, at time.Time) error {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *tokenRepository) RevokeRefreshTokenByHash(userId uint, hash string, at time.Time) error {
+	err := r.db.Model(&models.RefreshToken{}).
+		Where("token_hash = ? AND user_id = ? AND revoked_at IS NULL", hash, userId).
+		Update("revoked_at", at).Error
+
+	return translateError(err)
+}
+
+func (r *tokenRepository) RevokeAllRefreshTokens(userId uint, at time.Time) error {
+	err := r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", at).Error
+
+	return translateError(err)
+}
+
+func (r *tokenRepository) RevokeAccessToken(token *models.RevokedToken) error {
+	return translateError(r.db.Create(token).Error)
+}
+
+func (r *tokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	var total int64
+
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&total).Error
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	return total > 0, nil
+}
+
+func (r *tokenRepository) PurgeExpired(now time.Time) error {
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.PasswordReset{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	return translateError(r.db.Where("expires_at < ?", now).Delete(&models.TwoFactorChallenge{}).Error)
+}
+
+func (r *tokenRepository) CreatePasswordReset(reset *models.PasswordReset) error {
+	return translateError(r.db.Create(reset).Error)
+}
+
+func (r *tokenRepository) FindPasswordResetByHash(hash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+
+	err := r.db.Preload("User").First(&reset, "token_hash = ?", hash).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &reset, nil
+}
+
+func (r *tokenRepository) UsePasswordReset(id uint, at time.Time) error {
+	result := r.db.Model(&models.PasswordReset{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, at).
+		Update("used_at", at)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *tokenRepository) UseAllPasswordResets(userId uint, at time.Time) error {
+	err := r.db.Model(&models.PasswordReset{}).
+		Where("user_id = ? AND used_at IS NULL", userId).
+		Update("used_at", at).Error
+
+	return translateError(err)
+}
+
+func (r *tokenRepository) ReplaceRecoveryCodes(userId uint, codes []models.RecoveryCode) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		for i := range codes {
+			codes[i].UserId = userId
+		}
+
+		return tx.Create(&codes).Error
+	})
+
+	return translateError(err)
+}
+
+func (r *tokenRepository) UseRecoveryCode(userId uint, hash string, at time.Time) error {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, hash).
+		Update("used_at", at)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *tokenRepository) CountRecoveryCodes(userId uint) (int64, error) {
+	var total int64
+
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userId).Count(&total).Error
+
+	return total, translateError(err)
+}
+
+func (r *tokenRepository) CreateTwoFactorChallenge(challenge *models.TwoFactorChallenge) error {
+	return translateError(r.db.Create(challenge).Error)
+}
+
+func (r *tokenRepository) FindTwoFactorChallengeByHash(hash string) (*models.TwoFactorChallenge, error) {
+	var challenge models.TwoFactorChallenge
+
+	err := r.db.Preload("User").First(&challenge, "token_hash = ?", hash).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &challenge, nil
+}
+
+func (r *tokenRepository) AttemptTwoFactorChallenge(id uint, maxAttempts int, at time.Time) error {
+	result := r.db.Model(&models.TwoFactorChallenge{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?", id, at, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *tokenRepository) UseTwoFactorChallenge(id uint, at time.Time) error {
+	result := r.db.Model(&models.TwoFactorChallenge{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, at).
+		Update("used_at", at)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
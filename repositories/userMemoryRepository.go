@@ -0,0 +1,385 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type userMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewUserMemoryRepository(store *MemoryStore) UserRepository {
+	return &userMemoryRepository{
+		store: store,
+	}
+}
+
+func (r *userMemoryRepository) Create(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Deleted users keep their username and email until they are purged.
+	for _, users := range []map[uint]models.User{r.store.users, r.store.deletedUsers} {
+		for _, existing := range users {
+			if existing.Username == user.Username {
+				return ErrDuplicateUsername
+			}
+			if existing.Email == user.Email {
+				return ErrDuplicateEmail
+			}
+		}
+	}
+
+	if err := user.BeforeCreate(nil); err != nil {
+		return translateError(err)
+	}
+
+	user.Id = r.store.nextId("users")
+	user.CreatedAt = timestamp()
+	user.UpdatedAt = user.CreatedAt
+	r.store.users[user.Id] = stripUser(*user)
+
+	return nil
+}
+
+func (r *userMemoryRepository) FindById(id uint) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user := r.store.userRef(id)
+	if user == nil {
+		return nil, ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (r *userMemoryRepository) FindByEmail(email string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *userMemoryRepository) List(query ListQuery) ([]models.User, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := make([]models.User, 0, len(r.store.users))
+	for _, user := range r.store.users {
+		users = append(users, user)
+	}
+
+	page, total := listRecords(users, query, userField)
+
+	return page, total, nil
+}
+
+func (r *userMemoryRepository) Update(user *models.User, changes models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.users[user.Id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	for _, users := range []map[uint]models.User{r.store.users, r.store.deletedUsers} {
+		for id, existing := range users {
+			if id == user.Id {
+				continue
+			}
+			if changes.Username != "" && existing.Username == changes.Username {
+				return ErrDuplicateUsername
+			}
+			if changes.Email != "" && existing.Email == changes.Email {
+				return ErrDuplicateEmail
+			}
+		}
+	}
+
+	if changes.Username != "" {
+		stored.Username = changes.Username
+	}
+	if changes.Email != "" {
+		stored.Email = changes.Email
+	}
+	if changes.Password != "" {
+		if err := stored.SetPassword(changes.Password, time.Now()); err != nil {
+			return err
+		}
+	}
+	if changes.Age != 0 {
+		stored.Age = changes.Age
+	}
+
+	if err := stored.BeforeUpdate(nil); err != nil {
+		return translateError(err)
+	}
+
+	stored.UpdatedAt = timestamp()
+	r.store.users[user.Id] = stored
+	*user = stored
+
+	return nil
+}
+
+func (r *userMemoryRepository) Delete(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.users[user.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	at := deletedAt(time.Now())
+	replied := r.store.repliedByOthers(user.Id)
+
+	for _, photo := range r.store.photos {
+		if photo.UserId == user.Id {
+			r.store.trashPhoto(photo, at)
+		}
+	}
+	for _, comment := range r.store.comments {
+		if comment.UserId != user.Id {
+			continue
+		}
+		if !replied[comment.Id] {
+			r.store.trashComment(comment, at)
+		} else if !comment.Removed() {
+			comment.Message = models.CommentDeletedMessage
+			comment.RemovedAt = &at.Time
+			comment.UpdatedAt = &at.Time
+			r.store.comments[comment.Id] = comment
+		}
+	}
+	for id, social := range r.store.socials {
+		if social.UserId == user.Id {
+			social.DeletedAt = at
+			delete(r.store.socials, id)
+			r.store.deletedSocials[id] = social
+		}
+	}
+
+	stored.DeletedAt = at
+	delete(r.store.users, user.Id)
+	r.store.deletedUsers[user.Id] = stored
+	user.DeletedAt = at
+
+	return nil
+}
+
+func (r *userMemoryRepository) FindDeletedByEmail(email string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.deletedUsers {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *userMemoryRepository) Restore(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.deletedUsers[user.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	at := stored.DeletedAt
+
+	photoIds := make(map[uint]bool)
+	for id, photo := range r.store.deletedPhotos {
+		if photo.UserId == user.Id && photo.DeletedAt == at {
+			photoIds[id] = true
+			photo.DeletedAt = gorm.DeletedAt{}
+			delete(r.store.deletedPhotos, id)
+			r.store.photos[id] = photo
+		}
+	}
+	for _, comment := range r.store.deletedComments {
+		if comment.DeletedAt == at && (comment.UserId == user.Id || photoIds[comment.PhotoId]) {
+			r.store.restoreComment(comment)
+		}
+	}
+	for id, social := range r.store.deletedSocials {
+		if social.UserId == user.Id && social.DeletedAt == at {
+			social.DeletedAt = gorm.DeletedAt{}
+			delete(r.store.deletedSocials, id)
+			r.store.socials[id] = social
+		}
+	}
+
+	stored.DeletedAt = gorm.DeletedAt{}
+	delete(r.store.deletedUsers, user.Id)
+	r.store.users[user.Id] = stored
+	user.DeletedAt = stored.DeletedAt
+
+	return nil
+}
+
+func (r *userMemoryRepository) PurgeDeleted(before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var total int64
+	for id, user := range r.store.deletedUsers {
+		if user.DeletedAt.Time.Before(before) {
+			r.store.purgeUser(id)
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (r *userMemoryRepository) SetTokensRevokedAt(id uint, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil
+	}
+	user.TokensRevokedAt = &at
+	r.store.users[id] = user
+
+	return nil
+}
+
+func (r *userMemoryRepository) SetDisabledAt(id uint, at *time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil
+	}
+	user.DisabledAt = at
+	r.store.users[id] = user
+
+	return nil
+}
+
+func (r *userMemoryRepository) SetEmailVerifiedAt(id uint, at *time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil
+	}
+	user.EmailVerifiedAt = at
+	r.store.users[id] = user
+
+	return nil
+}
+
+func (r *userMemoryRepository) SetTotp(id uint, secret string, enabledAt *time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil
+	}
+	user.TotpSecret = secret
+	user.TotpEnabledAt = enabledAt
+	user.TotpLastStep = 0
+	r.store.users[id] = user
+
+	return nil
+}
+
+func (r *userMemoryRepository) UseTotpStep(id uint, step int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok || user.TotpLastStep >= step {
+		return ErrNotFound
+	}
+	user.TotpLastStep = step
+	r.store.users[id] = user
+
+	return nil
+}
+
+func (r *userMemoryRepository) SetPassword(id uint, hash string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil
+	}
+	user.Password = hash
+	user.PasswordChangedAt = &at
+	r.store.users[id] = user
+
+	return nil
+}
+
+func (r *userMemoryRepository) SetPrivate(id uint, private bool) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil
+	}
+	user.Private = private
+	r.store.users[id] = user
+
+	return nil
+}
+
+func (r *userMemoryRepository) SetRole(id uint, role string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil
+	}
+	user.Role = role
+	r.store.users[id] = user
+
+	return nil
+}
+
+func stripUser(user models.User) models.User {
+	user.Photo = nil
+	user.Comment = nil
+	user.Sosial = nil
+
+	return user
+}
+
+func userField(user models.User, field string) interface{} {
+	switch field {
+	case "id":
+		return user.Id
+	case "username":
+		return user.Username
+	case "created_at":
+		return user.CreatedAt
+	case "updated_at":
+		return user.UpdatedAt
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package repositories
+
+import (
+	"final-project-golang/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserRepository interface {
+	Create(user *models.User) error
+	FindById(id uint) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	List(query ListQuery) ([]models.User, int64, error)
+	Update(user *models.User, changes models.User) error
+	// Delete hides the user together with their photos, comments, social
+	// media and the comments on their photos until Restore or a purge.
+	Delete(user *models.User) error
+	FindDeletedByEmail(email string) (*models.User, error)
+	Restore(user *models.User) error
+	// PurgeDeleted hard deletes the users deleted before the given time.
+	PurgeDeleted(before time.Time) (int64, error)
+	SetTokensRevokedAt(id uint, at time.Time) error
+	SetDisabledAt(id uint, at *time.Time) error
+	SetPassword(id uint, hash string, at time.Time) error
+	SetEmailVerifiedAt(id uint, at *time.Time) error
+	// SetTotp stores the TOTP secret and when it was enabled, forgetting the
+	// last used step. An empty secret turns two factor authentication off.
+	SetTotp(id uint, secret string, enabledAt *time.Time) error
+	// UseTotpStep returns ErrNotFound unless step is later than the last
+	// accepted one, so a code can't be replayed.
+	UseTotpStep(id uint, step int64) error
+	SetPrivate(id uint, private bool) error
+	SetRole(id uint, role string) error
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{
+		db: db,
+	}
+}
+
+func (r *userRepository) Create(user *models.User) error {
+	return translateError(r.db.Create(user).Error)
+}
+
+func (r *userRepository) FindById(id uint) (*models.User, error) {
+	var user models.User
+
+	err := r.db.First(&user, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+
+	err := r.db.First(&user, "email = ?", email).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) List(query ListQuery) ([]models.User, int64, error) {
+	var users []models.User
+
+	total, err := list(r.db, &models.User{}, &users, query, "users")
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	return users, total, nil
+}
+
+func (r *userRepository) Update(user *models.User, changes models.User) error {
+	if changes.Password != "" {
+		if err := changes.SetPassword(changes.Password, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return translateError(r.db.Model(user).Updates(changes).Error)
+}
+
+// Delete gives every hidden row the same deleted_at so Restore brings back
+// exactly what was deleted with the user.
+func (r *userRepository) Delete(user *models.User) error {
+	at := time.Now()
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Comments other users replied to, directly or further down, become
+		// tombstones so the replies stay in their threads. The rest go.
+		var replied []uint
+		err := tx.Raw(`WITH RECURSIVE ancestors AS (
+			SELECT parent_id AS id FROM comments WHERE parent_id IS NOT NULL AND user_id <> ? AND deleted_at IS NULL
+			UNION
+			SELECT comments.parent_id FROM comments JOIN ancestors ON comments.id = ancestors.id WHERE comments.parent_id IS NOT NULL
+		) SELECT id FROM ancestors`, user.Id).Scan(&replied).Error
+		if err != nil {
+			return err
+		}
+
+		photos := tx.Model(&models.Photo{}).Select("id").Where("user_id = ?", user.Id)
+		comments := tx.Model(&models.Comment{}).Where("user_id = ?", user.Id)
+		if len(replied) > 0 {
+			err = tx.Model(&models.Comment{}).
+				Where("user_id = ? AND id IN ? AND photo_id NOT IN (?) AND removed_at IS NULL", user.Id, replied, photos).
+				UpdateColumns(map[string]interface{}{
+					"message":    models.CommentDeletedMessage,
+					"removed_at": at,
+					"updated_at": at,
+				}).Error
+			if err != nil {
+				return err
+			}
+			comments = comments.Where("id NOT IN ?", replied)
+		}
+
+		err = tx.Model(&models.Comment{}).Where("id IN (?) OR photo_id IN (?)", comments.Select("id"), photos).UpdateColumn("deleted_at", at).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Photo{}).Where("user_id = ?", user.Id).UpdateColumn("deleted_at", at).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Social{}).Where("user_id = ?", user.Id).UpdateColumn("deleted_at", at).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.User{}).Where("id = ?", user.Id).UpdateColumn("deleted_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	user.DeletedAt = gorm.DeletedAt{Time: at, Valid: true}
+
+	return nil
+}
+
+func (r *userRepository) FindDeletedByEmail(email string) (*models.User, error) {
+	var user models.User
+
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&user, "email = ?", email).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) Restore(user *models.User) error {
+	at := user.DeletedAt.Time
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		photos := tx.Unscoped().Model(&models.Photo{}).Select("id").Where("user_id = ? AND deleted_at = ?", user.Id, at)
+		err := tx.Unscoped().Model(&models.Comment{}).
+			Where("deleted_at = ? AND (user_id = ? OR photo_id IN (?))", at, user.Id, photos).
+			UpdateColumn("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Model(&models.Photo{}).Where("user_id = ? AND deleted_at = ?", user.Id, at).UpdateColumn("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Model(&models.Social{}).Where("user_id = ? AND deleted_at = ?", user.Id, at).UpdateColumn("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&models.User{}).Where("id = ?", user.Id).UpdateColumn("deleted_at", nil).Error
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+
+	return nil
+}
+
+func (r *userRepository) PurgeDeleted(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("deleted_at < ?", before).Delete(&models.User{})
+
+	return result.RowsAffected, translateError(result.Error)
+}
+
+func (r *userRepository) SetTokensRevokedAt(id uint, at time.Time) error {
+	return translateError(r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("tokens_revoked_at", at).Error)
+}
+
+func (r *userRepository) SetDisabledAt(id uint, at *time.Time) error {
+	return translateError(r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("disabled_at", at).Error)
+}
+
+// SetPassword stores an already hashed password, bypassing the model hooks.
+func (r *userRepository) SetPassword(id uint, hash string, at time.Time) error {
+	err := r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"password":            hash,
+		"password_changed_at": at,
+	}).Error
+
+	return translateError(err)
+}
+
+func (r *userRepository) SetEmailVerifiedAt(id uint, at *time.Time) error {
+	return translateError(r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("email_verified_at", at).Error)
+}
+
+func (r *userRepository) SetTotp(id uint, secret string, enabledAt *time.Time) error {
+	err := r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"totp_secret":     secret,
+		"totp_enabled_at": enabledAt,
+		"totp_last_step":  0,
+	}).Error
+
+	return translateError(err)
+}
+
+func (r *userRepository) UseTotpStep(id uint, step int64) error {
+	result := r.db.Model(&models.User{}).Where("id = ? AND totp_last_step < ?", id, step).UpdateColumn("totp_last_step", step)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) SetPrivate(id uint, private bool) error {
+	return translateError(r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("private", private).Error)
+}
+
+func (r *userRepository) SetRole(id uint, role string) error {
+	return translateError(r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("role", role).Error)
+}
@@ -1,54 +1,206 @@
 package routes
 
 import (
+	"final-project-golang/config"
 	"final-project-golang/controllers"
-	"final-project-golang/database"
+	"final-project-golang/helpers"
+	"final-project-golang/mailer"
 	"final-project-golang/middlewares"
+	"final-project-golang/oidc"
+	"final-project-golang/ratelimit"
+	"final-project-golang/repositories"
+	"final-project-golang/services"
+	"final-project-golang/storage"
+	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func StartApp() *gin.Engine {
+// Dependencies are the values the router is built from. Repos may be the
+// gorm or the in-memory implementation.
+type Dependencies struct {
+	Repos          repositories.Repositories
+	Keyring        *helpers.Keyring
+	Storage        storage.Storage
+	PhotoProcessor *services.PhotoProcessor
+	Health         *controllers.HealthController
+	MaxUploadSize  int64
+	// RestoreWindow is how long deleted rows can be restored.
+	RestoreWindow time.Duration
+	// RateLimits throttles sign-in and writes; nil turns throttling off.
+	RateLimits *ratelimit.Limits
+	Mailer     mailer.Mailer
+	// PasswordResetTTL is how long a mailed reset token stays valid and
+	// PasswordResetURL the page the mail links to.
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+	// Verification sets how verification links are mailed and what
+	// unverified users can't post.
+	Verification config.VerificationConfig
+	TwoFactor    config.TwoFactorConfig
+	// OIDC holds the providers users can sign in with and OIDCStateTTL how
+	// long a sign-in may take.
+	OIDC         *oidc.Providers
+	OIDCStateTTL time.Duration
+	// TrustedProxies may set the client IP with X-Forwarded-For; nil
+	// trusts none.
+	TrustedProxies []string
+}
+
+// NewRouter registers every route on top of deps.
+func NewRouter(deps Dependencies) *gin.Engine {
 	router := gin.Default()
+	// The config validates the proxies, so an error here falls back to
+	// trusting none rather than every peer, gin's default.
+	if err := router.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		log.Printf("routes: ignoring trusted proxies: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+	repos, store := deps.Repos, deps.Storage
+
+	var limits ratelimit.Limits
+	if deps.RateLimits != nil {
+		limits = *deps.RateLimits
+	}
+
+	userService := services.NewUserService(repos, limits.Lockout, time.Duration(deps.TwoFactor.ChallengeTTL))
+	photoService := services.NewPhotoService(repos, store, deps.PhotoProcessor)
+	commentService := services.NewCommentService(repos)
+	socialService := services.NewSocialService(repos)
+	followService := services.NewFollowService(repos)
+	likeService := services.NewLikeService(repos)
+	mediaService := services.NewMediaService(repos, store)
+	restoreService := services.NewRestoreService(repos, deps.RestoreWindow)
+	auditService := services.NewAuditService(repos)
+	verificationService := services.NewVerificationService(repos, deps.Mailer, time.Duration(deps.Verification.TTL), deps.Verification.URL)
+	passwordService := services.NewPasswordService(repos, userService, deps.Mailer, deps.PasswordResetTTL, deps.PasswordResetURL)
+	twoFactorService := services.NewTwoFactorService(repos, userService, deps.TwoFactor.Issuer)
+	oidcService := services.NewOIDCService(repos, userService, deps.OIDC, deps.OIDCStateTTL)
+	moderationService := services.NewModerationService(repos, userService, twoFactorService, photoService, commentService, socialService)
+
+	userController := controllers.NewUserController(userService, verificationService, auditService)
+	photoController := controllers.NewPhotoController(photoService, likeService, auditService, deps.MaxUploadSize)
+	commentController := controllers.NewCommentController(commentService, likeService, auditService)
+	socialController := controllers.NewSocialController(socialService, auditService)
+	followController := controllers.NewFollowController(followService)
+	likeController := controllers.NewLikeController(likeService)
+	mediaController := controllers.NewMediaController(mediaService)
+	keyController := controllers.NewKeyController(deps.Keyring)
+	adminController := controllers.NewAdminController(moderationService, auditService)
+	restoreController := controllers.NewRestoreController(restoreService, auditService)
+	auditController := controllers.NewAuditController(auditService)
+	passwordController := controllers.NewPasswordController(userService, passwordService, auditService)
+	verificationController := controllers.NewVerificationController(verificationService, auditService)
+	twoFactorController := controllers.NewTwoFactorController(twoFactorService)
+	oidcController := controllers.NewOIDCController(oidcService, auditService)
+	auth := middlewares.Auth(userService)
+	moderate := middlewares.Authorize(services.PermModerateContent)
+	manageUsers := middlewares.Authorize(services.PermManageUsers)
+	viewModeration := middlewares.Authorize(services.PermViewModeration)
+	viewAudit := middlewares.Authorize(services.PermViewAudit)
+	authLimit := middlewares.RateLimit(limits.Store, "auth", limits.Auth, middlewares.ByIP)
+	writeLimit := middlewares.RateLimit(limits.Store, "writes", limits.Writes, middlewares.ByUser)
+	verifiedPhotos := middlewares.RequireVerified(deps.Verification.Restricts("photos"))
+	verifiedComments := middlewares.RequireVerified(deps.Verification.Restricts("comments"))
 
-	db := database.ConnectDB()
-	userController := controllers.NewUserController(db)
-	photoController := controllers.NewPhotoController(db)
-	commentController := controllers.NewCommentController(db)
-	socialController := controllers.NewSocialController(db)
+	router.GET("/healthz", deps.Health.Healthz)
+	router.GET("/readyz", deps.Health.Readyz)
+	router.GET("/.well-known/jwks.json", keyController.JWKS)
+	router.GET("/media/*key", middlewares.OptionalAuth(userService), mediaController.Get)
+	router.GET("/feed", auth, photoController.Feed)
+
+	oidcGroup := router.Group("/auth/oidc")
+	{
+		oidcGroup.GET("/", oidcController.Providers)
+		oidcGroup.POST("/:provider/start", authLimit, oidcController.Start)
+		oidcGroup.POST("/:provider/callback", authLimit, oidcController.Callback)
+	}
 
 	userGroup := router.Group("/users")
 	{
-		userGroup.POST("/register", userController.Register)
-		userGroup.POST("/login", userController.Login)
-		userGroup.PUT("/", middlewares.Auth(), userController.Update)
-		userGroup.DELETE("/", middlewares.Auth(), userController.Delete)
+		userGroup.POST("/register", authLimit, userController.Register)
+		userGroup.POST("/login", authLimit, userController.Login)
+		userGroup.POST("/login/2fa", authLimit, twoFactorController.Login)
+		userGroup.POST("/refresh", authLimit, userController.Refresh)
+		userGroup.POST("/logout", auth, userController.Logout)
+		userGroup.POST("/restore", authLimit, restoreController.User)
+		userGroup.PUT("/password", auth, writeLimit, passwordController.Change)
+		userGroup.POST("/password/forgot", authLimit, passwordController.Forgot)
+		userGroup.POST("/password/reset", authLimit, passwordController.Reset)
+		userGroup.POST("/verify", authLimit, verificationController.Verify)
+		userGroup.POST("/verify/resend", auth, authLimit, verificationController.Resend)
+		userGroup.GET("/2fa", auth, twoFactorController.Status)
+		userGroup.POST("/2fa/enroll", auth, writeLimit, twoFactorController.Enroll)
+		userGroup.POST("/2fa/confirm", auth, writeLimit, twoFactorController.Confirm)
+		userGroup.POST("/2fa/recovery-codes", auth, writeLimit, twoFactorController.RecoveryCodes)
+		userGroup.DELETE("/2fa", auth, writeLimit, twoFactorController.Disable)
+		userGroup.GET("/identities", auth, oidcController.Identities)
+		userGroup.DELETE("/identities/:identityId", auth, writeLimit, oidcController.Unlink)
+		userGroup.GET("/", auth, userController.Get)
+		userGroup.GET("/:userId", auth, userController.GetById)
+		userGroup.PUT("/", auth, writeLimit, userController.Update)
+		userGroup.DELETE("/", auth, writeLimit, userController.Delete)
+		userGroup.POST("/:userId/follow", auth, writeLimit, followController.Follow)
+		userGroup.DELETE("/:userId/follow", auth, writeLimit, followController.Unfollow)
+		userGroup.GET("/:userId/followers", auth, followController.Followers)
+		userGroup.GET("/:userId/following", auth, followController.Following)
+		userGroup.GET("/follow-requests", auth, followController.Requests)
+		userGroup.POST("/follow-requests/:userId/approve", auth, writeLimit, followController.Approve)
+		userGroup.DELETE("/follow-requests/:userId", auth, writeLimit, followController.Reject)
 	}
 
 	photoGroup := router.Group("/photos")
 	{
-		photoGroup.POST("/", middlewares.Auth(), photoController.Create)
-		photoGroup.GET("/", middlewares.Auth(), photoController.Get)
-		photoGroup.PUT("/:photoId", middlewares.Auth(), photoController.Update)
-		photoGroup.DELETE("/:photoId", middlewares.Auth(), photoController.Delete)
+		photoGroup.POST("/", auth, verifiedPhotos, writeLimit, photoController.Create)
+		photoGroup.GET("/", auth, photoController.Get)
+		photoGroup.GET("/:photoId", auth, photoController.GetById)
+		photoGroup.PUT("/:photoId", auth, writeLimit, photoController.Update)
+		photoGroup.DELETE("/:photoId", auth, writeLimit, photoController.Delete)
+		photoGroup.POST("/:photoId/restore", auth, writeLimit, restoreController.Photo)
+		photoGroup.POST("/:photoId/like", auth, writeLimit, likeController.LikePhoto)
+		photoGroup.DELETE("/:photoId/like", auth, writeLimit, likeController.UnlikePhoto)
+		photoGroup.GET("/:photoId/likes", auth, likeController.PhotoLikers)
+		photoGroup.GET("/:photoId/comments", auth, commentController.Thread)
 	}
 
 	commentGroup := router.Group("/comments")
 	{
-		commentGroup.POST("/", middlewares.Auth(), commentController.Create)
-		commentGroup.GET("/", middlewares.Auth(), commentController.Get)
-		commentGroup.PUT("/:commentId", middlewares.Auth(), commentController.Update)
-		commentGroup.DELETE("/:commentId", middlewares.Auth(), commentController.Delete)
+		commentGroup.POST("/", auth, verifiedComments, writeLimit, commentController.Create)
+		commentGroup.GET("/", auth, commentController.Get)
+		commentGroup.GET("/:commentId", auth, commentController.GetById)
+		commentGroup.PUT("/:commentId", auth, writeLimit, commentController.Update)
+		commentGroup.DELETE("/:commentId", auth, writeLimit, commentController.Delete)
+		commentGroup.POST("/:commentId/restore", auth, writeLimit, restoreController.Comment)
+		commentGroup.POST("/:commentId/like", auth, writeLimit, likeController.LikeComment)
+		commentGroup.DELETE("/:commentId/like", auth, writeLimit, likeController.UnlikeComment)
+		commentGroup.GET("/:commentId/likes", auth, likeController.CommentLikers)
 	}
 
 	socialGroup := router.Group("/socialmedias")
 	{
-		socialGroup.POST("/", middlewares.Auth(), socialController.Create)
-		socialGroup.GET("/", middlewares.Auth(), socialController.Get)
-		socialGroup.PUT("/:socialMediaId", middlewares.Auth(), socialController.Update)
-		socialGroup.DELETE("/:socialMediaId", middlewares.Auth(), socialController.Delete)
+		socialGroup.POST("/", auth, writeLimit, socialController.Create)
+		socialGroup.GET("/", auth, socialController.Get)
+		socialGroup.GET("/:socialMediaId", auth, socialController.GetById)
+		socialGroup.PUT("/:socialMediaId", auth, writeLimit, socialController.Update)
+		socialGroup.DELETE("/:socialMediaId", auth, writeLimit, socialController.Delete)
+		socialGroup.POST("/:socialMediaId/restore", auth, writeLimit, restoreController.Social)
 	}
 
-	return router
+	adminGroup := router.Group("/admin", auth)
+	{
+		adminGroup.GET("/users", manageUsers, adminController.Users)
+		adminGroup.POST("/users/:userId/disable", manageUsers, adminController.DisableUser)
+		adminGroup.POST("/users/:userId/enable", manageUsers, adminController.EnableUser)
+		adminGroup.PUT("/users/:userId/role", manageUsers, adminController.SetRole)
+		adminGroup.DELETE("/users/:userId/2fa", manageUsers, adminController.ResetTwoFactor)
+		adminGroup.DELETE("/photos/:photoId", moderate, adminController.DeletePhoto)
+		adminGroup.DELETE("/comments/:commentId", moderate, adminController.DeleteComment)
+		adminGroup.DELETE("/socialmedias/:socialMediaId", moderate, adminController.DeleteSocial)
+		adminGroup.GET("/moderation", viewModeration, adminController.History)
+		adminGroup.GET("/audit", viewAudit, auditController.Get)
+		adminGroup.GET("/audit/export", viewAudit, auditController.Export)
+	}
 
+	return router
 }
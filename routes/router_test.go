@@ -0,0 +1,504 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"final-project-golang/config"
+	"final-project-golang/controllers"
+	"final-project-golang/helpers"
+	"final-project-golang/mailer"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/storage"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	repos  repositories.Repositories
+	outbox *outbox
+}
+
+// outbox keeps the mail the server sends.
+type outbox struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (o *outbox) Send(message mailer.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+// waitFor returns the first message to the address, waiting for mail sent
+// in the background.
+func (o *outbox) waitFor(t *testing.T, to string) mailer.Message {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		o.mu.Lock()
+		for _, message := range o.messages {
+			if message.To == to {
+				o.mu.Unlock()
+				return message
+			}
+		}
+		o.mu.Unlock()
+	}
+	t.Fatalf("no mail to %s", to)
+
+	return mailer.Message{}
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	return newTestServerWith(t, func(*Dependencies) {})
+}
+
+// newTestServerWith lets configure change the dependencies before the
+// router is built.
+func newTestServerWith(t *testing.T, configure func(*Dependencies)) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	keyring := helpers.NewHMACKeyring("test-secret")
+	helpers.InitToken(keyring, time.Minute, time.Hour)
+
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	mail := &outbox{}
+	deps := Dependencies{
+		Repos:         repos,
+		Keyring:       keyring,
+		Storage:       storage.NewMemory("/media"),
+		Health:        controllers.NewHealthController(nil),
+		MaxUploadSize: 1 << 20,
+		RestoreWindow: time.Hour,
+		Mailer:        mail,
+
+		PasswordResetTTL: time.Hour,
+	}
+	configure(&deps)
+	router := NewRouter(deps)
+
+	return &testServer{t: t, router: router, repos: repos, outbox: mail}
+}
+
+func (s *testServer) do(method, path, token string, body interface{}) (int, map[string]interface{}) {
+	s.t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	out := map[string]interface{}{}
+	json.Unmarshal(w.Body.Bytes(), &out)
+
+	return w.Code, out
+}
+
+func (s *testServer) expect(method, path, token string, body interface{}, want int) map[string]interface{} {
+	s.t.Helper()
+
+	code, out := s.do(method, path, token, body)
+	if code != want {
+		s.t.Fatalf("%s %s: got %d want %d: %v", method, path, code, want, out)
+	}
+
+	return out
+}
+
+// register signs up name and returns its id and an access token.
+func (s *testServer) register(name string) (uint, string) {
+	s.t.Helper()
+
+	out := s.expect(http.MethodPost, "/users/register", "", map[string]interface{}{
+		"age": 20, "email": name + "@example.com", "password": "secret123", "username": name,
+	}, http.StatusCreated)
+	id := uint(out["id"].(float64))
+
+	out = s.expect(http.MethodPost, "/users/login", "", map[string]interface{}{
+		"email": name + "@example.com", "password": "secret123",
+	}, http.StatusOK)
+	token, _ := out["token"].(string)
+
+	return id, token
+}
+
+func (s *testServer) createPhoto(token, title string) uint {
+	s.t.Helper()
+
+	out := s.expect(http.MethodPost, "/photos/", token, map[string]interface{}{
+		"title": title, "photo_url": "http://example.com/" + title + ".jpg",
+	}, http.StatusCreated)
+
+	return uint(out["id"].(float64))
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newTestServer(t)
+	s.register("alice")
+
+	s.expect(http.MethodPost, "/users/register", "", map[string]interface{}{
+		"age": 20, "email": "other@example.com", "password": "secret123", "username": "alice",
+	}, http.StatusConflict)
+	s.expect(http.MethodPost, "/users/login", "", map[string]interface{}{
+		"email": "alice@example.com", "password": "wrong",
+	}, http.StatusUnauthorized)
+	s.expect(http.MethodGet, "/photos/", "", nil, http.StatusUnauthorized)
+}
+
+func TestPhotoOwnership(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.register("alice")
+	_, bob := s.register("bob")
+	photoId := s.createPhoto(alice, "sunset")
+	path := fmt.Sprintf("/photos/%d", photoId)
+
+	update := map[string]interface{}{"title": "dusk", "photo_url": "http://example.com/dusk.jpg"}
+	s.expect(http.MethodPut, path, bob, update, http.StatusForbidden)
+	s.expect(http.MethodDelete, path, bob, nil, http.StatusForbidden)
+
+	out := s.expect(http.MethodPut, path, alice, update, http.StatusOK)
+	if out["title"] != "dusk" {
+		t.Fatalf("title = %v, want dusk", out["title"])
+	}
+
+	s.expect(http.MethodGet, "/photos/999", alice, nil, http.StatusNotFound)
+}
+
+func TestPhotoListPagination(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.register("alice")
+	for _, title := range []string{"a", "b", "c", "d", "e"} {
+		s.createPhoto(alice, title)
+	}
+
+	out := s.expect(http.MethodGet, "/photos/?limit=2&sort=title:desc", alice, nil, http.StatusOK)
+	data := out["data"].([]interface{})
+	if len(data) != 2 || data[0].(map[string]interface{})["title"] != "e" {
+		t.Fatalf("first page = %v", data)
+	}
+	if total := out["meta"].(map[string]interface{})["total"]; total != float64(5) {
+		t.Fatalf("total = %v, want 5", total)
+	}
+
+	out = s.expect(http.MethodGet, "/photos/?limit=2&after=4", alice, nil, http.StatusOK)
+	data = out["data"].([]interface{})
+	if len(data) != 1 || data[0].(map[string]interface{})["id"] != float64(5) {
+		t.Fatalf("page after 4 = %v", data)
+	}
+
+	s.expect(http.MethodGet, "/photos/?sort=caption", alice, nil, http.StatusBadRequest)
+}
+
+func TestCommentOnPhoto(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.register("alice")
+	_, bob := s.register("bob")
+	photoId := s.createPhoto(alice, "sunset")
+
+	s.expect(http.MethodPost, "/comments/", bob, map[string]interface{}{"message": "nice", "photo_id": photoId}, http.StatusCreated)
+	s.expect(http.MethodPost, "/comments/", bob, map[string]interface{}{"message": "nice", "photo_id": 999}, http.StatusNotFound)
+
+	out := s.expect(http.MethodGet, fmt.Sprintf("/photos/%d", photoId), alice, nil, http.StatusOK)
+	if comments := out["comments"].([]interface{}); len(comments) != 1 {
+		t.Fatalf("comments = %v, want one", comments)
+	}
+}
+
+func TestPhotoDeleteAndRestore(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.register("alice")
+	_, bob := s.register("bob")
+	photoId := s.createPhoto(alice, "sunset")
+	path := fmt.Sprintf("/photos/%d", photoId)
+
+	s.expect(http.MethodDelete, path, alice, nil, http.StatusOK)
+	s.expect(http.MethodGet, path, alice, nil, http.StatusNotFound)
+
+	if _, err := s.repos.Photos.FindDeleted(photoId); err != nil {
+		t.Fatalf("deleted photo is gone from the repository: %v", err)
+	}
+
+	s.expect(http.MethodPost, path+"/restore", bob, nil, http.StatusNotFound)
+	s.expect(http.MethodPost, path+"/restore", alice, nil, http.StatusOK)
+	s.expect(http.MethodGet, path, alice, nil, http.StatusOK)
+}
+
+func TestPrivateAccountPhotos(t *testing.T) {
+	s := newTestServer(t)
+	aliceId, alice := s.register("alice")
+	bobId, bob := s.register("bob")
+	_, carol := s.register("carol")
+	photoId := s.createPhoto(alice, "sunset")
+	path := fmt.Sprintf("/photos/%d", photoId)
+
+	s.expect(http.MethodPost, "/comments/", alice, map[string]interface{}{"message": "mine", "photo_id": photoId}, http.StatusCreated)
+	s.expect(http.MethodPut, "/users/", alice, map[string]interface{}{"private": true}, http.StatusOK)
+
+	// Bob follows and is approved, Carol doesn't follow.
+	s.expect(http.MethodPost, fmt.Sprintf("/users/%d/follow", aliceId), bob, nil, http.StatusOK)
+	s.expect(http.MethodPost, fmt.Sprintf("/users/follow-requests/%d/approve", bobId), alice, nil, http.StatusOK)
+
+	for _, viewer := range []string{alice, bob} {
+		s.expect(http.MethodGet, path, viewer, nil, http.StatusOK)
+		s.expect(http.MethodGet, path+"/comments", viewer, nil, http.StatusOK)
+		s.expect(http.MethodGet, path+"/likes", viewer, nil, http.StatusOK)
+	}
+
+	s.expect(http.MethodGet, path, carol, nil, http.StatusForbidden)
+	s.expect(http.MethodGet, path+"/comments", carol, nil, http.StatusForbidden)
+	s.expect(http.MethodGet, path+"/likes", carol, nil, http.StatusForbidden)
+	s.expect(http.MethodPost, path+"/like", carol, nil, http.StatusForbidden)
+	s.expect(http.MethodPost, "/comments/", carol, map[string]interface{}{"message": "hi", "photo_id": photoId}, http.StatusForbidden)
+	s.expect(http.MethodGet, "/comments/1", carol, nil, http.StatusForbidden)
+
+	lists := map[string]float64{
+		fmt.Sprintf("/photos/?user_id=%d", aliceId): 1,
+		"/comments/": 1,
+	}
+	for list, visible := range lists {
+		for viewer, want := range map[string]float64{bob: visible, carol: 0} {
+			out := s.expect(http.MethodGet, list, viewer, nil, http.StatusOK)
+			if total := out["meta"].(map[string]interface{})["total"]; total != want {
+				t.Errorf("GET %s: total %v, want %v", list, total, want)
+			}
+		}
+	}
+}
+
+func TestAccountDeletionKeepsReplies(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.register("alice")
+	_, bob := s.register("bob")
+	_, carol := s.register("carol")
+	photoId := s.createPhoto(carol, "sunset")
+	path := fmt.Sprintf("/photos/%d/comments", photoId)
+
+	out := s.expect(http.MethodPost, "/comments/", alice, map[string]interface{}{"message": "first", "photo_id": photoId}, http.StatusCreated)
+	parentId := out["id"]
+	s.expect(http.MethodPost, "/comments/", bob, map[string]interface{}{"message": "reply", "photo_id": photoId, "parent_id": parentId}, http.StatusCreated)
+	s.expect(http.MethodPost, "/comments/", alice, map[string]interface{}{"message": "unanswered", "photo_id": photoId}, http.StatusCreated)
+
+	s.expect(http.MethodDelete, "/users/", alice, nil, http.StatusOK)
+
+	check := func(when string) {
+		t.Helper()
+
+		out := s.expect(http.MethodGet, path, carol, nil, http.StatusOK)
+		data := out["data"].([]interface{})
+		if len(data) != 1 {
+			t.Fatalf("%s: thread = %v, want only the replied comment", when, data)
+		}
+		parent := data[0].(map[string]interface{})
+		if parent["deleted"] != true || parent["message"] != "[deleted]" {
+			t.Fatalf("%s: parent = %v, want a tombstone", when, parent)
+		}
+		replies := parent["replies"].([]interface{})
+		if len(replies) != 1 || replies[0].(map[string]interface{})["message"] != "reply" {
+			t.Fatalf("%s: replies = %v, want bob's reply", when, replies)
+		}
+	}
+	check("after delete")
+
+	later := time.Now().Add(time.Hour)
+	if _, err := s.repos.Comments.PurgeDeleted(later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.repos.Users.PurgeDeleted(later); err != nil {
+		t.Fatal(err)
+	}
+	check("after purge")
+}
+
+func TestModeratorDeleteIsPermanent(t *testing.T) {
+	s := newTestServer(t)
+	modId, mod := s.register("mod")
+	_, alice := s.register("alice")
+	if err := s.repos.Users.SetRole(modId, models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	photoId := s.createPhoto(alice, "sunset")
+	path := fmt.Sprintf("/photos/%d", photoId)
+
+	s.expect(http.MethodDelete, fmt.Sprintf("/admin/photos/%d", photoId), mod, map[string]interface{}{"reason": "spam"}, http.StatusOK)
+	s.expect(http.MethodGet, path, alice, nil, http.StatusNotFound)
+	s.expect(http.MethodPost, path+"/restore", alice, nil, http.StatusNotFound)
+}
+
+func TestRestoreAndModerationAreAudited(t *testing.T) {
+	s := newTestServer(t)
+	adminId, admin := s.register("admin")
+	aliceId, alice := s.register("alice")
+	if err := s.repos.Users.SetRole(adminId, models.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	photoId := s.createPhoto(alice, "sunset")
+	spamId := s.createPhoto(alice, "spam")
+
+	s.expect(http.MethodDelete, fmt.Sprintf("/photos/%d", photoId), alice, nil, http.StatusOK)
+	s.expect(http.MethodPost, fmt.Sprintf("/photos/%d/restore", photoId), alice, nil, http.StatusOK)
+	s.expect(http.MethodDelete, fmt.Sprintf("/admin/photos/%d", spamId), admin, nil, http.StatusOK)
+	s.expect(http.MethodPost, fmt.Sprintf("/admin/users/%d/disable", aliceId), admin, nil, http.StatusOK)
+
+	entry := func(query string) map[string]interface{} {
+		t.Helper()
+
+		out := s.expect(http.MethodGet, "/admin/audit?"+query, admin, nil, http.StatusOK)
+		data := out["data"].([]interface{})
+		if len(data) != 1 {
+			t.Fatalf("audit %s: %v, want one entry", query, data)
+		}
+		return data[0].(map[string]interface{})
+	}
+
+	restored := entry(fmt.Sprintf("action=restore&resource_id=%d", photoId))
+	if restored["resource_type"] != "photo" || restored["actor_id"] != float64(aliceId) {
+		t.Errorf("restore entry = %v", restored)
+	}
+
+	deleted := entry(fmt.Sprintf("action=delete&actor_id=%d", adminId))
+	if deleted["resource_id"] != float64(spamId) {
+		t.Errorf("moderator delete entry = %v", deleted)
+	}
+
+	disabled := entry(fmt.Sprintf("action=update&actor_id=%d&resource_type=user", adminId))
+	changes := disabled["changes"].(map[string]interface{})
+	if change, ok := changes["disabled"].(map[string]interface{}); !ok || change["from"] != false || change["to"] != true {
+		t.Errorf("disable changes = %v", changes)
+	}
+}
+
+func TestClientIPFromTrustedProxiesOnly(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		{"no proxies", nil, "192.0.2.1"},
+		{"trusted peer", []string{"192.0.2.0/24"}, "203.0.113.9"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServerWith(t, func(deps *Dependencies) {
+				deps.TrustedProxies = tc.proxies
+			})
+
+			body := strings.NewReader(`{"age": 20, "email": "alice@example.com", "password": "secret123", "username": "alice"}`)
+			req := httptest.NewRequest(http.MethodPost, "/users/register", body)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("register: %d %s", w.Code, w.Body)
+			}
+
+			entries, _, err := s.repos.Audit.List(repositories.ListQuery{Pagination: helpers.Pagination{Page: 1, Limit: 10, SortField: "id"}}, repositories.AuditFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Ip != tc.want {
+				t.Fatalf("audit entries = %+v, want one from %s", entries, tc.want)
+			}
+		})
+	}
+}
+
+func TestPasswordForgotAnswersTheSame(t *testing.T) {
+	s := newTestServer(t)
+	s.register("alice")
+	s.outbox.mu.Lock()
+	s.outbox.messages = nil
+	s.outbox.mu.Unlock()
+
+	for _, email := range []string{"nobody@example.com", "alice@example.com"} {
+		out := s.expect(http.MethodPost, "/users/password/forgot", "", map[string]interface{}{"email": email}, http.StatusAccepted)
+		if out["message"] != "If the email belongs to an account, a reset link has been sent to it" {
+			t.Fatalf("forgot %s: %v", email, out)
+		}
+	}
+
+	message := s.outbox.waitFor(t, "alice@example.com")
+	_, token, ok := strings.Cut(message.Body, "Your reset token is ")
+	if !ok {
+		t.Fatalf("reset mail = %q", message.Body)
+	}
+	token, _, _ = strings.Cut(token, "\n")
+
+	s.expect(http.MethodPost, "/users/password/reset", "", map[string]interface{}{"token": token, "password": "newsecret123"}, http.StatusOK)
+	s.expect(http.MethodPost, "/users/login", "", map[string]interface{}{"email": "alice@example.com", "password": "newsecret123"}, http.StatusOK)
+
+	s.outbox.mu.Lock()
+	defer s.outbox.mu.Unlock()
+	for _, message := range s.outbox.messages {
+		if message.To == "nobody@example.com" {
+			t.Fatal("mailed an unknown email")
+		}
+	}
+}
+
+func TestTwoFactorChallengeIsSingleUse(t *testing.T) {
+	s := newTestServerWith(t, func(deps *Dependencies) {
+		deps.TwoFactor = config.TwoFactorConfig{Issuer: "Test", ChallengeTTL: config.Duration(time.Minute)}
+	})
+	_, alice := s.register("alice")
+
+	out := s.expect(http.MethodPost, "/users/2fa/enroll", alice, nil, http.StatusOK)
+	code, err := helpers.TotpCode(out["secret"].(string), helpers.TotpStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = s.expect(http.MethodPost, "/users/2fa/confirm", alice, map[string]interface{}{"code": code}, http.StatusOK)
+	var codes []string
+	for _, code := range out["recovery_codes"].([]interface{}) {
+		codes = append(codes, code.(string))
+	}
+
+	login := func() string {
+		out := s.expect(http.MethodPost, "/users/login", "", map[string]interface{}{"email": "alice@example.com", "password": "secret123"}, http.StatusOK)
+		return out["challenge_token"].(string)
+	}
+	complete := func(challenge, code string, want int) {
+		t.Helper()
+		s.expect(http.MethodPost, "/users/login/2fa", "", map[string]interface{}{"challenge_token": challenge, "code": code}, want)
+	}
+
+	// Without a lockout, the challenge still runs out of attempts.
+	challenge := login()
+	for i := 0; i < 5; i++ {
+		complete(challenge, "000000", http.StatusUnauthorized)
+	}
+	complete(challenge, codes[0], http.StatusUnauthorized)
+
+	challenge = login()
+	complete(challenge, codes[0], http.StatusOK)
+	complete(challenge, codes[1], http.StatusUnauthorized)
+}
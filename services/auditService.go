@@ -0,0 +1,159 @@
+package services
+
+import (
+	"encoding/json"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"reflect"
+)
+
+// auditExportBatch is how many entries Export reads per query.
+const auditExportBatch = 100
+
+// auditIgnored lists fields never written to a diff: secrets and timestamps
+// that change on every write.
+var auditIgnored = map[string]bool{
+	"password":   true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// AuditActor is who made a change and where the request came from. A zero
+// UserId records an anonymous change.
+type AuditActor struct {
+	UserId    uint
+	Ip        string
+	UserAgent string
+}
+
+// AuditChange is the old and new value of one field.
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type AuditService struct {
+	repos repositories.Repositories
+}
+
+func NewAuditService(repos repositories.Repositories) *AuditService {
+	return &AuditService{
+		repos: repos,
+	}
+}
+
+// Record stores what changed between before and after. before is nil for a
+// create and after is nil for a delete.
+func (s *AuditService) Record(actor AuditActor, action, resourceType string, resourceId uint, before, after interface{}) error {
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+
+	entry := models.AuditEntry{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceId:   resourceId,
+		Changes:      changes,
+		Ip:           actor.Ip,
+		UserAgent:    actor.UserAgent,
+	}
+	if actor.UserId != 0 {
+		entry.ActorId = &actor.UserId
+	}
+
+	return s.repos.Audit.Create(&entry)
+}
+
+func (s *AuditService) List(query repositories.ListQuery, filter repositories.AuditFilter) ([]models.AuditEntry, int64, error) {
+	return s.repos.Audit.List(query, filter)
+}
+
+// Export calls fn with every entry matching the filters, oldest first.
+func (s *AuditService) Export(filter helpers.Filter, auditFilter repositories.AuditFilter, fn func(models.AuditEntry) error) error {
+	query := repositories.ListQuery{
+		Pagination: helpers.Pagination{Page: 1, Limit: auditExportBatch, SortField: "id"},
+		Filter:     filter,
+	}
+
+	for {
+		entries, _, err := s.repos.Audit.List(query, auditFilter)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+
+		if len(entries) < auditExportBatch {
+			return nil
+		}
+		query.Pagination.After = entries[len(entries)-1].Id
+	}
+}
+
+// auditDiff returns a JSON object of every field whose value differs between
+// before and after.
+func auditDiff(before, after interface{}) (string, error) {
+	from, err := auditFields(before)
+	if err != nil {
+		return "", err
+	}
+	to, err := auditFields(after)
+	if err != nil {
+		return "", err
+	}
+
+	changes := make(map[string]AuditChange)
+	for field, value := range from {
+		if !reflect.DeepEqual(value, to[field]) {
+			changes[field] = AuditChange{From: value, To: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok && value != nil {
+			changes[field] = AuditChange{From: nil, To: value}
+		}
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+// auditFields flattens a model into its JSON fields, leaving out ignored
+// fields and nested records.
+func auditFields(record interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if record == nil {
+		return fields, nil
+	}
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+
+	for field, value := range fields {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			delete(fields, field)
+			continue
+		}
+		if auditIgnored[field] {
+			delete(fields, field)
+		}
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"time"
+)
+
+// CommentThread is a comment with its replies, oldest first.
+type CommentThread struct {
+	Comment models.Comment
+	Replies []CommentThread
+}
+
+type CommentService struct {
+	repos repositories.Repositories
+}
+
+func NewCommentService(repos repositories.Repositories) *CommentService {
+	return &CommentService{
+		repos: repos,
+	}
+}
+
+func (s *CommentService) Create(comment *models.Comment) error {
+	// The foreign key alone would accept a deleted photo.
+	photo, err := s.repos.Photos.FindById(comment.PhotoId)
+	if err != nil {
+		return notFoundAs(err, repositories.ErrPhotoNotFound)
+	}
+	if err := checkPhotoVisible(s.repos, comment.UserId, photo, repositories.ErrPhotoNotFound); err != nil {
+		return err
+	}
+
+	if comment.ParentId != nil {
+		parent, err := s.repos.Comments.FindById(*comment.ParentId)
+		if err != nil {
+			return notFoundAs(err, repositories.ErrParentCommentNotFound)
+		}
+		if parent.Removed() {
+			return repositories.ErrParentCommentNotFound
+		}
+		if parent.PhotoId != comment.PhotoId {
+			return apperrors.Validation(apperrors.FieldError{
+				Field:   "parent_id",
+				Rule:    "photo",
+				Message: "parent comment belongs to another photo",
+			})
+		}
+	}
+
+	return s.repos.Comments.Create(comment)
+}
+
+// Thread pages through the top-level comments of a photo and attaches all
+// of their replies.
+func (s *CommentService) Thread(viewerId, photoId uint, query repositories.ListQuery) ([]CommentThread, int64, error) {
+	photo, err := s.repos.Photos.FindById(photoId)
+	if err != nil {
+		return nil, 0, notFound(err, "photo not found")
+	}
+	if err := checkPhotoVisible(s.repos, viewerId, photo, apperrors.NotFound("photo not found")); err != nil {
+		return nil, 0, err
+	}
+
+	roots, total, err := s.repos.Comments.ListRoots(photoId, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uint, 0, len(roots))
+	for _, root := range roots {
+		ids = append(ids, root.Id)
+	}
+
+	replies, err := s.repos.Comments.ListDescendants(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	children := make(map[uint][]models.Comment)
+	for _, reply := range replies {
+		children[*reply.ParentId] = append(children[*reply.ParentId], reply)
+	}
+
+	threads := make([]CommentThread, 0, len(roots))
+	for _, root := range roots {
+		threads = append(threads, buildThread(root, children))
+	}
+
+	return threads, total, nil
+}
+
+// List leaves out comments on the photos of private accounts userId doesn't
+// follow.
+func (s *CommentService) List(userId uint, query repositories.ListQuery) ([]models.Comment, int64, error) {
+	return s.repos.Comments.List(userId, query)
+}
+
+func (s *CommentService) Get(viewerId, id uint) (*models.Comment, error) {
+	comment, err := s.repos.Comments.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "comment not found")
+	}
+	if err := checkCommentVisible(s.repos, viewerId, comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (s *CommentService) Update(userId, id uint, changes models.Comment) (*models.Comment, error) {
+	comment, err := s.repos.Comments.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "comment not found")
+	}
+
+	if comment.UserId != userId {
+		return nil, apperrors.Forbidden("you're not allowed to update or edit this comment")
+	}
+	if comment.Removed() {
+		return nil, apperrors.NotFound("comment not found")
+	}
+
+	if err := s.repos.Comments.Update(comment, changes); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (s *CommentService) Delete(userId, id uint) error {
+	comment, err := s.repos.Comments.FindById(id)
+	if err != nil {
+		return notFound(err, "comment not found")
+	}
+
+	if comment.UserId != userId {
+		return apperrors.Forbidden("you're not allowed to delete this comment")
+	}
+	if comment.Removed() {
+		return apperrors.NotFound("comment not found")
+	}
+
+	return s.remove(comment, s.repos.Comments.Delete)
+}
+
+// Remove permanently deletes any comment regardless of its author, or
+// tombstones it when it has replies. Unlike Delete it skips the restore
+// window so the author can't bring the comment back.
+func (s *CommentService) Remove(id uint) (*models.Comment, error) {
+	comment, err := s.repos.Comments.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "comment not found")
+	}
+	if comment.Removed() {
+		return nil, apperrors.NotFound("comment not found")
+	}
+	before := *comment
+
+	return &before, s.remove(comment, s.repos.Comments.Purge)
+}
+
+// remove deletes a comment without replies with del. One with replies
+// becomes a tombstone so the thread keeps its shape.
+func (s *CommentService) remove(comment *models.Comment, del func(*models.Comment) error) error {
+	replies, err := s.repos.Comments.CountReplies(comment.Id)
+	if err != nil {
+		return err
+	}
+	if replies > 0 {
+		return s.repos.Comments.Tombstone(comment, time.Now())
+	}
+
+	if err := del(comment); err != nil {
+		return err
+	}
+
+	return s.pruneTombstones(comment.ParentId)
+}
+
+// pruneTombstones deletes tombstoned ancestors left without replies.
+func (s *CommentService) pruneTombstones(parentId *uint) error {
+	for parentId != nil {
+		parent, err := s.repos.Comments.FindById(*parentId)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !parent.Removed() {
+			return nil
+		}
+
+		replies, err := s.repos.Comments.CountReplies(parent.Id)
+		if err != nil || replies > 0 {
+			return err
+		}
+		if err := s.repos.Comments.Delete(parent); err != nil {
+			return err
+		}
+
+		parentId = parent.ParentId
+	}
+
+	return nil
+}
+
+func buildThread(comment models.Comment, children map[uint][]models.Comment) CommentThread {
+	thread := CommentThread{
+		Comment: comment,
+		Replies: make([]CommentThread, 0, len(children[comment.Id])),
+	}
+	for _, reply := range children[comment.Id] {
+		thread.Replies = append(thread.Replies, buildThread(reply, children))
+	}
+
+	return thread
+}
+
+// checkCommentVisible checks that viewerId may see the photo comment is on.
+func checkCommentVisible(repos repositories.Repositories, viewerId uint, comment *models.Comment) error {
+	if comment.Photo == nil {
+		return apperrors.NotFound("comment not found")
+	}
+
+	return checkPhotoVisible(repos, viewerId, comment.Photo, apperrors.NotFound("comment not found"))
+}
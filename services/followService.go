@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"time"
+)
+
+var (
+	ErrFollowSelf            = apperrors.BadRequest("you can't follow yourself")
+	ErrNotFollowing          = apperrors.NotFound("you are not following this user")
+	ErrFollowRequestNotFound = apperrors.NotFound("follow request not found")
+	ErrPrivateAccount        = apperrors.Forbidden("this account is private")
+)
+
+type FollowService struct {
+	repos repositories.Repositories
+}
+
+func NewFollowService(repos repositories.Repositories) *FollowService {
+	return &FollowService{
+		repos: repos,
+	}
+}
+
+// Follow makes followerId follow followingId. Following a private account
+// creates a pending request instead. Following twice returns the existing
+// follow.
+func (s *FollowService) Follow(followerId, followingId uint) (*models.Follow, error) {
+	if followerId == followingId {
+		return nil, ErrFollowSelf
+	}
+
+	user, err := s.repos.Users.FindById(followingId)
+	if err != nil {
+		return nil, notFound(err, "user not found")
+	}
+
+	existing, err := s.repos.Follows.Find(followerId, followingId)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	follow := models.Follow{
+		FollowerId:  followerId,
+		FollowingId: followingId,
+	}
+	if !user.Private {
+		now := time.Now()
+		follow.AcceptedAt = &now
+	}
+
+	err = s.repos.Follows.Create(&follow)
+	if errors.Is(err, repositories.ErrDuplicateFollow) {
+		return s.repos.Follows.Find(followerId, followingId)
+	}
+	if err != nil {
+		return nil, notFound(err, "user not found")
+	}
+
+	return &follow, nil
+}
+
+// Unfollow removes a follow or withdraws a pending request.
+func (s *FollowService) Unfollow(followerId, followingId uint) error {
+	follow, err := s.repos.Follows.Find(followerId, followingId)
+	if err != nil {
+		return notFoundAs(err, ErrNotFollowing)
+	}
+
+	return s.repos.Follows.Delete(follow)
+}
+
+func (s *FollowService) Followers(viewerId, userId uint, query repositories.ListQuery) ([]models.Follow, int64, error) {
+	if err := checkVisible(s.repos, viewerId, userId); err != nil {
+		return nil, 0, err
+	}
+
+	return s.repos.Follows.ListFollowers(userId, false, query)
+}
+
+func (s *FollowService) Following(viewerId, userId uint, query repositories.ListQuery) ([]models.Follow, int64, error) {
+	if err := checkVisible(s.repos, viewerId, userId); err != nil {
+		return nil, 0, err
+	}
+
+	return s.repos.Follows.ListFollowing(userId, query)
+}
+
+// Requests lists the pending follow requests sent to userId.
+func (s *FollowService) Requests(userId uint, query repositories.ListQuery) ([]models.Follow, int64, error) {
+	return s.repos.Follows.ListFollowers(userId, true, query)
+}
+
+func (s *FollowService) Approve(userId, followerId uint) (*models.Follow, error) {
+	follow, err := s.pendingRequest(userId, followerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Follows.Accept(follow, time.Now()); err != nil {
+		return nil, notFoundAs(err, ErrFollowRequestNotFound)
+	}
+
+	return follow, nil
+}
+
+func (s *FollowService) Reject(userId, followerId uint) error {
+	follow, err := s.pendingRequest(userId, followerId)
+	if err != nil {
+		return err
+	}
+
+	return notFoundAs(s.repos.Follows.Delete(follow), ErrFollowRequestNotFound)
+}
+
+func (s *FollowService) pendingRequest(userId, followerId uint) (*models.Follow, error) {
+	follow, err := s.repos.Follows.Find(followerId, userId)
+	if err != nil {
+		return nil, notFoundAs(err, ErrFollowRequestNotFound)
+	}
+	if follow.Accepted() {
+		return nil, ErrFollowRequestNotFound
+	}
+
+	return follow, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+)
+
+var ErrNotLiked = apperrors.NotFound("you haven't liked this")
+
+type LikeService struct {
+	repos repositories.Repositories
+}
+
+func NewLikeService(repos repositories.Repositories) *LikeService {
+	return &LikeService{
+		repos: repos,
+	}
+}
+
+// Like records a like of userId on the target and returns its new count.
+// Liking twice is not an error.
+func (s *LikeService) Like(userId uint, target repositories.LikeTarget, targetId uint) (repositories.LikeCount, error) {
+	if err := s.checkTarget(userId, target, targetId); err != nil {
+		return repositories.LikeCount{}, err
+	}
+
+	like := models.Like{UserId: userId}
+	if target == repositories.LikePhoto {
+		like.PhotoId = &targetId
+	} else {
+		like.CommentId = &targetId
+	}
+
+	err := s.repos.Likes.Create(&like)
+	if err != nil && !errors.Is(err, repositories.ErrDuplicateLike) {
+		return repositories.LikeCount{}, err
+	}
+
+	return s.count(userId, target, targetId)
+}
+
+func (s *LikeService) Unlike(userId uint, target repositories.LikeTarget, targetId uint) (repositories.LikeCount, error) {
+	if err := s.checkTarget(userId, target, targetId); err != nil {
+		return repositories.LikeCount{}, err
+	}
+
+	err := s.repos.Likes.Delete(userId, target, targetId)
+	if err != nil {
+		return repositories.LikeCount{}, notFoundAs(err, ErrNotLiked)
+	}
+
+	return s.count(userId, target, targetId)
+}
+
+func (s *LikeService) Likers(viewerId uint, target repositories.LikeTarget, targetId uint, query repositories.ListQuery) ([]models.Like, int64, error) {
+	if err := s.checkTarget(viewerId, target, targetId); err != nil {
+		return nil, 0, err
+	}
+
+	return s.repos.Likes.ListByTarget(target, targetId, query)
+}
+
+// Counts returns the like count of each target id as seen by userId.
+// Targets without likes are missing from the map.
+func (s *LikeService) Counts(userId uint, target repositories.LikeTarget, targetIds []uint) (map[uint]repositories.LikeCount, error) {
+	return s.repos.Likes.Count(target, targetIds, userId)
+}
+
+func (s *LikeService) count(userId uint, target repositories.LikeTarget, targetId uint) (repositories.LikeCount, error) {
+	counts, err := s.Counts(userId, target, []uint{targetId})
+	if err != nil {
+		return repositories.LikeCount{}, err
+	}
+
+	return counts[targetId], nil
+}
+
+// checkTarget checks that the target exists and that userId may see it.
+// Tombstones of removed comments are only kept for their replies.
+func (s *LikeService) checkTarget(userId uint, target repositories.LikeTarget, targetId uint) error {
+	if target == repositories.LikePhoto {
+		photo, err := s.repos.Photos.FindById(targetId)
+		if err != nil {
+			return notFound(err, "photo not found")
+		}
+		return checkPhotoVisible(s.repos, userId, photo, apperrors.NotFound("photo not found"))
+	}
+
+	comment, err := s.repos.Comments.FindById(targetId)
+	if err != nil {
+		return notFound(err, "comment not found")
+	}
+	if comment.Removed() {
+		return apperrors.NotFound("comment not found")
+	}
+
+	return checkCommentVisible(s.repos, userId, comment)
+}
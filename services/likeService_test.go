@@ -0,0 +1,53 @@
+package services
+
+import (
+	"final-project-golang/apperrors"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"testing"
+)
+
+func TestLikeRemovedComment(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	likes := NewLikeService(repos)
+	comments := NewCommentService(repos)
+
+	user := models.User{Username: "alice", Email: "alice@example.com", Password: "secret123", Age: 20}
+	if err := repos.Users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	photo := &models.Photo{Title: "sunset", PhotoUrl: "http://example.com/sunset.jpg", UserId: user.Id}
+	if err := repos.Photos.Create(photo); err != nil {
+		t.Fatal(err)
+	}
+
+	parent := &models.Comment{Message: "first", PhotoId: photo.Id, UserId: user.Id}
+	if err := comments.Create(parent); err != nil {
+		t.Fatal(err)
+	}
+	reply := &models.Comment{Message: "second", PhotoId: photo.Id, UserId: user.Id, ParentId: &parent.Id}
+	if err := comments.Create(reply); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := likes.Like(user.Id, repositories.LikeComment, parent.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reply keeps the parent as a tombstone.
+	if err := comments.Delete(user.Id, parent.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := likes.Like(user.Id, repositories.LikeComment, parent.Id); !apperrors.HasCode(err, apperrors.CodeNotFound) {
+		t.Errorf("Like: got %v, want not found", err)
+	}
+	if _, err := likes.Unlike(user.Id, repositories.LikeComment, parent.Id); !apperrors.HasCode(err, apperrors.CodeNotFound) {
+		t.Errorf("Unlike: got %v, want not found", err)
+	}
+	if _, _, err := likes.Likers(user.Id, repositories.LikeComment, parent.Id, repositories.ListQuery{}); !apperrors.HasCode(err, apperrors.CodeNotFound) {
+		t.Errorf("Likers: got %v, want not found", err)
+	}
+	if _, err := likes.Like(user.Id, repositories.LikeComment, reply.Id); err != nil {
+		t.Errorf("Like reply: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/repositories"
+	"final-project-golang/storage"
+	"io"
+	"strings"
+)
+
+const (
+	// mediaPrefix holds the public renditions served at /media.
+	mediaPrefix = "photos"
+	// uploadPrefix holds raw uploads, which may still carry EXIF and GPS
+	// metadata and are never served.
+	uploadPrefix = "incoming"
+)
+
+var ErrMediaNotFound = apperrors.NotFound("media not found")
+
+// Media is an opened rendition of a photo. Public is false when it belongs
+// to a private account, so it must not be cached by shared caches nor reused
+// without asking again.
+type Media struct {
+	Body   io.ReadCloser
+	Object storage.Object
+	Public bool
+}
+
+type MediaService struct {
+	repos repositories.Repositories
+	store storage.Storage
+}
+
+func NewMediaService(repos repositories.Repositories, store storage.Storage) *MediaService {
+	return &MediaService{
+		repos: repos,
+		store: store,
+	}
+}
+
+// Open opens a rendition of a photo viewerId may see. viewerId is 0 for
+// anonymous requests, which only see the photos of public accounts. Files
+// of deleted photos are served until the purge.
+func (s *MediaService) Open(ctx context.Context, viewerId uint, key string) (*Media, error) {
+	if !strings.HasPrefix(key, mediaPrefix+"/") {
+		return nil, ErrMediaNotFound
+	}
+
+	photo, err := s.repos.Photos.FindByStorageKey(originalKey(key))
+	if err != nil {
+		return nil, notFoundAs(err, ErrMediaNotFound)
+	}
+	if err := checkVisible(s.repos, viewerId, photo.UserId); err != nil {
+		return nil, err
+	}
+
+	body, object, err := s.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return nil, apperrors.Wrap(err, apperrors.CodeNotFound, "media not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Media{
+		Body:   body,
+		Object: object,
+		Public: photo.User != nil && !photo.User.Private,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"final-project-golang/apperrors"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"fmt"
+)
+
+var ErrModerateSelf = apperrors.BadRequest("you can't moderate your own account")
+
+// ModerationService acts on other users' accounts and content on behalf of a
+// moderator or admin and records every action it takes. Content it deletes
+// is purged at once rather than soft deleted; the record is what remains.
+type ModerationService struct {
+	repos     repositories.Repositories
+	users     *UserService
+	twoFactor *TwoFactorService
+	photos    *PhotoService
+	comments  *CommentService
+	socials   *SocialService
+}
+
+func NewModerationService(repos repositories.Repositories, users *UserService, twoFactor *TwoFactorService, photos *PhotoService, comments *CommentService, socials *SocialService) *ModerationService {
+	return &ModerationService{
+		repos:     repos,
+		users:     users,
+		twoFactor: twoFactor,
+		photos:    photos,
+		comments:  comments,
+		socials:   socials,
+	}
+}
+
+// DeletePhoto returns the photo as it was before it was deleted.
+func (s *ModerationService) DeletePhoto(actorId, id uint, reason string) (*models.Photo, error) {
+	photo, err := s.photos.Remove(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return photo, s.record(actorId, models.ModerationDeletePhoto, "photo", id, reason, fmt.Sprintf("owner %d", photo.UserId))
+}
+
+// DeleteComment returns the comment as it was before it was deleted.
+func (s *ModerationService) DeleteComment(actorId, id uint, reason string) (*models.Comment, error) {
+	comment, err := s.comments.Remove(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, s.record(actorId, models.ModerationDeleteComment, "comment", id, reason, fmt.Sprintf("author %d", comment.UserId))
+}
+
+// DeleteSocial returns the social media as it was before it was deleted.
+func (s *ModerationService) DeleteSocial(actorId, id uint, reason string) (*models.Social, error) {
+	social, err := s.socials.Remove(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return social, s.record(actorId, models.ModerationDeleteSocial, "social", id, reason, fmt.Sprintf("owner %d", social.UserId))
+}
+
+// DisableUser, like the other account actions, returns the user before and
+// after the change.
+func (s *ModerationService) DisableUser(actorId, id uint, reason string) (*models.User, *models.User, error) {
+	return s.change(actorId, id, func(*models.User) error {
+		if err := s.users.Disable(id); err != nil {
+			return err
+		}
+		return s.record(actorId, models.ModerationDisableUser, "user", id, reason, "")
+	})
+}
+
+func (s *ModerationService) EnableUser(actorId, id uint, reason string) (*models.User, *models.User, error) {
+	return s.change(actorId, id, func(*models.User) error {
+		if err := s.users.Enable(id); err != nil {
+			return err
+		}
+		return s.record(actorId, models.ModerationEnableUser, "user", id, reason, "")
+	})
+}
+
+func (s *ModerationService) SetRole(actorId, id uint, role, reason string) (*models.User, *models.User, error) {
+	return s.change(actorId, id, func(user *models.User) error {
+		if err := s.users.SetRole(id, role); err != nil {
+			return err
+		}
+		return s.record(actorId, models.ModerationChangeRole, "user", id, reason, fmt.Sprintf("%s -> %s", user.Role, role))
+	})
+}
+
+// ResetTwoFactor turns two factor authentication off for a user who lost
+// their authenticator and recovery codes.
+func (s *ModerationService) ResetTwoFactor(actorId, id uint, reason string) (*models.User, *models.User, error) {
+	return s.change(actorId, id, func(user *models.User) error {
+		if !user.TwoFactorEnabled() && user.TotpSecret == "" {
+			return ErrTwoFactorNotEnabled
+		}
+		if err := s.twoFactor.Reset(id); err != nil {
+			return err
+		}
+		return s.record(actorId, models.ModerationResetTwoFactor, "user", id, reason, "")
+	})
+}
+
+// Users lists every account, disabled ones included.
+func (s *ModerationService) Users(query repositories.ListQuery) ([]models.User, int64, error) {
+	return s.repos.Users.List(query)
+}
+
+func (s *ModerationService) History(query repositories.ListQuery) ([]models.ModerationAction, int64, error) {
+	return s.repos.Moderation.List(query)
+}
+
+// target loads the user an account action applies to. Nobody may act on
+// their own account so an admin can't lock themselves out.
+func (s *ModerationService) target(actorId, id uint) (*models.User, error) {
+	if actorId == id {
+		return nil, ErrModerateSelf
+	}
+
+	return s.users.Get(id)
+}
+
+// change runs fn on the user an account action applies to and returns the
+// user before and after it.
+func (s *ModerationService) change(actorId, id uint, fn func(user *models.User) error) (*models.User, *models.User, error) {
+	before, err := s.target(actorId, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := fn(before); err != nil {
+		return nil, nil, err
+	}
+
+	after, err := s.users.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return before, after, nil
+}
+
+func (s *ModerationService) record(actorId uint, action, targetType string, targetId uint, reason, detail string) error {
+	return s.repos.Moderation.Create(&models.ModerationAction{
+		ActorId:    &actorId,
+		Action:     action,
+		TargetType: targetType,
+		TargetId:   targetId,
+		Reason:     reason,
+		Detail:     detail,
+	})
+}
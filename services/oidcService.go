@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/oidc"
+	"final-project-golang/repositories"
+	"log"
+	"time"
+)
+
+var (
+	ErrProviderNotFound         = apperrors.NotFound("login provider not found")
+	ErrInvalidLoginState        = apperrors.BadRequest("sign-in is invalid or has expired, start again")
+	ErrProviderLoginFailed      = apperrors.Unauthorized("the provider did not confirm your sign-in")
+	ErrProviderEmailNotVerified = apperrors.Forbidden("the provider has not verified your email address")
+	ErrNoLinkedAccount          = apperrors.NotFound("no account uses this email, register first")
+)
+
+// OIDCLogin is where to send a user to sign in with a provider. Binding
+// stays with the browser that started the sign-in and must come back with
+// the callback, so nobody can finish a sign-in they didn't start.
+type OIDCLogin struct {
+	URL       string
+	State     string
+	Binding   string
+	ExpiresAt time.Time
+}
+
+// OIDCService signs users in with OpenID Connect providers. A provider
+// account is linked to the user with the same email the first time it is
+// used, as long as both the provider and we have verified that email.
+// Accounts are never created here since registration needs more than a
+// provider tells.
+type OIDCService struct {
+	repos     repositories.Repositories
+	users     *UserService
+	providers *oidc.Providers
+	stateTTL  time.Duration
+}
+
+func NewOIDCService(repos repositories.Repositories, users *UserService, providers *oidc.Providers, stateTTL time.Duration) *OIDCService {
+	return &OIDCService{
+		repos:     repos,
+		users:     users,
+		providers: providers,
+		stateTTL:  stateTTL,
+	}
+}
+
+func (s *OIDCService) Providers() []string {
+	return s.providers.Names()
+}
+
+// Start remembers a new authorization request and returns the URL of the
+// provider's sign-in page.
+func (s *OIDCService) Start(ctx context.Context, providerName string) (OIDCLogin, error) {
+	provider, ok := s.providers.Get(providerName)
+	if !ok {
+		return OIDCLogin{}, ErrProviderNotFound
+	}
+
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		return OIDCLogin{}, err
+	}
+	url, err := provider.AuthURL(ctx, req)
+	if err != nil {
+		return OIDCLogin{}, err
+	}
+
+	now := time.Now()
+	if err := s.repos.Identities.PurgeExpiredStates(now); err != nil {
+		return OIDCLogin{}, err
+	}
+
+	state := models.LoginState{
+		StateHash:    helpers.HashToken(req.State),
+		Provider:     providerName,
+		Nonce:        req.Nonce,
+		CodeVerifier: req.Verifier,
+		ExpiresAt:    now.Add(s.stateTTL),
+	}
+	if err := s.repos.Identities.CreateState(&state); err != nil {
+		return OIDCLogin{}, err
+	}
+
+	return OIDCLogin{URL: url, State: req.State, Binding: state.StateHash, ExpiresAt: state.ExpiresAt}, nil
+}
+
+// Callback completes a sign-in with the code and state the provider sent the
+// user back with and the binding Start handed to their browser. It returns
+// the identity when it was linked just now.
+func (s *OIDCService) Callback(ctx context.Context, providerName, code, state, binding string) (LoginResult, *models.Identity, error) {
+	provider, ok := s.providers.Get(providerName)
+	if !ok {
+		return LoginResult{}, nil, ErrProviderNotFound
+	}
+
+	stored, err := s.repos.Identities.UseState(helpers.HashToken(state), time.Now())
+	if err != nil {
+		return LoginResult{}, nil, notFoundAs(err, ErrInvalidLoginState)
+	}
+	if stored.Provider != providerName {
+		return LoginResult{}, nil, ErrInvalidLoginState
+	}
+	// Without this, anyone could start a sign-in, stop at the redirect back
+	// and get someone else to post their code and state, signing that
+	// person into the wrong account.
+	if subtle.ConstantTimeCompare([]byte(binding), []byte(stored.StateHash)) != 1 {
+		return LoginResult{}, nil, ErrInvalidLoginState
+	}
+
+	claims, err := provider.Exchange(ctx, code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		log.Printf("oidc: %s sign-in failed: %v", providerName, err)
+		return LoginResult{}, nil, ErrProviderLoginFailed
+	}
+
+	user, linked, err := s.resolve(providerName, claims)
+	if err != nil {
+		return LoginResult{}, nil, err
+	}
+
+	result, err := s.users.startSession(*user)
+	if err != nil {
+		return LoginResult{}, nil, err
+	}
+
+	return result, linked, nil
+}
+
+func (s *OIDCService) Identities(userId uint) ([]models.Identity, error) {
+	return s.repos.Identities.ListByUser(userId)
+}
+
+// Unlink removes a linked provider account. Users always keep their
+// password, so they can't lock themselves out this way.
+func (s *OIDCService) Unlink(userId, id uint) (*models.Identity, error) {
+	identities, err := s.repos.Identities.ListByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, identity := range identities {
+		if identity.Id == id {
+			if err := s.repos.Identities.Delete(userId, id); err != nil {
+				return nil, notFound(err, "identity not found")
+			}
+			return &identity, nil
+		}
+	}
+
+	return nil, apperrors.NotFound("identity not found")
+}
+
+// resolve finds the user a provider account belongs to, linking it by email
+// on first use. A local account whose email is unverified is never linked:
+// whoever registered it may not own the email.
+func (s *OIDCService) resolve(providerName string, claims oidc.Claims) (*models.User, *models.Identity, error) {
+	identity, err := s.repos.Identities.FindBySubject(providerName, claims.Subject)
+	if err == nil {
+		if identity.User == nil {
+			return nil, nil, ErrNoLinkedAccount
+		}
+		if identity.User.DisabledAt != nil {
+			return nil, nil, ErrAccountDisabled
+		}
+		return identity.User, nil, nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, nil, ErrProviderEmailNotVerified
+	}
+
+	user, err := s.repos.Users.FindByEmail(claims.Email)
+	if err != nil {
+		return nil, nil, notFoundAs(err, ErrNoLinkedAccount)
+	}
+	if user.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+	if !user.Verified() {
+		return nil, nil, ErrEmailNotVerified
+	}
+
+	identity = &models.Identity{
+		UserId:   user.Id,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+	if err := s.repos.Identities.Create(identity); err != nil {
+		return nil, nil, err
+	}
+
+	return user, identity, nil
+}
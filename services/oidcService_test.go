@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"final-project-golang/config"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/oidc"
+	"final-project-golang/repositories"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type oidcTest struct {
+	t       *testing.T
+	repos   repositories.Repositories
+	mock    *oidc.Mock
+	service *OIDCService
+}
+
+// newOIDCTest serves an oidc.Mock that two providers, mock and other,
+// sign in with.
+func newOIDCTest(t *testing.T) *oidcTest {
+	t.Helper()
+
+	helpers.InitToken(helpers.NewHMACKeyring("test-secret"), time.Minute, time.Hour)
+
+	mock, err := oidc.NewMock("client", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+
+	providers := oidc.NewProviders()
+	for _, name := range []string{"mock", "other"} {
+		providers.Add(oidc.NewProvider(config.OIDCProviderConfig{
+			Name:         name,
+			Issuer:       server.URL,
+			ClientId:     "client",
+			ClientSecret: "secret",
+			RedirectURL:  "http://app/callback",
+		}, server.Client()))
+	}
+
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	users := NewUserService(repos, nil, time.Minute)
+
+	return &oidcTest{
+		t:       t,
+		repos:   repos,
+		mock:    mock,
+		service: NewOIDCService(repos, users, providers, time.Minute),
+	}
+}
+
+// user creates an account with a verified email and lets login sign in as
+// it with the provider, which says it verified the email when
+// providerVerified is set.
+func (o *oidcTest) user(login string, providerVerified bool) *models.User {
+	o.t.Helper()
+
+	now := time.Now()
+	user := &models.User{
+		Username:        login,
+		Email:           login + "@example.com",
+		Password:        "secret123",
+		Age:             20,
+		EmailVerifiedAt: &now,
+	}
+	if err := o.repos.Users.Create(user); err != nil {
+		o.t.Fatal(err)
+	}
+	o.mock.AddUser(login, oidc.Claims{Subject: "sub-" + login, Email: user.Email, EmailVerified: providerVerified})
+
+	return user
+}
+
+// start begins a sign-in with mock and follows the provider's redirect back,
+// the way a browser would, returning the code and state it carries along
+// with the binding the browser keeps.
+func (o *oidcTest) start(login string) (string, string, string) {
+	o.t.Helper()
+
+	started, err := o.service.Start(context.Background(), "mock")
+	if err != nil {
+		o.t.Fatalf("Start: %v", err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(started.URL + "&login_hint=" + url.QueryEscape(login))
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	query := location.Query()
+	if query.Get("state") != started.State || query.Get("code") == "" {
+		o.t.Fatalf("redirect = %s", location)
+	}
+
+	return query.Get("code"), query.Get("state"), started.Binding
+}
+
+func (o *oidcTest) signIn(login string) (LoginResult, *models.Identity, error) {
+	o.t.Helper()
+
+	code, state, binding := o.start(login)
+
+	return o.service.Callback(context.Background(), "mock", code, state, binding)
+}
+
+func TestOIDCLinksVerifiedEmail(t *testing.T) {
+	o := newOIDCTest(t)
+	alice := o.user("alice", true)
+
+	result, linked, err := o.signIn("alice")
+	if err != nil {
+		t.Fatalf("first sign-in: %v", err)
+	}
+	if linked == nil || linked.UserId != alice.Id || result.Tokens.Token == "" {
+		t.Fatalf("first sign-in: linked %+v, result %+v", linked, result)
+	}
+
+	result, linked, err = o.signIn("alice")
+	if err != nil || linked != nil || result.Tokens.Token == "" {
+		t.Fatalf("second sign-in: linked %+v, err %v", linked, err)
+	}
+}
+
+func TestOIDCStateIsSingleUse(t *testing.T) {
+	o := newOIDCTest(t)
+	o.user("alice", true)
+	ctx := context.Background()
+
+	code, state, binding := o.start("alice")
+	if _, _, err := o.service.Callback(ctx, "mock", code, state, binding); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := o.service.Callback(ctx, "mock", code, state, binding); !errors.Is(err, ErrInvalidLoginState) {
+		t.Fatalf("reused state: got %v, want ErrInvalidLoginState", err)
+	}
+}
+
+// An attacker who starts a sign-in and gets someone else to post their code
+// and state must not sign that person in, as the attacker or at all.
+func TestOIDCStateIsBoundToItsBrowser(t *testing.T) {
+	o := newOIDCTest(t)
+	o.user("mallory", true)
+	o.user("alice", true)
+	ctx := context.Background()
+
+	_, _, victim := o.start("alice")
+	for _, binding := range []string{victim, ""} {
+		code, state, _ := o.start("mallory")
+		if _, _, err := o.service.Callback(ctx, "mock", code, state, binding); !errors.Is(err, ErrInvalidLoginState) {
+			t.Fatalf("binding %q: got %v, want ErrInvalidLoginState", binding, err)
+		}
+	}
+}
+
+func TestOIDCWrongProvider(t *testing.T) {
+	o := newOIDCTest(t)
+	o.user("alice", true)
+	ctx := context.Background()
+
+	code, state, binding := o.start("alice")
+	if _, _, err := o.service.Callback(ctx, "other", code, state, binding); !errors.Is(err, ErrInvalidLoginState) {
+		t.Fatalf("callback to other: got %v, want ErrInvalidLoginState", err)
+	}
+	// The state was used up by the failed callback.
+	if _, _, err := o.service.Callback(ctx, "mock", code, state, binding); !errors.Is(err, ErrInvalidLoginState) {
+		t.Fatalf("callback to mock after: got %v, want ErrInvalidLoginState", err)
+	}
+	if _, _, err := o.service.Callback(ctx, "unknown", code, state, binding); !errors.Is(err, ErrProviderNotFound) {
+		t.Fatalf("unknown provider: got %v, want ErrProviderNotFound", err)
+	}
+}
+
+func TestOIDCUnverifiedEmail(t *testing.T) {
+	o := newOIDCTest(t)
+	o.user("alice", false)
+	bob := o.user("bob", true)
+	if err := o.repos.Users.SetEmailVerifiedAt(bob.Id, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := o.signIn("alice"); !errors.Is(err, ErrProviderEmailNotVerified) {
+		t.Fatalf("unverified by the provider: got %v, want ErrProviderEmailNotVerified", err)
+	}
+	if _, _, err := o.signIn("bob"); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("unverified by us: got %v, want ErrEmailNotVerified", err)
+	}
+
+	for _, user := range []string{"alice", "bob"} {
+		if _, err := o.repos.Identities.FindBySubject("mock", "sub-"+user); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("%s was linked: %v", user, err)
+		}
+	}
+}
+
+func TestOIDCDisabledUser(t *testing.T) {
+	o := newOIDCTest(t)
+	alice := o.user("alice", true)
+	bob := o.user("bob", true)
+	if _, _, err := o.signIn("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for _, user := range []*models.User{alice, bob} {
+		if err := o.repos.Users.SetDisabledAt(user.Id, &now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := o.signIn("alice"); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("linked: got %v, want ErrAccountDisabled", err)
+	}
+	if _, _, err := o.signIn("bob"); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("not linked yet: got %v, want ErrAccountDisabled", err)
+	}
+}
+
+func TestOIDCTwoFactorChallenge(t *testing.T) {
+	o := newOIDCTest(t)
+	alice := o.user("alice", true)
+	now := time.Now()
+	if err := o.repos.Users.SetTotp(alice.Id, "JBSWY3DPEHPK3PXP", &now); err != nil {
+		t.Fatal(err)
+	}
+
+	result, _, err := o.signIn("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Challenge == nil || result.Tokens.Token != "" {
+		t.Fatalf("result = %+v, want a challenge instead of tokens", result)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/mailer"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrWrongPassword = apperrors.Validation(apperrors.FieldError{
+		Field:   "current_password",
+		Rule:    "match",
+		Message: "current password is not correct",
+	})
+	ErrSamePassword = apperrors.Validation(apperrors.FieldError{
+		Field:   "password",
+		Rule:    "different",
+		Message: "new password must be different from the current one",
+	})
+	ErrInvalidResetToken = apperrors.BadRequest("reset token is invalid or has expired")
+)
+
+// PasswordService changes passwords of signed in users and resets them
+// through a single-use token mailed to users who forgot theirs.
+type PasswordService struct {
+	repos    repositories.Repositories
+	users    *UserService
+	mailer   mailer.Mailer
+	resetTTL time.Duration
+	resetURL string
+}
+
+func NewPasswordService(repos repositories.Repositories, users *UserService, mailer mailer.Mailer, resetTTL time.Duration, resetURL string) *PasswordService {
+	return &PasswordService{
+		repos:    repos,
+		users:    users,
+		mailer:   mailer,
+		resetTTL: resetTTL,
+		resetURL: resetURL,
+	}
+}
+
+// Change replaces the password after checking the current one. Every other
+// session ends, so the caller gets a fresh pair of tokens.
+func (s *PasswordService) Change(userId uint, current, password string) (*models.User, TokenPair, error) {
+	user, err := s.users.Get(userId)
+	if err != nil {
+		return nil, TokenPair{}, err
+	}
+
+	if !helpers.ComparePassword(user.Password, current) {
+		return nil, TokenPair{}, ErrWrongPassword
+	}
+	if current == password {
+		return nil, TokenPair{}, ErrSamePassword
+	}
+
+	if err := s.users.setPassword(user, password); err != nil {
+		return nil, TokenPair{}, err
+	}
+
+	tokens, err := s.users.issueTokens(*user)
+	if err != nil {
+		return nil, TokenPair{}, err
+	}
+
+	return user, tokens, nil
+}
+
+// RequestReset mails a reset token to email in the background, so neither
+// the response nor its timing tells which emails exist. Unknown and
+// disabled accounts are skipped silently and failures are only logged.
+func (s *PasswordService) RequestReset(email string) {
+	go func() {
+		if err := s.requestReset(email); err != nil {
+			log.Printf("password: could not send a reset: %v", err)
+		}
+	}()
+}
+
+func (s *PasswordService) requestReset(email string) error {
+	user, err := s.repos.Users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+
+	token, hash, expiresAt, err := helpers.GenerateOneTimeToken(s.resetTTL)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := s.repos.Tokens.UseAllPasswordResets(user.Id, now); err != nil {
+		return err
+	}
+	err = s.repos.Tokens.CreatePasswordReset(&models.PasswordReset{
+		UserId:    user.Id,
+		TokenHash: hash,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    s.resetBody(user.Username, token),
+	})
+}
+
+// Reset sets a new password with a token from RequestReset. It returns the
+// account as it was before and after the change.
+func (s *PasswordService) Reset(token, password string) (models.User, *models.User, error) {
+	reset, err := s.repos.Tokens.FindPasswordResetByHash(helpers.HashToken(token))
+	if err != nil {
+		return models.User{}, nil, notFoundAs(err, ErrInvalidResetToken)
+	}
+
+	now := time.Now()
+	if reset.User == nil || reset.UsedAt != nil || !reset.ExpiresAt.After(now) {
+		return models.User{}, nil, ErrInvalidResetToken
+	}
+	if reset.User.DisabledAt != nil {
+		return models.User{}, nil, ErrAccountDisabled
+	}
+	// Check the password first so a weak one doesn't use up the token.
+	if err := validatePassword(password); err != nil {
+		return models.User{}, nil, err
+	}
+
+	if err := s.repos.Tokens.UsePasswordReset(reset.Id, now); err != nil {
+		return models.User{}, nil, notFoundAs(err, ErrInvalidResetToken)
+	}
+
+	before := *reset.User
+	if err := s.users.setPassword(reset.User, password); err != nil {
+		return models.User{}, nil, err
+	}
+
+	return before, reset.User, nil
+}
+
+func (s *PasswordService) resetBody(username, token string) string {
+	lines := []string{
+		fmt.Sprintf("Hi %s,", username),
+		"",
+		"Someone asked to reset the password of your account. If it wasn't you, ignore this message.",
+		"",
+	}
+	if s.resetURL != "" {
+		separator := "?"
+		if strings.Contains(s.resetURL, "?") {
+			separator = "&"
+		}
+		lines = append(lines, "Open "+s.resetURL+separator+"token="+url.QueryEscape(token))
+	} else {
+		lines = append(lines, "Your reset token is "+token)
+	}
+	lines = append(lines, "", fmt.Sprintf("It expires in %s.", s.resetTTL))
+
+	return strings.Join(lines, "\n")
+}
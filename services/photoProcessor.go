@@ -0,0 +1,224 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"final-project-golang/imaging"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/storage"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"sync"
+)
+
+type rendition struct {
+	name    string
+	maxSize int
+}
+
+// renditions are generated for every upload. The original keeps its size
+// but, like the others, is re-encoded without metadata.
+var renditions = []rendition{
+	{name: "original", maxSize: 0},
+	{name: "medium", maxSize: 1080},
+	{name: "thumbnail", maxSize: 320},
+}
+
+// PhotoProcessor turns raw uploads into renditions in the background.
+type PhotoProcessor struct {
+	repos repositories.Repositories
+	store storage.Storage
+	queue chan uint
+	wg    sync.WaitGroup
+}
+
+func NewPhotoProcessor(repos repositories.Repositories, store storage.Storage, queueSize int) *PhotoProcessor {
+	return &PhotoProcessor{
+		repos: repos,
+		store: store,
+		queue: make(chan uint, queueSize),
+	}
+}
+
+// Start runs workers until ctx is cancelled. Photos left pending by a
+// previous run are queued again.
+func (p *PhotoProcessor) Start(ctx context.Context, workers int) error {
+	pending, err := p.repos.Photos.ListPendingIds()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+
+	go func() {
+		for _, id := range pending {
+			select {
+			case p.queue <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Wait blocks until every worker has stopped.
+func (p *PhotoProcessor) Wait() {
+	p.wg.Wait()
+}
+
+// Enqueue schedules a photo without blocking. When the queue is full the
+// photo stays pending and is picked up on the next start.
+func (p *PhotoProcessor) Enqueue(id uint) {
+	select {
+	case p.queue <- id:
+	default:
+		log.Printf("photos: processing queue is full, photo %d stays pending", id)
+	}
+}
+
+func (p *PhotoProcessor) work(ctx context.Context) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-p.queue:
+			if err := p.Process(ctx, id); err != nil {
+				log.Printf("photos: processing photo %d failed: %v", id, err)
+			}
+		}
+	}
+}
+
+// Process generates the renditions of one pending photo and records its
+// dimensions, format and blurhash. Photos deleted while pending are
+// processed too, so they are ready if restored.
+func (p *PhotoProcessor) Process(ctx context.Context, id uint) error {
+	photo, err := p.repos.Photos.FindById(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		photo, err = p.repos.Photos.FindDeleted(id)
+	}
+	if err != nil {
+		return err
+	}
+	if photo.ProcessingStatus != models.PhotoProcessingPending || photo.UploadKey == nil || photo.StorageKey == nil {
+		return nil
+	}
+	uploadKey := *photo.UploadKey
+
+	if err := p.render(ctx, photo); err != nil {
+		if ctx.Err() != nil {
+			// Stopped by shutdown: the photo stays pending for the next start.
+			return err
+		}
+		photo.ProcessingStatus = models.PhotoProcessingFailed
+		if saveErr := p.repos.Photos.SaveProcessing(photo); saveErr != nil {
+			return saveErr
+		}
+		return err
+	}
+
+	photo.ProcessingStatus = models.PhotoProcessingReady
+	photo.UploadKey = nil
+	err = p.repos.Photos.SaveProcessing(photo)
+	if errors.Is(err, repositories.ErrNotFound) {
+		// The photo was purged while it was being processed.
+		for _, r := range renditions {
+			p.store.Delete(ctx, renditionKey(*photo.StorageKey, r.name))
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if err := p.store.Delete(ctx, uploadKey); err != nil {
+		log.Printf("photos: could not delete upload %s: %v", uploadKey, err)
+	}
+
+	return nil
+}
+
+func (p *PhotoProcessor) render(ctx context.Context, photo *models.Photo) error {
+	body, _, err := p.store.Get(ctx, *photo.UploadKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	outputFormat, contentType := "jpeg", "image/jpeg"
+	if path.Ext(*photo.StorageKey) == ".png" {
+		outputFormat, contentType = "png", "image/png"
+	}
+
+	for _, r := range renditions {
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, imaging.Fit(img, r.maxSize), outputFormat); err != nil {
+			return fmt.Errorf("encode %s: %w", r.name, err)
+		}
+
+		key := renditionKey(*photo.StorageKey, r.name)
+		if err := p.store.Put(ctx, key, &buf, int64(buf.Len()), contentType); err != nil {
+			return fmt.Errorf("store %s: %w", r.name, err)
+		}
+
+		switch r.name {
+		case "medium":
+			photo.MediumUrl = p.store.URL(key)
+		case "thumbnail":
+			photo.ThumbnailUrl = p.store.URL(key)
+		}
+	}
+
+	bounds := img.Bounds()
+	photo.Width = bounds.Dx()
+	photo.Height = bounds.Dy()
+	photo.Format = format
+	photo.Blurhash = imaging.Blurhash(img, 4, 3)
+
+	return nil
+}
+
+// renditionKey derives the key of a rendition from the key of the original,
+// e.g. photos/2024/10/abc.jpg becomes photos/2024/10/abc_thumbnail.jpg.
+func renditionKey(key, name string) string {
+	if name == "original" {
+		return key
+	}
+
+	extension := path.Ext(key)
+
+	return strings.TrimSuffix(key, extension) + "_" + name + extension
+}
+
+// originalKey is the inverse of renditionKey.
+func originalKey(key string) string {
+	extension := path.Ext(key)
+	base := strings.TrimSuffix(key, extension)
+
+	for _, r := range renditions {
+		if r.name != "original" && strings.HasSuffix(base, "_"+r.name) {
+			return strings.TrimSuffix(base, "_"+r.name) + extension
+		}
+	}
+
+	return key
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/storage"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+)
+
+// cancelingStore cancels the processing context at the first Put, the way
+// shutdown does mid-render.
+type cancelingStore struct {
+	storage.Storage
+	cancel context.CancelFunc
+}
+
+func (s cancelingStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	s.cancel()
+
+	return ctx.Err()
+}
+
+// newPendingPhoto stores an upload and the pending photo that refers to it.
+func newPendingPhoto(t *testing.T, repos repositories.Repositories, store storage.Storage) *models.Photo {
+	t.Helper()
+
+	user := models.User{Username: "alice", Email: "alice@example.com", Password: "secret123", Age: 20}
+	if err := repos.Users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	if err := png.Encode(&body, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	key, uploadKey := mediaPrefix+"/sunset.png", uploadPrefix+"/sunset.png"
+	if err := store.Put(context.Background(), uploadKey, bytes.NewReader(body.Bytes()), int64(body.Len()), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	photo := &models.Photo{
+		Title:            "sunset",
+		PhotoUrl:         store.URL(key),
+		UserId:           user.Id,
+		StorageKey:       &key,
+		UploadKey:        &uploadKey,
+		ProcessingStatus: models.PhotoProcessingPending,
+	}
+	if err := repos.Photos.Create(photo); err != nil {
+		t.Fatal(err)
+	}
+
+	return photo
+}
+
+func TestProcessDeletedPhoto(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	store := storage.NewMemory("/media")
+	photo := newPendingPhoto(t, repos, store)
+	if err := repos.Photos.Delete(photo); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewPhotoProcessor(repos, store, 1).Process(ctx, photo.Id); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	deleted, err := repos.Photos.FindDeleted(photo.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.ProcessingStatus != models.PhotoProcessingReady || deleted.UploadKey != nil {
+		t.Fatalf("deleted photo = %+v, want it processed", deleted)
+	}
+	if _, _, err := store.Get(ctx, *photo.StorageKey); err != nil {
+		t.Fatalf("rendition: %v", err)
+	}
+}
+
+func TestProcessStoppedByShutdown(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	store := storage.NewMemory("/media")
+	photo := newPendingPhoto(t, repos, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopping := NewPhotoProcessor(repos, cancelingStore{Storage: store, cancel: cancel}, 1)
+	if err := stopping.Process(ctx, photo.Id); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Process: got %v, want context.Canceled", err)
+	}
+
+	stopped, err := repos.Photos.FindById(photo.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.ProcessingStatus != models.PhotoProcessingPending || stopped.UploadKey == nil {
+		t.Fatalf("photo = %+v, want it still pending", stopped)
+	}
+
+	// The next start picks it up.
+	if err := NewPhotoProcessor(repos, store, 1).Process(context.Background(), photo.Id); err != nil {
+		t.Fatalf("Process after restart: %v", err)
+	}
+	ready, err := repos.Photos.FindById(photo.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ready.ProcessingStatus != models.PhotoProcessingReady {
+		t.Fatalf("photo = %+v, want it ready", ready)
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/storage"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// uploadTypes maps the image types accepted for upload to the extension of
+// their renditions. Formats that may carry transparency are kept as PNG.
+var uploadTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".png",
+	"image/webp": ".jpg",
+}
+
+type PhotoService struct {
+	repos     repositories.Repositories
+	store     storage.Storage
+	processor *PhotoProcessor
+}
+
+// NewPhotoService creates the service. Uploads are processed by processor;
+// when it is nil they stay pending until a processor picks them up.
+func NewPhotoService(repos repositories.Repositories, store storage.Storage, processor *PhotoProcessor) *PhotoService {
+	return &PhotoService{
+		repos:     repos,
+		store:     store,
+		processor: processor,
+	}
+}
+
+func (s *PhotoService) Create(photo *models.Photo) error {
+	return s.repos.Photos.Create(photo)
+}
+
+// Upload stores file privately and creates a pending photo. The content
+// type is sniffed from the data rather than trusted from the client; the
+// public renditions are generated in the background.
+func (s *PhotoService) Upload(ctx context.Context, photo *models.Photo, file io.Reader, size int64) error {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return apperrors.Validation(apperrors.FieldError{Field: "photo", Rule: "required", Message: "photo is empty"})
+		}
+		return err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	extension, ok := uploadTypes[contentType]
+	if !ok {
+		return apperrors.New(apperrors.CodeUnsupportedMedia, "photo must be a JPEG, PNG, GIF or WebP image").
+			WithField("photo", "content_type", "unsupported content type "+contentType)
+	}
+
+	key, err := storage.NewKey(mediaPrefix, extension)
+	if err != nil {
+		return err
+	}
+	uploadKey := uploadPrefix + strings.TrimPrefix(key, mediaPrefix)
+
+	err = s.store.Put(ctx, uploadKey, io.MultiReader(bytes.NewReader(head), file), size, contentType)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	photo.StorageKey = &key
+	photo.UploadKey = &uploadKey
+	photo.PhotoUrl = s.store.URL(key)
+	photo.ContentType = contentType
+	photo.Size = size
+	photo.ProcessingStatus = models.PhotoProcessingPending
+
+	if err := s.repos.Photos.Create(photo); err != nil {
+		removeObject(s.store, uploadKey)
+		return err
+	}
+
+	if s.processor != nil {
+		s.processor.Enqueue(photo.Id)
+	}
+
+	return nil
+}
+
+// List leaves out the photos of private accounts userId doesn't follow, and
+// other users' photos until they are processed.
+func (s *PhotoService) List(userId uint, query repositories.ListQuery) ([]models.Photo, int64, error) {
+	return s.repos.Photos.List(userId, query)
+}
+
+// Feed returns the home feed of userId, newest first.
+func (s *PhotoService) Feed(userId uint, p helpers.Pagination) ([]models.Photo, error) {
+	p.SortField = "created_at"
+	p.SortDesc = true
+
+	return s.repos.Photos.Feed(userId, p)
+}
+
+// Get returns a photo with its comments. Other users' photos are not found
+// until they are processed.
+func (s *PhotoService) Get(viewerId, id uint) (*models.Photo, error) {
+	photo, err := s.repos.Photos.FindWithComments(id)
+	if err != nil {
+		return nil, notFound(err, "photo not found")
+	}
+	if err := checkPhotoVisible(s.repos, viewerId, photo, apperrors.NotFound("photo not found")); err != nil {
+		return nil, err
+	}
+
+	return photo, nil
+}
+
+func (s *PhotoService) Update(userId, id uint, changes models.Photo) (*models.Photo, error) {
+	photo, err := s.repos.Photos.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "photo not found")
+	}
+
+	if photo.UserId != userId {
+		return nil, apperrors.Forbidden("you're not allowed to update or edit this photo")
+	}
+
+	if photo.StorageKey != nil && changes.PhotoUrl != "" && changes.PhotoUrl != photo.PhotoUrl {
+		return nil, apperrors.Validation(apperrors.FieldError{
+			Field:   "photo_url",
+			Rule:    "immutable",
+			Message: "photo_url of an uploaded photo can't be changed",
+		})
+	}
+
+	if err := s.repos.Photos.Update(photo, changes); err != nil {
+		return nil, err
+	}
+
+	return photo, nil
+}
+
+func (s *PhotoService) Delete(userId, id uint) error {
+	photo, err := s.repos.Photos.FindById(id)
+	if err != nil {
+		return notFound(err, "photo not found")
+	}
+
+	if photo.UserId != userId {
+		return apperrors.Forbidden("you're not allowed to delete this photo")
+	}
+
+	// The stored files are kept so the photo can be restored.
+	return s.repos.Photos.Delete(photo)
+}
+
+// Remove permanently deletes any photo regardless of its owner, together
+// with its stored files. Unlike Delete it skips the restore window: a
+// soft deleted photo stays at its URL and its owner could bring it back.
+func (s *PhotoService) Remove(id uint) (*models.Photo, error) {
+	photo, err := s.repos.Photos.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "photo not found")
+	}
+
+	if err := s.repos.Photos.Purge(photo); err != nil {
+		return nil, err
+	}
+	removePhotoFiles(s.store, photo)
+
+	return photo, nil
+}
+
+// removePhotoFiles deletes the stored files of a photo whose row is gone.
+func removePhotoFiles(store storage.Storage, photo *models.Photo) {
+	if photo.StorageKey != nil {
+		for _, r := range renditions {
+			removeObject(store, renditionKey(*photo.StorageKey, r.name))
+		}
+	}
+	if photo.UploadKey != nil {
+		removeObject(store, *photo.UploadKey)
+	}
+}
+
+// removeObject deletes a stored file whose row is gone. Failures only leave
+// an orphaned file behind, so they are logged rather than returned.
+func removeObject(store storage.Storage, key string) {
+	if err := store.Delete(context.Background(), key); err != nil {
+		log.Printf("photos: could not delete %s: %v", key, err)
+	}
+}
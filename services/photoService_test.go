@@ -0,0 +1,51 @@
+package services
+
+import (
+	"final-project-golang/apperrors"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"final-project-golang/storage"
+	"testing"
+)
+
+func TestGetHidesUnprocessedPhotos(t *testing.T) {
+	repos := repositories.NewMemoryRepositories(repositories.NewMemoryStore())
+	photos := NewPhotoService(repos, storage.NewMemory("/media"), nil)
+	likes := NewLikeService(repos)
+	comments := NewCommentService(repos)
+
+	var users []models.User
+	for _, name := range []string{"alice", "bob"} {
+		user := models.User{Username: name, Email: name + "@example.com", Password: "secret123", Age: 20}
+		if err := repos.Users.Create(&user); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, user)
+	}
+	alice, bob := users[0].Id, users[1].Id
+
+	for _, status := range []string{"", models.PhotoProcessingPending, models.PhotoProcessingReady, models.PhotoProcessingFailed} {
+		photo := &models.Photo{Title: "sunset", PhotoUrl: "http://example.com/sunset.jpg", UserId: alice, ProcessingStatus: status}
+		if err := repos.Photos.Create(photo); err != nil {
+			t.Fatal(err)
+		}
+		visible := photo.Processed()
+
+		if _, err := photos.Get(alice, photo.Id); err != nil {
+			t.Errorf("%q: owner Get: %v", status, err)
+		}
+
+		_, err := photos.Get(bob, photo.Id)
+		if visible != (err == nil) || (err != nil && !apperrors.HasCode(err, apperrors.CodeNotFound)) {
+			t.Errorf("%q: other user Get: %v", status, err)
+		}
+		_, err = likes.Like(bob, repositories.LikePhoto, photo.Id)
+		if visible != (err == nil) || (err != nil && !apperrors.HasCode(err, apperrors.CodeNotFound)) {
+			t.Errorf("%q: other user Like: %v", status, err)
+		}
+		err = comments.Create(&models.Comment{Message: "nice", PhotoId: photo.Id, UserId: bob})
+		if visible != (err == nil) || (err != nil && !apperrors.HasCode(err, apperrors.CodeNotFound)) {
+			t.Errorf("%q: other user comment: %v", status, err)
+		}
+	}
+}
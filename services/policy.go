@@ -0,0 +1,41 @@
+package services
+
+import "final-project-golang/models"
+
+// Permission names something a role may do beyond managing its own content.
+type Permission string
+
+const (
+	// PermModerateContent allows deleting any photo, comment or social media.
+	PermModerateContent Permission = "content:moderate"
+	// PermViewModeration allows reading the moderation log.
+	PermViewModeration Permission = "moderation:view"
+	// PermManageUsers allows listing, disabling and changing the role of users.
+	PermManageUsers Permission = "users:manage"
+	// PermViewAudit allows reading and exporting the audit log.
+	PermViewAudit Permission = "audit:view"
+)
+
+var rolePermissions = map[string][]Permission{
+	models.RoleUser:      {},
+	models.RoleModerator: {PermModerateContent, PermViewModeration},
+	models.RoleAdmin:     {PermModerateContent, PermViewModeration, PermManageUsers, PermViewAudit},
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+
+	return ok
+}
+
+// Can reports whether role grants permission.
+func Can(role string, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"final-project-golang/repositories"
+	"final-project-golang/storage"
+	"log"
+	"time"
+)
+
+const purgePhotoBatch = 100
+
+type PurgeResult struct {
+	Users    int64
+	Photos   int64
+	Comments int64
+	Socials  int64
+}
+
+// PurgeService hard deletes soft deleted rows once they are past retention.
+type PurgeService struct {
+	repos repositories.Repositories
+	store storage.Storage
+}
+
+func NewPurgeService(repos repositories.Repositories, store storage.Storage) *PurgeService {
+	return &PurgeService{
+		repos: repos,
+		store: store,
+	}
+}
+
+// Purge hard deletes everything deleted before the given time. Photos go
+// first, one at a time, so their stored files can be removed; rows deleted
+// along with a user share its deleted_at and are purged in the same run.
+func (s *PurgeService) Purge(before time.Time) (PurgeResult, error) {
+	var result PurgeResult
+
+	for {
+		photos, err := s.repos.Photos.ListDeleted(before, purgePhotoBatch)
+		if err != nil {
+			return result, err
+		}
+		for i := range photos {
+			if err := s.repos.Photos.Purge(&photos[i]); err != nil {
+				return result, err
+			}
+			removePhotoFiles(s.store, &photos[i])
+			result.Photos++
+		}
+		if len(photos) < purgePhotoBatch {
+			break
+		}
+	}
+
+	var err error
+	if result.Comments, err = s.repos.Comments.PurgeDeleted(before); err != nil {
+		return result, err
+	}
+	if result.Socials, err = s.repos.Socials.PurgeDeleted(before); err != nil {
+		return result, err
+	}
+	if result.Users, err = s.repos.Users.PurgeDeleted(before); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// Run purges rows deleted longer than retention ago every interval until
+// ctx is done.
+func (s *PurgeService) Run(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := s.Purge(time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("purge: %v", err)
+		} else if result != (PurgeResult{}) {
+			log.Printf("purge: removed %d users, %d photos, %d comments, %d social media",
+				result.Users, result.Photos, result.Comments, result.Socials)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRestoreExpired        = apperrors.New(apperrors.CodeConflict, "the restore period has ended")
+	ErrRestorePhotoDeleted   = apperrors.New(apperrors.CodeConflict, "the photo of this comment has been deleted")
+	ErrRestoreParentDeleted  = apperrors.New(apperrors.CodeConflict, "the comment this replies to has been deleted")
+	ErrRestoreNothingDeleted = apperrors.NotFound("no deleted account found")
+)
+
+// RestoreService brings back soft deleted rows, together with whatever was
+// deleted with them, while they are younger than the restore window.
+type RestoreService struct {
+	repos  repositories.Repositories
+	window time.Duration
+}
+
+func NewRestoreService(repos repositories.Repositories, window time.Duration) *RestoreService {
+	return &RestoreService{
+		repos:  repos,
+		window: window,
+	}
+}
+
+// RestoreUser checks the password since a deleted user has no session.
+func (s *RestoreService) RestoreUser(email, password string) (*models.User, error) {
+	user, err := s.repos.Users.FindDeletedByEmail(email)
+	if err != nil {
+		return nil, notFoundAs(err, ErrRestoreNothingDeleted)
+	}
+	if !helpers.ComparePassword(user.Password, password) {
+		return nil, ErrInvalidCredentials
+	}
+	if err := s.checkWindow(user.DeletedAt); err != nil {
+		return nil, err
+	}
+
+	return user, s.repos.Users.Restore(user)
+}
+
+func (s *RestoreService) RestorePhoto(userId, id uint) (*models.Photo, error) {
+	photo, err := s.repos.Photos.FindDeleted(id)
+	if err != nil || photo.UserId != userId {
+		return nil, notFound(errOrNotFound(err), "photo not found")
+	}
+	if err := s.checkWindow(photo.DeletedAt); err != nil {
+		return nil, err
+	}
+
+	return photo, s.repos.Photos.Restore(photo)
+}
+
+// RestoreComment also restores the tombstoned ancestors that were pruned
+// when the comment was deleted.
+func (s *RestoreService) RestoreComment(userId, id uint) (*models.Comment, error) {
+	comment, err := s.repos.Comments.FindDeleted(id)
+	if err != nil || comment.UserId != userId || comment.Removed() {
+		return nil, notFound(errOrNotFound(err), "comment not found")
+	}
+	if err := s.checkWindow(comment.DeletedAt); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repos.Photos.FindById(comment.PhotoId); err != nil {
+		return nil, notFoundAs(err, ErrRestorePhotoDeleted)
+	}
+
+	var tombstones []*models.Comment
+	for parentId := comment.ParentId; parentId != nil; {
+		_, err := s.repos.Comments.FindById(*parentId)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, repositories.ErrNotFound) {
+			return nil, err
+		}
+
+		parent, err := s.repos.Comments.FindDeleted(*parentId)
+		if err != nil {
+			return nil, notFoundAs(err, ErrRestoreParentDeleted)
+		}
+		if !parent.Removed() {
+			return nil, ErrRestoreParentDeleted
+		}
+		tombstones = append(tombstones, parent)
+		parentId = parent.ParentId
+	}
+
+	for i := len(tombstones) - 1; i >= 0; i-- {
+		if err := s.repos.Comments.Restore(tombstones[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return comment, s.repos.Comments.Restore(comment)
+}
+
+func (s *RestoreService) RestoreSocial(userId, id uint) (*models.Social, error) {
+	social, err := s.repos.Socials.FindDeleted(id)
+	if err != nil || social.UserId != userId {
+		return nil, notFound(errOrNotFound(err), "social media not found")
+	}
+	if err := s.checkWindow(social.DeletedAt); err != nil {
+		return nil, err
+	}
+
+	return social, s.repos.Socials.Restore(social)
+}
+
+func (s *RestoreService) checkWindow(deletedAt gorm.DeletedAt) error {
+	if time.Since(deletedAt.Time) > s.window {
+		return ErrRestoreExpired
+	}
+
+	return nil
+}
+
+// errOrNotFound hides rows of other users behind the same not-found error.
+func errOrNotFound(err error) error {
+	if err == nil {
+		return repositories.ErrNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+)
+
+var (
+	ErrInvalidCredentials = apperrors.Unauthorized("username / password is not match")
+	ErrInvalidToken       = apperrors.Unauthorized("invalid refresh token")
+	ErrTokenExpired       = apperrors.Unauthorized("refresh token has expired")
+	ErrTokenRevoked       = apperrors.Unauthorized("token has been revoked")
+	ErrAccountDisabled    = apperrors.Forbidden("your account has been disabled")
+)
+
+// checkVisible allows what a private account shares, its photos and social
+// graph included, to be seen only by the account itself and its accepted
+// followers.
+func checkVisible(repos repositories.Repositories, viewerId, userId uint) error {
+	if viewerId == userId {
+		return nil
+	}
+
+	user, err := repos.Users.FindById(userId)
+	if err != nil {
+		return notFound(err, "user not found")
+	}
+	if !user.Private {
+		return nil
+	}
+
+	follow, err := repos.Follows.Find(viewerId, userId)
+	if err != nil {
+		return notFoundAs(err, ErrPrivateAccount)
+	}
+	if !follow.Accepted() {
+		return ErrPrivateAccount
+	}
+
+	return nil
+}
+
+// checkPhotoVisible applies checkVisible to the owner of photo, and hides
+// other users' photos as missing until they are processed, the way List
+// leaves them out.
+func checkPhotoVisible(repos repositories.Repositories, viewerId uint, photo *models.Photo, missing error) error {
+	if photo.UserId != viewerId && !photo.Processed() {
+		return missing
+	}
+
+	return checkVisible(repos, viewerId, photo.UserId)
+}
+
+// notFound replaces the generic repository not-found error with one naming
+// the missing resource.
+func notFound(err error, message string) error {
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperrors.Wrap(err, apperrors.CodeNotFound, message)
+	}
+
+	return err
+}
+
+// notFoundAs replaces the repository not-found error with target.
+func notFoundAs(err error, target error) error {
+	if errors.Is(err, repositories.ErrNotFound) {
+		return target
+	}
+
+	return err
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"final-project-golang/apperrors"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+)
+
+type SocialService struct {
+	repos repositories.Repositories
+}
+
+func NewSocialService(repos repositories.Repositories) *SocialService {
+	return &SocialService{
+		repos: repos,
+	}
+}
+
+func (s *SocialService) Create(social *models.Social) error {
+	return s.repos.Socials.Create(social)
+}
+
+func (s *SocialService) List(query repositories.ListQuery) ([]models.Social, int64, error) {
+	return s.repos.Socials.List(query)
+}
+
+func (s *SocialService) Get(id uint) (*models.Social, error) {
+	social, err := s.repos.Socials.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "social media not found")
+	}
+
+	return social, nil
+}
+
+func (s *SocialService) Update(userId, id uint, changes models.Social) (*models.Social, error) {
+	social, err := s.repos.Socials.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "social media not found")
+	}
+
+	if social.UserId != userId {
+		return nil, apperrors.Forbidden("you're not allowed to update or edit this social media")
+	}
+
+	if err := s.repos.Socials.Update(social, changes); err != nil {
+		return nil, err
+	}
+
+	return social, nil
+}
+
+func (s *SocialService) Delete(userId, id uint) error {
+	social, err := s.repos.Socials.FindById(id)
+	if err != nil {
+		return notFound(err, "social media not found")
+	}
+
+	if social.UserId != userId {
+		return apperrors.Forbidden("you're not allowed to delete this social media")
+	}
+
+	return s.repos.Socials.Delete(social)
+}
+
+// Remove permanently deletes any social media regardless of its owner,
+// skipping the restore window so the owner can't bring it back.
+func (s *SocialService) Remove(id uint) (*models.Social, error) {
+	social, err := s.repos.Socials.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "social media not found")
+	}
+
+	return social, s.repos.Socials.Purge(social)
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"time"
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes a user gets at a time.
+	recoveryCodeCount = 10
+	// challengeAttempts is how many codes can be tried with one login
+	// challenge.
+	challengeAttempts = 5
+)
+
+var (
+	ErrInvalidChallenge     = apperrors.Unauthorized("login challenge is invalid or has expired")
+	ErrInvalidTwoFactorCode = apperrors.Unauthorized("two factor code is not valid")
+	ErrWrongTwoFactorCode   = apperrors.Validation(apperrors.FieldError{
+		Field:   "code",
+		Rule:    "match",
+		Message: "code is not valid",
+	})
+	ErrTwoFactorEnabled     = apperrors.New(apperrors.CodeConflict, "two factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = apperrors.BadRequest("two factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled = apperrors.BadRequest("start two factor enrollment first")
+)
+
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool
+	Pending           bool
+	RecoveryCodesLeft int64
+}
+
+// TwoFactorService enrolls users in TOTP and finishes the logins of those
+// who are. Anywhere a code is asked for, an unused recovery code works too.
+type TwoFactorService struct {
+	repos  repositories.Repositories
+	users  *UserService
+	issuer string
+}
+
+func NewTwoFactorService(repos repositories.Repositories, users *UserService, issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		repos:  repos,
+		users:  users,
+		issuer: issuer,
+	}
+}
+
+func (s *TwoFactorService) Status(userId uint) (TwoFactorStatus, error) {
+	user, err := s.users.Get(userId)
+	if err != nil {
+		return TwoFactorStatus{}, err
+	}
+
+	status := TwoFactorStatus{
+		Enabled: user.TwoFactorEnabled(),
+		Pending: !user.TwoFactorEnabled() && user.TotpSecret != "",
+	}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = s.repos.Tokens.CountRecoveryCodes(userId); err != nil {
+			return TwoFactorStatus{}, err
+		}
+	}
+
+	return status, nil
+}
+
+// Enroll creates a new secret for the user to add to their authenticator.
+// Two factor authentication stays off until Confirm.
+func (s *TwoFactorService) Enroll(userId uint) (TwoFactorEnrollment, error) {
+	user, err := s.users.Get(userId)
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	if user.TwoFactorEnabled() {
+		return TwoFactorEnrollment{}, ErrTwoFactorEnabled
+	}
+
+	secret, err := helpers.GenerateTotpSecret()
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	if err := s.repos.Users.SetTotp(user.Id, secret, nil); err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+
+	return TwoFactorEnrollment{
+		Secret: secret,
+		URI:    helpers.TotpURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm turns two factor authentication on once the user proves their
+// authenticator works, and returns their recovery codes.
+func (s *TwoFactorService) Confirm(userId uint, code string) ([]string, error) {
+	user, err := s.users.Get(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TotpSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := helpers.ValidateTotp(user.TotpSecret, code, time.Now())
+	if !ok {
+		return nil, ErrWrongTwoFactorCode
+	}
+
+	now := time.Now()
+	if err := s.repos.Users.SetTotp(user.Id, user.TotpSecret, &now); err != nil {
+		return nil, err
+	}
+	if err := s.repos.Users.UseTotpStep(user.Id, step); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(user.Id)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the user.
+func (s *TwoFactorService) RegenerateRecoveryCodes(userId uint, code string) ([]string, error) {
+	user, err := s.enabledUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCode(user, code); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(user.Id)
+}
+
+// Disable turns two factor authentication off. It asks for the password as
+// well as a code since a stolen session alone shouldn't be enough.
+func (s *TwoFactorService) Disable(userId uint, password, code string) error {
+	user, err := s.enabledUser(userId)
+	if err != nil {
+		return err
+	}
+
+	if !helpers.ComparePassword(user.Password, password) {
+		return apperrors.Validation(apperrors.FieldError{
+			Field:   "password",
+			Rule:    "match",
+			Message: "password is not correct",
+		})
+	}
+	if err := s.checkCode(user, code); err != nil {
+		return err
+	}
+
+	return s.Reset(user.Id)
+}
+
+// Reset turns two factor authentication off without asking for anything,
+// for admins helping a user who lost their authenticator.
+func (s *TwoFactorService) Reset(userId uint) error {
+	if err := s.repos.Users.SetTotp(userId, "", nil); err != nil {
+		return err
+	}
+
+	return s.repos.Tokens.ReplaceRecoveryCodes(userId, nil)
+}
+
+// CompleteLogin trades a challenge from UserService.Login and a code for a
+// session. A challenge works once and allows challengeAttempts codes, even
+// without a lockout; wrong codes also count towards the lockout like wrong
+// passwords.
+func (s *TwoFactorService) CompleteLogin(token, code string) (TokenPair, error) {
+	challenge, err := s.repos.Tokens.FindTwoFactorChallengeByHash(helpers.HashToken(token))
+	if err != nil {
+		return TokenPair{}, notFoundAs(err, ErrInvalidChallenge)
+	}
+	user := challenge.User
+	if user == nil || !user.TwoFactorEnabled() {
+		return TokenPair{}, ErrInvalidChallenge
+	}
+
+	locked, err := s.users.lockout.Check(user.Email)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if locked > 0 {
+		return TokenPair{}, errLoginLocked(locked)
+	}
+	if user.DisabledAt != nil {
+		return TokenPair{}, ErrAccountDisabled
+	}
+
+	// The attempt is counted before the code is checked so concurrent
+	// guesses can't get past the limit.
+	now := time.Now()
+	if err := s.repos.Tokens.AttemptTwoFactorChallenge(challenge.Id, challengeAttempts, now); err != nil {
+		return TokenPair{}, notFoundAs(err, ErrInvalidChallenge)
+	}
+
+	if err := s.checkCode(user, code); err != nil {
+		if errors.Is(err, ErrWrongTwoFactorCode) {
+			if _, err := s.users.lockout.Fail(user.Email); err != nil {
+				return TokenPair{}, err
+			}
+			return TokenPair{}, ErrInvalidTwoFactorCode
+		}
+		return TokenPair{}, err
+	}
+	if err := s.repos.Tokens.UseTwoFactorChallenge(challenge.Id, now); err != nil {
+		return TokenPair{}, notFoundAs(err, ErrInvalidChallenge)
+	}
+	if err := s.users.lockout.Succeed(user.Email); err != nil {
+		return TokenPair{}, err
+	}
+
+	return s.users.issueTokens(*user)
+}
+
+func (s *TwoFactorService) enabledUser(userId uint) (*models.User, error) {
+	user, err := s.users.Get(userId)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	return user, nil
+}
+
+// checkCode accepts a TOTP code that wasn't used yet or an unused recovery
+// code, and uses it up.
+func (s *TwoFactorService) checkCode(user *models.User, code string) error {
+	now := time.Now()
+
+	if step, ok := helpers.ValidateTotp(user.TotpSecret, code, now); ok {
+		err := s.repos.Users.UseTotpStep(user.Id, step)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrWrongTwoFactorCode
+		}
+		return err
+	}
+
+	err := s.repos.Tokens.UseRecoveryCode(user.Id, helpers.HashRecoveryCode(code), now)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrWrongTwoFactorCode
+	}
+
+	return err
+}
+
+func (s *TwoFactorService) newRecoveryCodes(userId uint) ([]string, error) {
+	codes, err := helpers.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]models.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		records = append(records, models.RecoveryCode{CodeHash: helpers.HashRecoveryCode(code)})
+	}
+	if err := s.repos.Tokens.ReplaceRecoveryCodes(userId, records); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"errors"
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/models"
+	"final-project-golang/ratelimit"
+	"final-project-golang/repositories"
+	"strings"
+	"time"
+)
+
+type TokenPair struct {
+	Token        string
+	ExpiresAt    time.Time
+	RefreshToken string
+}
+
+// LoginResult holds the session of a user who logged in, or the challenge a
+// user with two factor authentication has to answer first.
+type LoginResult struct {
+	Tokens    TokenPair
+	Challenge *LoginChallenge
+}
+
+type LoginChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+type UserProfile struct {
+	User           models.User
+	PhotoCount     int64
+	CommentCount   int64
+	SocialCount    int64
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+type UserService struct {
+	repos        repositories.Repositories
+	lockout      *ratelimit.Lockout
+	challengeTTL time.Duration
+}
+
+// NewUserService builds the service. lockout may be nil to never lock an
+// email out. challengeTTL is how long a two factor login challenge lasts.
+func NewUserService(repos repositories.Repositories, lockout *ratelimit.Lockout, challengeTTL time.Duration) *UserService {
+	return &UserService{
+		repos:        repos,
+		lockout:      lockout,
+		challengeTTL: challengeTTL,
+	}
+}
+
+func (s *UserService) Register(user *models.User) error {
+	return s.repos.Users.Create(user)
+}
+
+// Login refuses an email that failed too often, even with the right
+// password, until its lockout ends. Unknown emails are counted as well.
+// Users with two factor authentication get a challenge instead of tokens,
+// and their failures are only forgotten once they answer it.
+func (s *UserService) Login(email, password string) (LoginResult, error) {
+	locked, err := s.lockout.Check(email)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if locked > 0 {
+		return LoginResult{}, errLoginLocked(locked)
+	}
+
+	user, err := s.repos.Users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return LoginResult{}, s.loginFailed(email)
+		}
+		return LoginResult{}, err
+	}
+
+	if !helpers.ComparePassword(user.Password, password) {
+		return LoginResult{}, s.loginFailed(email)
+	}
+	if user.DisabledAt != nil {
+		return LoginResult{}, ErrAccountDisabled
+	}
+
+	if !user.TwoFactorEnabled() {
+		if err := s.lockout.Succeed(email); err != nil {
+			return LoginResult{}, err
+		}
+	}
+
+	return s.startSession(*user)
+}
+
+// startSession issues tokens for a user who proved who they are, or a
+// challenge when they also have to enter a two factor code.
+func (s *UserService) startSession(user models.User) (LoginResult, error) {
+	if user.TwoFactorEnabled() {
+		token, hash, expiresAt, err := helpers.GenerateOneTimeToken(s.challengeTTL)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		err = s.repos.Tokens.CreateTwoFactorChallenge(&models.TwoFactorChallenge{
+			UserId:    user.Id,
+			TokenHash: hash,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return LoginResult{}, err
+		}
+		return LoginResult{Challenge: &LoginChallenge{Token: token, ExpiresAt: expiresAt}}, nil
+	}
+
+	tokens, err := s.issueTokens(user)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	return LoginResult{Tokens: tokens}, nil
+}
+
+// Refresh rotates a refresh token. Presenting a token that was already
+// rotated means it leaked, so every session of its owner is ended.
+func (s *UserService) Refresh(refreshToken string) (TokenPair, error) {
+	stored, err := s.repos.Tokens.FindRefreshTokenByHash(helpers.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return TokenPair{}, ErrInvalidToken
+		}
+		return TokenPair{}, err
+	}
+
+	if stored.RevokedAt != nil {
+		if err := s.RevokeAllSessions(stored.UserId); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrInvalidToken
+	}
+
+	if time.Now().After(stored.ExpiresAt) || stored.User == nil {
+		return TokenPair{}, ErrTokenExpired
+	}
+	if stored.User.DisabledAt != nil {
+		return TokenPair{}, ErrAccountDisabled
+	}
+
+	err = s.repos.Tokens.RevokeRefreshToken(stored.Id, time.Now())
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return TokenPair{}, ErrInvalidToken
+		}
+		return TokenPair{}, err
+	}
+
+	return s.issueTokens(*stored.User)
+}
+
+func (s *UserService) Logout(userId uint, jti string, expiresAt time.Time, refreshToken string) error {
+	err := s.repos.Tokens.RevokeAccessToken(&models.RevokedToken{
+		Jti:       jti,
+		UserId:    userId,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if refreshToken != "" {
+		err = s.repos.Tokens.RevokeRefreshTokenByHash(userId, helpers.HashToken(refreshToken), time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.repos.Tokens.PurgeExpired(time.Now())
+}
+
+// CheckSession reports whether an access token is still usable and returns
+// the user it belongs to.
+func (s *UserService) CheckSession(userId uint, jti string, issuedAt time.Time) (*models.User, error) {
+	revoked, err := s.repos.Tokens.IsAccessTokenRevoked(jti)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	user, err := s.repos.Users.FindById(userId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, apperrors.Unauthorized("UNAUTHORIZED")
+		}
+		return nil, err
+	}
+	// Tokens issued in the same microsecond as the revocation are revoked too.
+	if user.TokensRevokedAt != nil && !issuedAt.After(user.TokensRevokedAt.Truncate(time.Microsecond)) {
+		return nil, ErrTokenRevoked
+	}
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	return user, nil
+}
+
+// RevokeAllSessions invalidates every access and refresh token of the user.
+func (s *UserService) RevokeAllSessions(userId uint) error {
+	now := time.Now()
+
+	if err := s.repos.Users.SetTokensRevokedAt(userId, now); err != nil {
+		return err
+	}
+
+	return s.repos.Tokens.RevokeAllRefreshTokens(userId, now)
+}
+
+// Disable blocks the user from logging in and ends every session.
+func (s *UserService) Disable(id uint) error {
+	now := time.Now()
+
+	if err := s.repos.Users.SetDisabledAt(id, &now); err != nil {
+		return err
+	}
+
+	return s.RevokeAllSessions(id)
+}
+
+// Enable lets a disabled user log in again.
+func (s *UserService) Enable(id uint) error {
+	return s.repos.Users.SetDisabledAt(id, nil)
+}
+
+// SetRole changes the role of the user. It takes effect on the next request
+// since the role is read on every authenticated call.
+func (s *UserService) SetRole(id uint, role string) error {
+	if !ValidRole(role) {
+		return apperrors.Validation(apperrors.FieldError{
+			Field:   "role",
+			Rule:    "in",
+			Message: "role must be user, moderator or admin",
+		})
+	}
+
+	return s.repos.Users.SetRole(id, role)
+}
+
+// ResetPassword replaces the password of the user and ends every session.
+func (s *UserService) ResetPassword(id uint, password string) error {
+	user, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	return s.setPassword(user, password)
+}
+
+// setPassword stores a new password, voids outstanding reset tokens, lifts a
+// login lockout and ends every session of the user.
+func (s *UserService) setPassword(user *models.User, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := user.SetPassword(password, now); err != nil {
+		return err
+	}
+	if err := s.repos.Users.SetPassword(user.Id, user.Password, now); err != nil {
+		return err
+	}
+	if err := s.repos.Tokens.UseAllPasswordResets(user.Id, now); err != nil {
+		return err
+	}
+	if err := s.lockout.Succeed(user.Email); err != nil {
+		return err
+	}
+
+	return s.RevokeAllSessions(user.Id)
+}
+
+// IssueTokens creates a session for the user without checking a password.
+func (s *UserService) IssueTokens(id uint) (TokenPair, error) {
+	user, err := s.Get(id)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return s.issueTokens(*user)
+}
+
+func (s *UserService) Get(id uint) (*models.User, error) {
+	user, err := s.repos.Users.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "user not found")
+	}
+
+	return user, nil
+}
+
+func (s *UserService) GetByEmail(email string) (*models.User, error) {
+	user, err := s.repos.Users.FindByEmail(email)
+	if err != nil {
+		return nil, notFound(err, "user not found")
+	}
+
+	return user, nil
+}
+
+func (s *UserService) List(query repositories.ListQuery) ([]models.User, int64, error) {
+	return s.repos.Users.List(query)
+}
+
+func (s *UserService) GetProfile(id uint) (UserProfile, error) {
+	user, err := s.repos.Users.FindById(id)
+	if err != nil {
+		return UserProfile{}, notFound(err, "user not found")
+	}
+
+	profile := UserProfile{User: *user}
+
+	if profile.PhotoCount, err = s.repos.Photos.CountByUser(id); err != nil {
+		return UserProfile{}, err
+	}
+	if profile.CommentCount, err = s.repos.Comments.CountByUser(id); err != nil {
+		return UserProfile{}, err
+	}
+	if profile.SocialCount, err = s.repos.Socials.CountByUser(id); err != nil {
+		return UserProfile{}, err
+	}
+	if profile.FollowerCount, err = s.repos.Follows.CountFollowers(id); err != nil {
+		return UserProfile{}, err
+	}
+	if profile.FollowingCount, err = s.repos.Follows.CountFollowing(id); err != nil {
+		return UserProfile{}, err
+	}
+
+	return profile, nil
+}
+
+func (s *UserService) Update(id uint, changes models.User) (*models.User, error) {
+	user, err := s.repos.Users.FindById(id)
+	if err != nil {
+		return nil, notFound(err, "user not found")
+	}
+
+	emailChanged := changes.Email != "" && !strings.EqualFold(changes.Email, user.Email)
+
+	if err := s.repos.Users.Update(user, changes); err != nil {
+		return nil, err
+	}
+
+	// A new email has to be verified again.
+	if emailChanged && user.Verified() {
+		if err := s.repos.Users.SetEmailVerifiedAt(user.Id, nil); err != nil {
+			return nil, err
+		}
+		user.EmailVerifiedAt = nil
+	}
+
+	return user, nil
+}
+
+// SetPrivate switches follow approval on or off. Making an account public
+// accepts every pending request.
+func (s *UserService) SetPrivate(id uint, private bool) error {
+	if err := s.repos.Users.SetPrivate(id, private); err != nil {
+		return err
+	}
+	if private {
+		return nil
+	}
+
+	return s.repos.Follows.AcceptPending(id, time.Now())
+}
+
+func (s *UserService) Delete(id uint) error {
+	user, err := s.repos.Users.FindById(id)
+	if err != nil {
+		return notFound(err, "user not found")
+	}
+
+	if err := s.RevokeAllSessions(user.Id); err != nil {
+		return err
+	}
+
+	return s.repos.Users.Delete(user)
+}
+
+func validatePassword(password string) error {
+	if !helpers.IsStrongPassword(password) {
+		return apperrors.Validation(apperrors.FieldError{
+			Field:   "password",
+			Rule:    "password",
+			Message: "password must be at least 8 characters and contain a letter and a digit",
+		})
+	}
+
+	return nil
+}
+
+func (s *UserService) loginFailed(email string) error {
+	if _, err := s.lockout.Fail(email); err != nil {
+		return err
+	}
+
+	return ErrInvalidCredentials
+}
+
+func errLoginLocked(retryAfter time.Duration) error {
+	return apperrors.TooManyRequests("too many failed logins, try again later", retryAfter)
+}
+
+func (s *UserService) issueTokens(user models.User) (TokenPair, error) {
+	token, expiresAt, err := helpers.GenerateToken(user.Id, user.Email)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refreshToken, refreshHash, refreshExpiresAt, err := helpers.GenerateRefreshToken()
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	err = s.repos.Tokens.CreateRefreshToken(&models.RefreshToken{
+		UserId:    user.Id,
+		TokenHash: refreshHash,
+		ExpiresAt: refreshExpiresAt,
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		Token:        token,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"final-project-golang/apperrors"
+	"final-project-golang/helpers"
+	"final-project-golang/mailer"
+	"final-project-golang/models"
+	"final-project-golang/repositories"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAlreadyVerified          = apperrors.New(apperrors.CodeConflict, "email is already verified")
+	ErrInvalidVerificationToken = apperrors.BadRequest("verification token is invalid or has expired")
+	ErrEmailNotVerified         = apperrors.Forbidden("verify your email address first")
+)
+
+// VerificationService mails signed links that prove a user owns their email.
+// The links aren't stored; one stops working once the email changes.
+type VerificationService struct {
+	repos  repositories.Repositories
+	mailer mailer.Mailer
+	ttl    time.Duration
+	url    string
+}
+
+func NewVerificationService(repos repositories.Repositories, mailer mailer.Mailer, ttl time.Duration, url string) *VerificationService {
+	return &VerificationService{
+		repos:  repos,
+		mailer: mailer,
+		ttl:    ttl,
+		url:    url,
+	}
+}
+
+// Send mails a verification link to the current email of user.
+func (s *VerificationService) Send(user *models.User) error {
+	token, _, err := helpers.GenerateVerificationToken(user.Id, user.Email, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body:    s.body(user.Username, token),
+	})
+}
+
+// Resend mails a new link to a user who hasn't verified yet.
+func (s *VerificationService) Resend(userId uint) error {
+	user, err := s.repos.Users.FindById(userId)
+	if err != nil {
+		return notFound(err, "user not found")
+	}
+	if user.Verified() {
+		return ErrAlreadyVerified
+	}
+
+	return s.Send(user)
+}
+
+// Verify marks the email in token as verified. It returns the account as it
+// was before and after.
+func (s *VerificationService) Verify(token string) (models.User, *models.User, error) {
+	userId, email, err := helpers.ParseVerificationToken(token)
+	if err != nil {
+		return models.User{}, nil, ErrInvalidVerificationToken
+	}
+
+	user, err := s.repos.Users.FindById(userId)
+	if err != nil {
+		return models.User{}, nil, notFoundAs(err, ErrInvalidVerificationToken)
+	}
+	if !strings.EqualFold(user.Email, email) {
+		return models.User{}, nil, ErrInvalidVerificationToken
+	}
+
+	before := *user
+	if user.Verified() {
+		return before, user, nil
+	}
+
+	now := time.Now()
+	if err := s.repos.Users.SetEmailVerifiedAt(user.Id, &now); err != nil {
+		return models.User{}, nil, err
+	}
+	user.EmailVerifiedAt = &now
+
+	return before, user, nil
+}
+
+func (s *VerificationService) body(username, token string) string {
+	lines := []string{
+		fmt.Sprintf("Hi %s,", username),
+		"",
+		"Please confirm this is your email address.",
+		"",
+	}
+	if s.url != "" {
+		separator := "?"
+		if strings.Contains(s.url, "?") {
+			separator = "&"
+		}
+		lines = append(lines, "Open "+s.url+separator+"token="+url.QueryEscape(token))
+	} else {
+		lines = append(lines, "Your verification token is "+token)
+	}
+	lines = append(lines, "", fmt.Sprintf("It expires in %s.", s.ttl))
+
+	return strings.Join(lines, "\n")
+}
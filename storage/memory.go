@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+type memoryObject struct {
+	data   []byte
+	object Object
+}
+
+// memoryStorage keeps objects in memory, for running without a disk or
+// bucket alongside the in-memory repositories.
+type memoryStorage struct {
+	mu        sync.RWMutex
+	objects   map[string]memoryObject
+	publicURL string
+}
+
+func NewMemory(publicURL string) Storage {
+	return &memoryStorage{
+		objects:   make(map[string]memoryObject),
+		publicURL: publicURL,
+	}
+}
+
+func (s *memoryStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.objects[key] = memoryObject{
+		data: data,
+		object: Object{
+			Key:          key,
+			ContentType:  contentType,
+			Size:         int64(len(data)),
+			LastModified: time.Now(),
+		},
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.objects[key]
+	if !ok {
+		return nil, Object{}, ErrNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(stored.data)), stored.object, nil
+}
+
+func (s *memoryStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.objects, key)
+
+	return nil
+}
+
+func (s *memoryStorage) URL(key string) string {
+	return publicURL(s.publicURL, key)
+}
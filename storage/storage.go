@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"final-project-golang/config"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+type Object struct {
+	Key          string
+	ContentType  string
+	Size         int64
+	LastModified time.Time
+}
+
+// Storage keeps uploaded files. Keys are slash separated relative paths
+// such as "photos/2024/10/3f2a....jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns the address clients use to download the object.
+	URL(key string) string
+}
+
+// New builds the storage backend selected in the configuration.
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocal(cfg.LocalDir, cfg.PublicURL)
+	case "s3":
+		return NewS3(cfg.S3, cfg.PublicURL)
+	case "memory":
+		return NewMemory(cfg.PublicURL), nil
+	}
+
+	return nil, fmt.Errorf("storage: unknown driver %q", cfg.Driver)
+}
+
+// NewKey returns a random, unguessable key under prefix.
+func NewKey(prefix, extension string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return path.Join(prefix, time.Now().UTC().Format("2006/01"), hex.EncodeToString(buf)+extension), nil
+}
+
+// ValidateKey rejects keys that could escape the storage root.
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
+
+func publicURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}